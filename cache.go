@@ -0,0 +1,230 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTLs returns cache lifetimes suited to endpoints that change rarely, for use with
+// SetCache. Endpoints are named as in RequestInfo.Endpoint.
+func DefaultCacheTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		"vehicles":       30 * time.Second,
+		"vehicle":        30 * time.Second,
+		"vehicle_config": 24 * time.Hour,
+		"gui_settings":   time.Hour,
+		"mobile_enabled": time.Hour,
+	}
+}
+
+// commandInvalidates lists the endpoints whose cached responses are discarded after a command
+// succeeds, by command name prefix. Commands not listed discard every response for the vehicle.
+var commandInvalidates = []struct {
+	prefix    string
+	endpoints []string
+}{
+	{"charge_", []string{"charge_state"}},
+	{"set_charge_limit", []string{"charge_state"}},
+	{"auto_conditioning_", []string{"climate_state"}},
+	{"set_temps", []string{"climate_state"}},
+	{"set_preconditioning_max", []string{"climate_state"}},
+	{"remote_seat_heater_request", []string{"climate_state"}},
+	{"remote_steering_wheel_heater_request", []string{"climate_state"}},
+	{"door_", []string{"vehicle_state"}},
+	{"actuate_trunk", []string{"vehicle_state"}},
+	{"sun_roof_control", []string{"vehicle_state"}},
+	{"window_control", []string{"vehicle_state"}},
+	{"set_sentry_mode", []string{"vehicle_state"}},
+	{"set_valet_mode", []string{"vehicle_state"}},
+	{"reset_valet_pin", []string{"vehicle_state"}},
+	{"speed_limit_", []string{"vehicle_state"}},
+	{"media_", []string{"vehicle_state"}},
+	{"schedule_software_update", []string{"vehicle_state"}},
+	{"cancel_software_update", []string{"vehicle_state"}},
+	{"remote_start_drive", []string{"vehicle_state", "drive_state"}},
+	{"honk_horn", nil},
+	{"flash_lights", nil},
+	{"trigger_homelink", nil},
+	{"share", nil},
+}
+
+// SetCache turns on caching of successful GET responses, with a lifetime for each endpoint, named
+// as in RequestInfo.Endpoint. Endpoints without a lifetime are not cached. Pass nil to turn
+// caching off.
+//
+// Cached responses are discarded when a related command succeeds; for example, SetChargeLimit
+// discards the cached charge state. Waking a vehicle discards its cached vehicle summaries. To
+// force a fresh read, call InvalidateCache before the getter.
+//
+// Hooks are not called for responses served from the cache.
+func (c *Conn) SetCache(ttls map[string]time.Duration) {
+	if ttls == nil {
+		c.cache = nil
+		return
+	}
+
+	c.cache = newResponseCache(ttls)
+}
+
+// InvalidateCache discards cached responses for the vehicle with the given id, or for the account
+// if id is zero. If no endpoints are given, every cached response for the vehicle or account is
+// discarded.
+func (c *Conn) InvalidateCache(id int, endpoints ...string) {
+	if c.cache == nil {
+		return
+	}
+
+	c.cache.invalidate(id, endpoints...)
+}
+
+type cacheEntry struct {
+	endpoint  string
+	vehicleID int
+	raw       RawResponse
+	expires   time.Time
+}
+
+type responseCache struct {
+	mu      sync.Mutex
+	ttls    map[string]time.Duration
+	entries map[string]cacheEntry
+
+	now func() time.Time
+}
+
+func newResponseCache(ttls map[string]time.Duration) *responseCache {
+	copied := make(map[string]time.Duration, len(ttls))
+	for endpoint, ttl := range ttls {
+		copied[endpoint] = ttl
+	}
+
+	return &responseCache{
+		ttls:    copied,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+func (rc *responseCache) get(url string) (*RawResponse, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.entries[url]
+	if !ok {
+		return nil, false
+	}
+
+	if !rc.now().Before(entry.expires) {
+		delete(rc.entries, url)
+		return nil, false
+	}
+
+	raw := entry.raw
+	raw.Body = append([]byte(nil), raw.Body...)
+
+	return &raw, true
+}
+
+func (rc *responseCache) put(url string, raw *RawResponse) {
+	endpoint, id := endpointName(url)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	ttl := rc.ttls[endpoint]
+	if ttl <= 0 {
+		return
+	}
+
+	rc.entries[url] = cacheEntry{
+		endpoint:  endpoint,
+		vehicleID: id,
+		raw:       *raw,
+		expires:   rc.now().Add(ttl),
+	}
+}
+
+func (rc *responseCache) invalidate(id int, endpoints ...string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for url, entry := range rc.entries {
+		if entry.vehicleID != id {
+			continue
+		}
+
+		if len(endpoints) == 0 {
+			delete(rc.entries, url)
+			continue
+		}
+
+		for _, endpoint := range endpoints {
+			if entry.endpoint == endpoint {
+				delete(rc.entries, url)
+				break
+			}
+		}
+	}
+}
+
+// changed discards the responses made stale by a successful command or other request that
+// changes state.
+func (rc *responseCache) changed(info *RequestInfo) {
+	if info.Endpoint == "wake_up" {
+		rc.invalidate(0, "vehicles")
+		rc.invalidate(info.VehicleID, "vehicle")
+
+		return
+	}
+
+	command := strings.TrimPrefix(info.Endpoint, "command/")
+
+	for _, inv := range commandInvalidates {
+		if strings.HasPrefix(command, inv.prefix) {
+			if len(inv.endpoints) > 0 {
+				rc.invalidate(info.VehicleID, inv.endpoints...)
+			}
+
+			return
+		}
+	}
+
+	rc.invalidate(info.VehicleID)
+}
+
+// doCachedRequest performs the request, serving GET requests from the cache if it is on.
+func (c *Conn) doCachedRequest(method, url string, reqBody, respBody interface{}) (*RawResponse, error) {
+	cache := c.cache
+	if cache == nil {
+		return c.doObservedRequest(method, url, reqBody, respBody, nil)
+	}
+
+	if method != http.MethodGet {
+		raw, err := c.doObservedRequest(method, url, reqBody, respBody, nil)
+		if err == nil {
+			cache.changed(newRequestInfo(method, url))
+		}
+
+		return raw, err
+	}
+
+	if raw, ok := cache.get(url); ok {
+		err := json.Unmarshal(raw.Body, respBody)
+		if err != nil {
+			return raw, fmt.Errorf("error unmarshaling response: %w", err)
+		}
+
+		return raw, nil
+	}
+
+	raw, err := c.doObservedRequest(method, url, reqBody, respBody, nil)
+	if err == nil {
+		cache.put(url, raw)
+	}
+
+	return raw, err
+}
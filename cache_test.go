@@ -0,0 +1,78 @@
+package tesla
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCacheExpires(t *testing.T) {
+	now := time.Unix(1580000000, 0)
+
+	rc := newResponseCache(map[string]time.Duration{"gui_settings": time.Hour})
+	rc.now = func() time.Time { return now }
+
+	url := "/api/1/vehicles/1/data_request/gui_settings"
+	rc.put(url, &RawResponse{StatusCode: http.StatusOK, Body: []byte(`{}`)})
+	rc.put("/api/1/vehicles/1/data_request/drive_state", &RawResponse{StatusCode: http.StatusOK})
+
+	_, ok := rc.get(url)
+	assert.True(t, ok)
+
+	_, ok = rc.get("/api/1/vehicles/1/data_request/drive_state")
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+
+	_, ok = rc.get(url)
+	assert.False(t, ok)
+}
+
+func TestResponseCacheChanged(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		kept     []string
+	}{
+		{endpoint: "command/set_charge_limit", kept: []string{"climate_state", "vehicle_state", "vehicles"}},
+		{endpoint: "command/charge_port_door_open", kept: []string{"climate_state", "vehicle_state", "vehicles"}},
+		{endpoint: "command/set_temps", kept: []string{"charge_state", "vehicle_state", "vehicles"}},
+		{endpoint: "command/door_lock", kept: []string{"charge_state", "climate_state", "vehicles"}},
+		{endpoint: "command/honk_horn", kept: []string{"charge_state", "climate_state", "vehicle_state", "vehicles"}},
+		{endpoint: "command/something_new", kept: []string{"vehicles"}},
+		{endpoint: "wake_up", kept: []string{"charge_state", "climate_state", "vehicle_state"}},
+	}
+
+	urls := map[string]string{
+		"charge_state":  "/api/1/vehicles/1/data_request/charge_state",
+		"climate_state": "/api/1/vehicles/1/data_request/climate_state",
+		"vehicle_state": "/api/1/vehicles/1/data_request/vehicle_state",
+		"vehicles":      "/api/1/vehicles",
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			ttls := make(map[string]time.Duration)
+			for endpoint := range urls {
+				ttls[endpoint] = time.Hour
+			}
+
+			rc := newResponseCache(ttls)
+			for _, url := range urls {
+				rc.put(url, &RawResponse{StatusCode: http.StatusOK})
+			}
+
+			rc.changed(&RequestInfo{Endpoint: tt.endpoint, VehicleID: 1})
+
+			var kept []string
+			for _, endpoint := range []string{"charge_state", "climate_state", "vehicle_state", "vehicles"} {
+				if _, ok := rc.get(urls[endpoint]); ok {
+					kept = append(kept, endpoint)
+				}
+			}
+
+			assert.Equal(t, tt.kept, kept)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package tesla
+
+import (
+	"fmt"
+)
+
+// Capabilities describes which optional features a vehicle has, as reported by its VehicleConfig
+// and VehicleState.
+type Capabilities struct {
+	CarType                     string
+	CanActuateTrunks            bool
+	MotorizedChargePort         bool
+	SunRoofInstalled            bool
+	RearSeatHeaters             bool
+	CanAcceptNavigationRequests bool
+	RemoteStartSupported        bool
+	SentryModeAvailable         bool
+	HomelinkAvailable           bool
+
+	configKnown bool
+	stateKnown  bool
+}
+
+// NewCapabilities derives the capabilities of a vehicle from its config and state. Either may be
+// nil, in which case the features they describe are treated as unknown and never reported as
+// unsupported.
+func NewCapabilities(config *VehicleConfig, state *VehicleState) Capabilities {
+	var caps Capabilities
+
+	caps.applyConfig(config)
+	caps.applyState(state)
+
+	return caps
+}
+
+func (caps *Capabilities) applyConfig(config *VehicleConfig) {
+	if config == nil {
+		return
+	}
+
+	caps.CarType = config.CarType
+	caps.CanActuateTrunks = config.CanActuateTrunks
+	caps.MotorizedChargePort = config.MotorizedChargePort
+	caps.SunRoofInstalled = config.SunRoofInstalled > 0
+	caps.RearSeatHeaters = config.RearSeatHeaters > 0
+	caps.CanAcceptNavigationRequests = config.CanAcceptNavigationRequests
+	caps.configKnown = true
+}
+
+func (caps *Capabilities) applyState(state *VehicleState) {
+	if state == nil {
+		return
+	}
+
+	caps.RemoteStartSupported = state.RemoteStartSupported
+	caps.SentryModeAvailable = state.SentryModeAvailable
+	caps.HomelinkAvailable = state.HomelinkDeviceCount > 0
+	caps.stateKnown = true
+}
+
+// SetCapabilityChecks turns pre-flight capability checks on or off. If on, commands for features
+// the vehicle does not have return ErrUnsupported without calling the API. Capabilities are
+// learned from GetVehicleConfig and GetVehicleState, or may be provided with SetCapabilities.
+func (c *Conn) SetCapabilityChecks(on bool) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	c.capsCheck = on
+}
+
+// SetCapabilities overrides the capabilities used for pre-flight checks of the given vehicle. Every
+// field of caps is taken as known, so a feature left false is reported as unsupported.
+func (c *Conn) SetCapabilities(id int, caps Capabilities) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	if c.caps == nil {
+		c.caps = make(map[int]Capabilities)
+	}
+
+	caps.configKnown = true
+	caps.stateKnown = true
+	c.caps[id] = caps
+}
+
+// Capabilities returns the capabilities currently known for the given vehicle.
+func (c *Conn) Capabilities(id int) (Capabilities, bool) {
+	c.capsMu.RLock()
+	defer c.capsMu.RUnlock()
+
+	caps, ok := c.caps[id]
+
+	return caps, ok
+}
+
+func (c *Conn) learnCapabilities(id int, config *VehicleConfig, state *VehicleState) {
+	c.capsMu.Lock()
+	defer c.capsMu.Unlock()
+
+	if c.caps == nil {
+		c.caps = make(map[int]Capabilities)
+	}
+
+	caps := c.caps[id]
+	caps.applyConfig(config)
+	caps.applyState(state)
+	c.caps[id] = caps
+}
+
+// requireConfigCapability returns ErrUnsupported if checks are on, the vehicle's config is known,
+// and the feature is missing.
+func (c *Conn) requireConfigCapability(id int, feature string, has func(Capabilities) bool) error {
+	c.capsMu.RLock()
+	defer c.capsMu.RUnlock()
+
+	caps, ok := c.caps[id]
+	if !c.capsCheck || !ok || !caps.configKnown || has(caps) {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", feature, ErrUnsupported)
+}
+
+// requireStateCapability returns ErrUnsupported if checks are on, the vehicle's state is known,
+// and the feature is missing.
+func (c *Conn) requireStateCapability(id int, feature string, has func(Capabilities) bool) error {
+	c.capsMu.RLock()
+	defer c.capsMu.RUnlock()
+
+	caps, ok := c.caps[id]
+	if !c.capsCheck || !ok || !caps.stateKnown || has(caps) {
+		return nil
+	}
+
+	return fmt.Errorf("%s: %w", feature, ErrUnsupported)
+}
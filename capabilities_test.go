@@ -0,0 +1,211 @@
+package tesla
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+// fakeAPI answers the requests of a Conn without a network. Data requests are answered from
+// states, by endpoint name, and every command succeeds. The paths requested are recorded.
+type fakeAPI struct {
+	states map[string]string
+	paths  []string
+}
+
+func newFakeConn() (*Conn, *fakeAPI) {
+	api := &fakeAPI{states: make(map[string]string)}
+
+	conn := NewConn(api, "https://owner-api.example.com", "", "")
+	conn.SetAccessToken("token")
+
+	return conn, api
+}
+
+func (api *fakeAPI) RoundTrip(req *http.Request) (*http.Response, error) {
+	api.paths = append(api.paths, req.URL.Path)
+
+	body := `{"response": {"result": true, "reason": ""}}`
+
+	if i := strings.Index(req.URL.Path, "/data_request/"); i >= 0 {
+		body = `{"response": ` + api.states[req.URL.Path[i+len("/data_request/"):]] + `}`
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// commands returns the names of the commands sent.
+func (api *fakeAPI) commands() []string {
+	var names []string
+
+	for _, path := range api.paths {
+		if i := strings.Index(path, "/command/"); i >= 0 {
+			names = append(names, path[i+len("/command/"):])
+		}
+	}
+
+	return names
+}
+
+func TestCapabilityChecks(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		// set adds or removes the feature from the config and state.
+		set func(config *VehicleConfig, state *VehicleState, has bool)
+		run func(conn *Conn) error
+	}{
+		{
+			name:    "remote start",
+			command: "remote_start_drive",
+			set:     func(config *VehicleConfig, state *VehicleState, has bool) { state.RemoteStartSupported = has },
+			run:     func(conn *Conn) error { return conn.RemoteStart(1, "password") },
+		},
+		{
+			name:    "homelink",
+			command: "trigger_homelink",
+			set: func(config *VehicleConfig, state *VehicleState, has bool) {
+				if has {
+					state.HomelinkDeviceCount = 1
+				}
+			},
+			run: func(conn *Conn) error { return conn.TriggerHomelink(1, 37.4919, -121.9447) },
+		},
+		{
+			name:    "sentry mode",
+			command: "set_sentry_mode",
+			set:     func(config *VehicleConfig, state *VehicleState, has bool) { state.SentryModeAvailable = has },
+			run:     func(conn *Conn) error { return conn.SetSentryMode(1, true) },
+		},
+		{
+			name:    "rear trunk",
+			command: "actuate_trunk",
+			set:     func(config *VehicleConfig, state *VehicleState, has bool) { config.CanActuateTrunks = has },
+			run:     func(conn *Conn) error { return conn.OpenTrunk(1, TrunkRear) },
+		},
+		{
+			name:    "sunroof",
+			command: "sun_roof_control",
+			set: func(config *VehicleConfig, state *VehicleState, has bool) {
+				if has {
+					config.SunRoofInstalled = 1
+				}
+			},
+			run: func(conn *Conn) error { return conn.ActuateSunroof(1, SunroofCommandVent) },
+		},
+		{
+			name:    "charge port",
+			command: "charge_port_door_close",
+			set:     func(config *VehicleConfig, state *VehicleState, has bool) { config.MotorizedChargePort = has },
+			run:     func(conn *Conn) error { return conn.CloseChargePortDoor(1) },
+		},
+		{
+			name:    "rear seat heater",
+			command: "remote_seat_heater_request",
+			set: func(config *VehicleConfig, state *VehicleState, has bool) {
+				if has {
+					config.RearSeatHeaters = 1
+				}
+			},
+			run: func(conn *Conn) error { return conn.SetSeatHeater(1, SeatRearDriver, SeatHeatLevelTwo) },
+		},
+		{
+			name:    "navigation",
+			command: "share",
+			set:     func(config *VehicleConfig, state *VehicleState, has bool) { config.CanAcceptNavigationRequests = has },
+			run:     func(conn *Conn) error { return conn.Share(1, language.AmericanEnglish, "1 Infinite Loop") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// try runs the command with the feature present or not, returning whether the
+			// command reached the API.
+			try := func(has, checks, known bool) (bool, error) {
+				conn, api := newFakeConn()
+				conn.SetCapabilityChecks(checks)
+
+				if known {
+					var config VehicleConfig
+					var state VehicleState
+					tt.set(&config, &state, has)
+
+					conn.learnCapabilities(1, &config, &state)
+				}
+
+				err := tt.run(conn)
+
+				return len(api.commands()) > 0, err
+			}
+
+			sent, err := try(false, false, true)
+			assert.NoError(t, err, "checks disabled")
+			assert.True(t, sent, "checks disabled")
+
+			sent, err = try(false, true, false)
+			assert.NoError(t, err, "capabilities not yet known")
+			assert.True(t, sent, "capabilities not yet known")
+
+			sent, err = try(false, true, true)
+			assert.True(t, errors.Is(err, ErrUnsupported), "feature missing: %v", err)
+			assert.False(t, sent, "feature missing")
+
+			sent, err = try(true, true, true)
+			assert.NoError(t, err, "feature present")
+			assert.True(t, sent, "feature present")
+		})
+	}
+}
+
+func TestCapabilitiesLearned(t *testing.T) {
+	conn, api := newFakeConn()
+	conn.SetCapabilityChecks(true)
+
+	api.states["vehicle_config"] = `{"car_type": "model3", "can_actuate_trunks": true, "sun_roof_installed": 0}`
+
+	_, ok := conn.Capabilities(1)
+	assert.False(t, ok)
+
+	_, err := conn.GetVehicleConfig(1)
+	require.NoError(t, err)
+
+	caps, ok := conn.Capabilities(1)
+	require.True(t, ok)
+	assert.Equal(t, "model3", caps.CarType)
+	assert.True(t, caps.CanActuateTrunks)
+	assert.False(t, caps.SunRoofInstalled)
+
+	err = conn.ActuateSunroof(1, SunroofCommandVent)
+	assert.True(t, errors.Is(err, ErrUnsupported), "%v", err)
+	assert.EqualError(t, err, "sunroof: not supported by vehicle")
+
+	assert.NoError(t, conn.SetSentryMode(1, true), "the vehicle state is not yet known")
+	assert.Equal(t, []string{"set_sentry_mode"}, api.commands())
+}
+
+func TestSetCapabilities(t *testing.T) {
+	conn, api := newFakeConn()
+	conn.SetCapabilityChecks(true)
+	conn.SetCapabilities(1, Capabilities{CanActuateTrunks: true})
+
+	assert.NoError(t, conn.OpenTrunk(1, TrunkFront))
+
+	err := conn.ActuateSunroof(1, SunroofCommandVent)
+	assert.True(t, errors.Is(err, ErrUnsupported), "config capability: %v", err)
+
+	err = conn.SetSentryMode(1, true)
+	assert.True(t, errors.Is(err, ErrUnsupported), "state capability: %v", err)
+
+	assert.Equal(t, []string{"actuate_trunk"}, api.commands())
+}
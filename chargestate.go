@@ -1,62 +1,284 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 )
 
+// ChargingState is the current charging status of the vehicle.
+type ChargingState string
+
+var (
+	// ChargingStateCharging means the vehicle is currently charging.
+	ChargingStateCharging = ChargingState("Charging")
+	// ChargingStateComplete means the vehicle has reached its charge limit.
+	ChargingStateComplete = ChargingState("Complete")
+	// ChargingStateDisconnected means no cable is plugged into the vehicle.
+	ChargingStateDisconnected = ChargingState("Disconnected")
+	// ChargingStateStopped means the vehicle is plugged in, but charging has been stopped.
+	ChargingStateStopped = ChargingState("Stopped")
+	// ChargingStateStarting means the vehicle is negotiating with the charger to begin charging.
+	ChargingStateStarting = ChargingState("Starting")
+	// ChargingStateNoPower means the vehicle is plugged in, but the charger is not supplying power.
+	ChargingStateNoPower = ChargingState("NoPower")
+)
+
+func (s ChargingState) String() string {
+	return string(s)
+}
+
+// UnmarshalJSON accepts any JSON value. Unknown values are kept as-is and null is left empty.
+func (s *ChargingState) UnmarshalJSON(data []byte) error {
+	*s = ChargingState(unmarshalEnum(data))
+	return nil
+}
+
+// ChargePortLatch is the state of the latch holding the charge cable in the charge port.
+type ChargePortLatch string
+
+var (
+	// ChargePortLatchEngaged means the cable is locked into the charge port.
+	ChargePortLatchEngaged = ChargePortLatch("Engaged")
+	// ChargePortLatchDisengaged means the cable can be removed from the charge port.
+	ChargePortLatchDisengaged = ChargePortLatch("Disengaged")
+	// ChargePortLatchBlocking means the latch is obstructed and cannot engage.
+	ChargePortLatchBlocking = ChargePortLatch("Blocking")
+)
+
+func (l ChargePortLatch) String() string {
+	return string(l)
+}
+
+// UnmarshalJSON accepts any JSON value. Unknown values are kept as-is and null is left empty.
+func (l *ChargePortLatch) UnmarshalJSON(data []byte) error {
+	*l = ChargePortLatch(unmarshalEnum(data))
+	return nil
+}
+
+// ChargeCable is the type of charge cable connected to the vehicle.
+type ChargeCable string
+
+var (
+	// ChargeCableSAE is a North American SAE J1772 or Tesla connector.
+	ChargeCableSAE = ChargeCable("SAE")
+	// ChargeCableIEC is a European IEC 62196 (Type 2) connector.
+	ChargeCableIEC = ChargeCable("IEC")
+)
+
+func (c ChargeCable) String() string {
+	return string(c)
+}
+
+// UnmarshalJSON accepts any JSON value. Unknown values are kept as-is and null is left empty.
+func (c *ChargeCable) UnmarshalJSON(data []byte) error {
+	*c = ChargeCable(unmarshalEnum(data))
+	return nil
+}
+
+// FastChargerType is the type of DC fast charger the vehicle is connected to.
+type FastChargerType string
+
+var (
+	// FastChargerTypeSupercharger is a Tesla Supercharger.
+	FastChargerTypeSupercharger = FastChargerType("Supercharger")
+	// FastChargerTypeCHAdeMO is a CHAdeMO charger, used through an adapter.
+	FastChargerTypeCHAdeMO = FastChargerType("CHAdeMO")
+	// FastChargerTypeCCS is a Combined Charging System charger.
+	FastChargerTypeCCS = FastChargerType("CCS")
+)
+
+func (t FastChargerType) String() string {
+	return string(t)
+}
+
+// UnmarshalJSON accepts any JSON value. Unknown values are kept as-is and null is left empty.
+func (t *FastChargerType) UnmarshalJSON(data []byte) error {
+	*t = FastChargerType(unmarshalEnum(data))
+	return nil
+}
+
+// ChargerPhases is the number of AC phases supplied by the charger. It is zero when the vehicle
+// is not charging on AC power.
+type ChargerPhases int
+
+func (p ChargerPhases) String() string {
+	return strconv.Itoa(int(p))
+}
+
+// UnmarshalJSON accepts a number, a numeric string, or null. Anything else is treated as zero.
+func (p *ChargerPhases) UnmarshalJSON(data []byte) error {
+	*p = 0
+
+	val, err := strconv.Atoi(unmarshalEnum(data))
+	if err == nil {
+		*p = ChargerPhases(val)
+	}
+
+	return nil
+}
+
+// unmarshalEnum returns the string form of a JSON value. Strings are unquoted, null becomes
+// empty, and any other value is returned as its raw JSON text.
+func unmarshalEnum(data []byte) string {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		return s
+	}
+
+	if string(data) == "null" {
+		return ""
+	}
+
+	return string(data)
+}
+
 // ChargeState is the current state of charging for the vehicle.
 type ChargeState struct {
-	BatteryHeaterOn             bool        `json:"battery_heater_on"`
-	BatteryLevel                int         `json:"battery_level"`
-	BatteryRange                float64     `json:"battery_range"`
-	ChargeCurrentRequest        int         `json:"charge_current_request"`
-	ChargeCurrentRequestMax     int         `json:"charge_current_request_max"`
-	ChargeEnableRequest         bool        `json:"charge_enable_request"`
-	ChargeEnergyAdded           float64     `json:"charge_energy_added"`
-	ChargeLimitSoc              int         `json:"charge_limit_soc"`
-	ChargeLimitSocMax           int         `json:"charge_limit_soc_max"`
-	ChargeLimitSocMin           int         `json:"charge_limit_soc_min"`
-	ChargeLimitSocStd           int         `json:"charge_limit_soc_std"`
-	ChargeMilesAddedIdeal       float64     `json:"charge_miles_added_ideal"`
-	ChargeMilesAddedRated       float64     `json:"charge_miles_added_rated"`
-	ChargePortColdWeatherMode   bool        `json:"charge_port_cold_weather_mode"`
-	ChargePortDoorOpen          bool        `json:"charge_port_door_open"`
-	ChargePortLatch             string      `json:"charge_port_latch"`
-	ChargeRate                  float64     `json:"charge_rate"`
-	ChargeToMaxRange            bool        `json:"charge_to_max_range"`
-	ChargerActualCurrent        int         `json:"charger_actual_current"`
-	ChargerPhases               interface{} `json:"charger_phases"`
-	ChargerPilotCurrent         int         `json:"charger_pilot_current"`
-	ChargerPower                int         `json:"charger_power"`
-	ChargerVoltage              int         `json:"charger_voltage"`
-	ChargingState               string      `json:"charging_state"`
-	ConnChargeCable             string      `json:"conn_charge_cable"`
-	EstBatteryRange             float64     `json:"est_battery_range"`
-	FastChargerBrand            string      `json:"fast_charger_brand"`
-	FastChargerPresent          bool        `json:"fast_charger_present"`
-	FastChargerType             string      `json:"fast_charger_type"`
-	IdealBatteryRange           float64     `json:"ideal_battery_range"`
-	ManagedChargingActive       bool        `json:"managed_charging_active"`
-	ManagedChargingStartTime    *int        `json:"managed_charging_start_time"`
-	ManagedChargingUserCanceled bool        `json:"managed_charging_user_canceled"`
-	MaxRangeChargeCounter       int         `json:"max_range_charge_counter"`
-	MinutesToFullCharge         int         `json:"minutes_to_full_charge"`
-	NotEnoughPowerToHeat        bool        `json:"not_enough_power_to_heat"`
-	ScheduledChargingPending    bool        `json:"scheduled_charging_pending"`
-	ScheduledChargingStartTime  *int        `json:"scheduled_charging_start_time"`
-	ScheduledDepartureTime      *int        `json:"scheduled_departure_time"`
-	TimeToFullCharge            float64     `json:"time_to_full_charge"`
-	Timestamp                   int64       `json:"timestamp"`
-	TripCharging                bool        `json:"trip_charging"`
-	UsableBatteryLevel          int         `json:"usable_battery_level"`
-	UserChargeEnableRequest     interface{} `json:"user_charge_enable_request"`
+	BatteryHeaterOn             bool            `json:"battery_heater_on"`
+	BatteryLevel                int             `json:"battery_level"`
+	BatteryRange                float64         `json:"battery_range"`
+	ChargeCurrentRequest        int             `json:"charge_current_request"`
+	ChargeCurrentRequestMax     int             `json:"charge_current_request_max"`
+	ChargeEnableRequest         bool            `json:"charge_enable_request"`
+	ChargeEnergyAdded           float64         `json:"charge_energy_added"`
+	ChargeLimitSoc              int             `json:"charge_limit_soc"`
+	ChargeLimitSocMax           int             `json:"charge_limit_soc_max"`
+	ChargeLimitSocMin           int             `json:"charge_limit_soc_min"`
+	ChargeLimitSocStd           int             `json:"charge_limit_soc_std"`
+	ChargeMilesAddedIdeal       float64         `json:"charge_miles_added_ideal"`
+	ChargeMilesAddedRated       float64         `json:"charge_miles_added_rated"`
+	ChargePortColdWeatherMode   bool            `json:"charge_port_cold_weather_mode"`
+	ChargePortDoorOpen          bool            `json:"charge_port_door_open"`
+	ChargePortLatch             ChargePortLatch `json:"charge_port_latch"`
+	ChargeRate                  float64         `json:"charge_rate"`
+	ChargeToMaxRange            bool            `json:"charge_to_max_range"`
+	ChargerActualCurrent        int             `json:"charger_actual_current"`
+	ChargerPhases               ChargerPhases   `json:"charger_phases"`
+	ChargerPilotCurrent         int             `json:"charger_pilot_current"`
+	ChargerPower                int             `json:"charger_power"`
+	ChargerVoltage              int             `json:"charger_voltage"`
+	ChargingState               ChargingState   `json:"charging_state"`
+	ConnChargeCable             ChargeCable     `json:"conn_charge_cable"`
+	EstBatteryRange             float64         `json:"est_battery_range"`
+	FastChargerBrand            string          `json:"fast_charger_brand"`
+	FastChargerPresent          bool            `json:"fast_charger_present"`
+	FastChargerType             FastChargerType `json:"fast_charger_type"`
+	IdealBatteryRange           float64         `json:"ideal_battery_range"`
+	ManagedChargingActive       bool            `json:"managed_charging_active"`
+	ManagedChargingStartTime    *int            `json:"managed_charging_start_time"`
+	ManagedChargingUserCanceled bool            `json:"managed_charging_user_canceled"`
+	MaxRangeChargeCounter       int             `json:"max_range_charge_counter"`
+	MinutesToFullCharge         int             `json:"minutes_to_full_charge"`
+	NotEnoughPowerToHeat        bool            `json:"not_enough_power_to_heat"`
+	ScheduledChargingPending    bool            `json:"scheduled_charging_pending"`
+	ScheduledChargingStartTime  *int            `json:"scheduled_charging_start_time"`
+	ScheduledDepartureTime      *int            `json:"scheduled_departure_time"`
+	TimeToFullCharge            float64         `json:"time_to_full_charge"`
+	Timestamp                   int64           `json:"timestamp"`
+	TripCharging                bool            `json:"trip_charging"`
+	UsableBatteryLevel          int             `json:"usable_battery_level"`
+	UserChargeEnableRequest     *bool           `json:"user_charge_enable_request"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the charge state, keeping any unrecognized fields in Extra.
+func (s *ChargeState) UnmarshalJSON(data []byte) error {
+	type plain ChargeState
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the charge state, including any fields in Extra.
+func (s ChargeState) MarshalJSON() ([]byte, error) {
+	type plain ChargeState
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// BatteryRangeDistance returns the rated range remaining at the current state of charge.
+func (s *ChargeState) BatteryRangeDistance() Distance {
+	return Miles(s.BatteryRange)
+}
+
+// EstBatteryRangeDistance returns the range remaining, estimated from recent driving.
+func (s *ChargeState) EstBatteryRangeDistance() Distance {
+	return Miles(s.EstBatteryRange)
+}
+
+// IdealBatteryRangeDistance returns the ideal range remaining at the current state of charge.
+func (s *ChargeState) IdealBatteryRangeDistance() Distance {
+	return Miles(s.IdealBatteryRange)
+}
+
+// RangeAddedRated returns the rated range added during the current or last charging session.
+func (s *ChargeState) RangeAddedRated() Distance {
+	return Miles(s.ChargeMilesAddedRated)
+}
+
+// RangeAddedIdeal returns the ideal range added during the current or last charging session.
+func (s *ChargeState) RangeAddedIdeal() Distance {
+	return Miles(s.ChargeMilesAddedIdeal)
+}
+
+// RangeAddedRate returns how quickly range is being added, per hour.
+func (s *ChargeState) RangeAddedRate() Speed {
+	return MilesPerHour(s.ChargeRate)
+}
+
+// EnergyAdded returns the energy added during the current or last charging session.
+func (s *ChargeState) EnergyAdded() Energy {
+	return KilowattHours(s.ChargeEnergyAdded)
+}
+
+// ChargingPower returns the power currently supplied by the charger.
+func (s *ChargeState) ChargingPower() Power {
+	return Kilowatts(float64(s.ChargerPower))
+}
+
+// Time returns when the charge state was recorded by the vehicle.
+func (s *ChargeState) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
+}
+
+// ManagedChargingStart returns when managed charging will begin, or the zero time if it is not
+// set.
+func (s *ChargeState) ManagedChargingStart() time.Time {
+	return timeFromSecondsPtr(s.ManagedChargingStartTime)
+}
+
+// ScheduledChargingStart returns when scheduled charging will begin, or the zero time if it is not
+// set.
+func (s *ChargeState) ScheduledChargingStart() time.Time {
+	return timeFromSecondsPtr(s.ScheduledChargingStartTime)
+}
+
+// ScheduledDeparture returns the scheduled departure time, or the zero time if it is not set.
+func (s *ChargeState) ScheduledDeparture() time.Time {
+	return timeFromSecondsPtr(s.ScheduledDepartureTime)
 }
 
 // GetChargeState gets information on the state of charge in the battery and its various settings.
 func (c *Conn) GetChargeState(id int) (*ChargeState, error) {
+	val, _, err := c.GetChargeStateRaw(id)
+	return val, err
+}
+
+// GetChargeStateRaw is like GetChargeState, but also returns the raw HTTP response.
+func (c *Conn) GetChargeStateRaw(id int) (*ChargeState, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -65,10 +287,12 @@ func (c *Conn) GetChargeState(id int) (*ChargeState, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/charge_state", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/charge_state", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	c.learnChargeLimits(id, &respBody.Response)
+
+	return &respBody.Response, raw, nil
 }
@@ -0,0 +1,83 @@
+package tesla
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChargeStateEnums(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		name          string
+		json          string
+		chargingState ChargingState
+		latch         ChargePortLatch
+		cable         ChargeCable
+		fastCharger   FastChargerType
+		phases        ChargerPhases
+		enableRequest *bool
+	}{
+		{
+			name: "known values",
+			json: `{"charging_state": "Charging", "charge_port_latch": "Engaged", "conn_charge_cable": "IEC",
+				"fast_charger_type": "Supercharger", "charger_phases": 3, "user_charge_enable_request": true}`,
+			chargingState: ChargingStateCharging,
+			latch:         ChargePortLatchEngaged,
+			cable:         ChargeCableIEC,
+			fastCharger:   FastChargerTypeSupercharger,
+			phases:        3,
+			enableRequest: &yes,
+		},
+		{
+			name: "unknown values",
+			json: `{"charging_state": "Calibrating", "charge_port_latch": "<invalid>", "conn_charge_cable": "GB_AC",
+				"fast_charger_type": "ACSingleWireCAN", "charger_phases": "2", "user_charge_enable_request": false}`,
+			chargingState: ChargingState("Calibrating"),
+			latch:         ChargePortLatch("<invalid>"),
+			cable:         ChargeCable("GB_AC"),
+			fastCharger:   FastChargerType("ACSingleWireCAN"),
+			phases:        2,
+			enableRequest: &no,
+		},
+		{
+			name: "nulls",
+			json: `{"charging_state": null, "charge_port_latch": null, "conn_charge_cable": null,
+				"fast_charger_type": null, "charger_phases": null, "user_charge_enable_request": null}`,
+		},
+		{
+			name: "missing",
+			json: `{}`,
+		},
+		{
+			name:          "not strings",
+			json:          `{"charging_state": 1, "charger_phases": "three"}`,
+			chargingState: ChargingState("1"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state ChargeState
+			require.NoError(t, json.Unmarshal([]byte(tt.json), &state))
+
+			assert.Equal(t, tt.chargingState, state.ChargingState)
+			assert.Equal(t, tt.latch, state.ChargePortLatch)
+			assert.Equal(t, tt.cable, state.ConnChargeCable)
+			assert.Equal(t, tt.fastCharger, state.FastChargerType)
+			assert.Equal(t, tt.phases, state.ChargerPhases)
+			assert.Equal(t, tt.enableRequest, state.UserChargeEnableRequest)
+		})
+	}
+}
+
+func TestChargeStateEnumStrings(t *testing.T) {
+	assert.Equal(t, "Charging", ChargingStateCharging.String())
+	assert.Equal(t, "Disengaged", ChargePortLatchDisengaged.String())
+	assert.Equal(t, "SAE", ChargeCableSAE.String())
+	assert.Equal(t, "CCS", FastChargerTypeCCS.String())
+	assert.Equal(t, "3", ChargerPhases(3).String())
+}
@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // ChargingSites represents nearby Tesla-operated charging stations.
@@ -30,13 +32,54 @@ type ChargingSites struct {
 		SiteClosed      bool    `json:"site_closed"`
 	} `json:"superchargers"`
 	Timestamp int64 `json:"timestamp"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the charging sites, keeping any unrecognized fields in Extra.
+func (s *ChargingSites) UnmarshalJSON(data []byte) error {
+	type plain ChargingSites
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the charging sites, including any fields in Extra.
+func (s ChargingSites) MarshalJSON() ([]byte, error) {
+	type plain ChargingSites
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// Time returns when the list of sites was generated.
+func (s *ChargingSites) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
+}
+
+// CongestionSyncTime returns when the Supercharger stall availability was last updated.
+func (s *ChargingSites) CongestionSyncTime() time.Time {
+	return timeFromSeconds(int64(s.CongestionSyncTimeUtcSecs))
 }
 
 // GetNearbyChargingSites returns a list of nearby Tesla-operated charging stations. (Requires car
 // software version 2018.48 or higher.)
 func (c *Conn) GetNearbyChargingSites(id int) (*ChargingSites, error) {
+	val, _, err := c.GetNearbyChargingSitesRaw(id)
+	return val, err
+}
+
+// GetNearbyChargingSitesRaw is like GetNearbyChargingSites, but also returns the raw HTTP
+// response.
+func (c *Conn) GetNearbyChargingSitesRaw(id int) (*ChargingSites, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -45,10 +88,10 @@ func (c *Conn) GetNearbyChargingSites(id int) (*ChargingSites, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/nearby_charging_sites", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/nearby_charging_sites", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	return &respBody.Response, raw, nil
 }
@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // ClimateState represents the current state of climate control for the vehicle.
@@ -37,13 +39,78 @@ type ClimateState struct {
 	SteeringWheelHeater        bool    `json:"steering_wheel_heater"`
 	Timestamp                  int64   `json:"timestamp"`
 	WiperBladeHeater           bool    `json:"wiper_blade_heater"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the climate state, keeping any unrecognized fields in Extra.
+func (s *ClimateState) UnmarshalJSON(data []byte) error {
+	type plain ClimateState
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the climate state, including any fields in Extra.
+func (s ClimateState) MarshalJSON() ([]byte, error) {
+	type plain ClimateState
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// InsideTemperature returns the temperature inside the cabin.
+func (s *ClimateState) InsideTemperature() Temperature {
+	return Celsius(s.InsideTemp)
+}
+
+// OutsideTemperature returns the temperature outside the vehicle.
+func (s *ClimateState) OutsideTemperature() Temperature {
+	return Celsius(s.OutsideTemp)
+}
+
+// DriverTemperature returns the target temperature for the driver's side.
+func (s *ClimateState) DriverTemperature() Temperature {
+	return Celsius(s.DriverTempSetting)
+}
+
+// PassengerTemperature returns the target temperature for the passenger's side.
+func (s *ClimateState) PassengerTemperature() Temperature {
+	return Celsius(s.PassengerTempSetting)
+}
+
+// MinTemperature returns the lowest target temperature the vehicle accepts.
+func (s *ClimateState) MinTemperature() Temperature {
+	return Celsius(s.MinAvailTemp)
+}
+
+// MaxTemperature returns the highest target temperature the vehicle accepts.
+func (s *ClimateState) MaxTemperature() Temperature {
+	return Celsius(s.MaxAvailTemp)
+}
+
+// Time returns when the climate state was recorded by the vehicle.
+func (s *ClimateState) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
 }
 
 // GetClimateState retrieves information on the current internal temperature and climate control
 // system.
 func (c *Conn) GetClimateState(id int) (*ClimateState, error) {
+	val, _, err := c.GetClimateStateRaw(id)
+	return val, err
+}
+
+// GetClimateStateRaw is like GetClimateState, but also returns the raw HTTP response.
+func (c *Conn) GetClimateStateRaw(id int) (*ClimateState, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -52,10 +119,12 @@ func (c *Conn) GetClimateState(id int) (*ClimateState, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/climate_state", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/climate_state", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	c.learnTemperatureLimits(id, &respBody.Response)
+
+	return &respBody.Response, raw, nil
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rickbassham/tesla"
+)
+
+var (
+	vehicleLabels = []string{"vin"}
+
+	infoDesc = prometheus.NewDesc("tesla_vehicle_info",
+		"Vehicles on the account; always 1.", []string{"vin", "id", "display_name"}, nil)
+	onlineDesc = prometheus.NewDesc("tesla_vehicle_online",
+		"Whether the vehicle is online.", vehicleLabels, nil)
+	asleepDesc = prometheus.NewDesc("tesla_vehicle_asleep",
+		"Whether the vehicle is asleep.", vehicleLabels, nil)
+	updatedDesc = prometheus.NewDesc("tesla_vehicle_updated_timestamp_seconds",
+		"When the vehicle state below was last polled. It is not polled while the vehicle is asleep or idle.", vehicleLabels, nil)
+
+	batteryLevelDesc = prometheus.NewDesc("tesla_battery_level_percent",
+		"State of charge of the battery.", vehicleLabels, nil)
+	batteryRangeDesc = prometheus.NewDesc("tesla_battery_range_miles",
+		"Rated range of the battery.", vehicleLabels, nil)
+	chargeLimitDesc = prometheus.NewDesc("tesla_charge_limit_percent",
+		"Charge limit.", vehicleLabels, nil)
+	chargerPowerDesc = prometheus.NewDesc("tesla_charger_power_kilowatts",
+		"Power being delivered by the charger.", vehicleLabels, nil)
+	energyAddedDesc = prometheus.NewDesc("tesla_charge_energy_added_kilowatt_hours",
+		"Energy added in the current or most recent charging session.", vehicleLabels, nil)
+	chargingDesc = prometheus.NewDesc("tesla_charging",
+		"Whether the vehicle is charging.", vehicleLabels, nil)
+
+	insideTempDesc = prometheus.NewDesc("tesla_inside_temperature_celsius",
+		"Temperature inside the vehicle.", vehicleLabels, nil)
+	outsideTempDesc = prometheus.NewDesc("tesla_outside_temperature_celsius",
+		"Temperature outside the vehicle.", vehicleLabels, nil)
+	climateOnDesc = prometheus.NewDesc("tesla_climate_on",
+		"Whether climate control is on.", vehicleLabels, nil)
+
+	odometerDesc = prometheus.NewDesc("tesla_odometer_miles",
+		"Odometer reading.", vehicleLabels, nil)
+	lockedDesc = prometheus.NewDesc("tesla_locked",
+		"Whether the vehicle is locked.", vehicleLabels, nil)
+	sentryDesc = prometheus.NewDesc("tesla_sentry_mode",
+		"Whether sentry mode is on.", vehicleLabels, nil)
+)
+
+// collector exports the data last polled by a poller. Collecting never makes requests, so
+// scraping cannot keep a vehicle awake.
+type collector struct {
+	poller *poller
+}
+
+func (c collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		infoDesc, onlineDesc, asleepDesc, updatedDesc,
+		batteryLevelDesc, batteryRangeDesc, chargeLimitDesc, chargerPowerDesc, energyAddedDesc, chargingDesc,
+		insideTempDesc, outsideTempDesc, climateOnDesc,
+		odometerDesc, lockedDesc, sentryDesc,
+	} {
+		ch <- desc
+	}
+}
+
+func (c collector) Collect(ch chan<- prometheus.Metric) {
+	for _, d := range c.poller.snapshot() {
+		vin := d.vehicle.VIN
+
+		gauge := func(desc *prometheus.Desc, value float64) {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, vin)
+		}
+
+		ch <- prometheus.MustNewConstMetric(infoDesc, prometheus.GaugeValue, 1,
+			vin, strconv.Itoa(d.vehicle.ID), d.vehicle.DisplayName)
+
+		gauge(onlineDesc, boolValue(d.vehicle.State == "online"))
+		gauge(asleepDesc, boolValue(d.vehicle.State == "asleep"))
+
+		if d.updated.IsZero() {
+			continue
+		}
+
+		gauge(updatedDesc, float64(d.updated.UnixNano())/1e9)
+
+		if s := d.charge; s != nil {
+			gauge(batteryLevelDesc, float64(s.BatteryLevel))
+			gauge(batteryRangeDesc, s.BatteryRange)
+			gauge(chargeLimitDesc, float64(s.ChargeLimitSoc))
+			gauge(chargerPowerDesc, float64(s.ChargerPower))
+			gauge(energyAddedDesc, s.ChargeEnergyAdded)
+			gauge(chargingDesc, boolValue(s.ChargingState == tesla.ChargingStateCharging))
+		}
+
+		if s := d.climate; s != nil {
+			gauge(insideTempDesc, s.InsideTemp)
+			gauge(outsideTempDesc, s.OutsideTemp)
+			gauge(climateOnDesc, boolValue(s.IsClimateOn))
+		}
+
+		if s := d.state; s != nil {
+			gauge(odometerDesc, s.Odometer)
+			gauge(lockedDesc, boolValue(s.Locked))
+			gauge(sentryDesc, boolValue(s.SentryMode))
+		}
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,152 @@
+// Command tesla-exporter serves Prometheus metrics for the vehicles on an account: battery,
+// charging, climate, odometer, lock, and sentry state, and whether each vehicle is online or
+// asleep. It also exports the tesla_requests_total and related metrics for its own API requests.
+//
+// Usage:
+//
+//	tesla-exporter [-listen :9617] [-interval 1m] [-idle-after 15m] [-sleep-window 30m]
+//
+// It uses the credentials saved by "tesla login". Vehicles are polled in the background, and
+// /metrics reports the latest values, so scraping never makes requests. Polling is arranged so
+// vehicles can sleep: the vehicle list, which never wakes a vehicle, is polled every interval, but
+// the state of a vehicle is only polled while it is online, and once it has been idle for
+// -idle-after, polling stops for -sleep-window to let it fall asleep. The
+// tesla_vehicle_updated_timestamp_seconds metric tells how fresh the state metrics are.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/promhooks"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	exporter, err := setup(os.Args[1:], os.Stderr)
+	if err == nil {
+		err = exporter.run(ctx)
+	}
+
+	if err != nil && err != flag.ErrHelp {
+		fmt.Fprintln(os.Stderr, "tesla-exporter:", err)
+		os.Exit(1)
+	}
+}
+
+type exporter struct {
+	poller   *poller
+	interval time.Duration
+	server   *http.Server
+	logger   tesla.Logger
+}
+
+// setup parses the flags and returns the exporter to run.
+func setup(args []string, stderr io.Writer) (*exporter, error) {
+	flags := flag.NewFlagSet("tesla-exporter", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	listen := flags.String("listen", ":9617", "address to serve metrics on")
+	configPath := flags.String("config", os.Getenv("TESLA_CONFIG"), "path of the credentials file saved by tesla login")
+	interval := flags.Duration("interval", time.Minute, "how often to poll")
+	idleAfter := flags.Duration("idle-after", 15*time.Minute, "how long to keep polling a vehicle after it stops being used")
+	sleepWindow := flags.Duration("sleep-window", 30*time.Minute, "how long to stop polling an idle vehicle so it can sleep")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	session, err := credentials.Open(*configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	requests := promhooks.NewCollector()
+	session.Conn.AddHooks(requests.Hooks())
+
+	p := newPoller(session.Conn, *idleAfter, *sleepWindow)
+	p.call = session.Call
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector{poller: p}, requests)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+
+	return &exporter{
+		poller:   p,
+		interval: *interval,
+		server:   &http.Server{Addr: *listen, Handler: mux},
+		logger:   tesla.NewTextLogger(stderr),
+	}, nil
+}
+
+// shutdownTimeout is how long to wait for in-flight scrapes when shutting down.
+const shutdownTimeout = 5 * time.Second
+
+// run polls in the background and serves metrics until ctx is done, then shuts the server down
+// and stops polling.
+func (e *exporter) run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	polling := make(chan struct{})
+
+	go func() {
+		defer close(polling)
+		e.poll(ctx)
+	}()
+
+	shutdown := make(chan error, 1)
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelShutdown()
+
+		shutdown <- e.server.Shutdown(shutdownCtx)
+	}()
+
+	err := e.server.ListenAndServe()
+
+	cancel()
+	<-polling
+
+	if err == http.ErrServerClosed {
+		return <-shutdown
+	}
+
+	return err
+}
+
+// poll polls every interval until ctx is done.
+func (e *exporter) poll(ctx context.Context) {
+	tick := time.NewTicker(e.interval)
+	defer tick.Stop()
+
+	for {
+		if err := e.poller.poll(); err != nil {
+			e.logger.Warn("error polling vehicles", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
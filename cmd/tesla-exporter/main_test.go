@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+const vin = teslatest.VIN
+
+// newTestPoller returns a poller that uses the server's simulated clock.
+func newTestPoller(t *testing.T) (*poller, *teslatest.Server) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	p := newPoller(conn, 15*time.Minute, 30*time.Minute)
+	p.now = srv.Now
+
+	return p, srv
+}
+
+// dataRequests counts the requests that would keep a vehicle awake.
+func dataRequests(srv *teslatest.Server) int {
+	n := 0
+
+	for _, req := range srv.Requests() {
+		if strings.Contains(req.Path, "/data_request/") {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestPollerLetsIdleVehiclesSleep(t *testing.T) {
+	p, srv := newTestPoller(t)
+
+	poll := func(d time.Duration) int {
+		srv.Advance(d)
+		before := dataRequests(srv)
+		require.NoError(t, p.poll())
+
+		return dataRequests(srv) - before
+	}
+
+	assert.Equal(t, 4, poll(0), "a vehicle that is online is polled")
+	assert.Equal(t, 4, poll(14*time.Minute), "and keeps being polled until idle")
+	assert.Equal(t, 0, poll(time.Minute), "an idle vehicle is left alone to sleep")
+	assert.Equal(t, 0, poll(29*time.Minute))
+	assert.Equal(t, 4, poll(time.Minute), "a vehicle awake after the sleep window is polled again")
+	assert.Equal(t, 0, poll(time.Minute), "and left alone for another window")
+	assert.Equal(t, 4, poll(30*time.Minute))
+
+	srv.Vehicle(1).Sleep()
+	assert.Equal(t, 0, poll(30*time.Minute), "an asleep vehicle is never polled")
+
+	wake := srv.Conn()
+	require.NoError(t, wake.Authenticate(teslatest.Email, teslatest.Password))
+	_, err := wake.WakeUp(1)
+	require.NoError(t, err)
+	srv.Advance(time.Minute)
+
+	assert.Equal(t, 4, poll(time.Minute), "a vehicle that woke up is polled")
+}
+
+func TestPollerKeepsPollingActiveVehicles(t *testing.T) {
+	p, srv := newTestPoller(t)
+
+	srv.Vehicle(1).PlugIn(true)
+
+	for i := 0; i < 10; i++ {
+		before := dataRequests(srv)
+		require.NoError(t, p.poll())
+		assert.Equal(t, 4, dataRequests(srv)-before, "a charging vehicle is always polled")
+
+		srv.Advance(10 * time.Minute)
+	}
+}
+
+func TestCollector(t *testing.T) {
+	p, srv := newTestPoller(t)
+
+	require.NoError(t, p.poll())
+
+	expected := `
+# HELP tesla_battery_level_percent State of charge of the battery.
+# TYPE tesla_battery_level_percent gauge
+tesla_battery_level_percent{vin="5YJSA1E2XLF000001"} 60
+# HELP tesla_inside_temperature_celsius Temperature inside the vehicle.
+# TYPE tesla_inside_temperature_celsius gauge
+tesla_inside_temperature_celsius{vin="5YJSA1E2XLF000001"} 20
+# HELP tesla_locked Whether the vehicle is locked.
+# TYPE tesla_locked gauge
+tesla_locked{vin="5YJSA1E2XLF000001"} 1
+# HELP tesla_odometer_miles Odometer reading.
+# TYPE tesla_odometer_miles gauge
+tesla_odometer_miles{vin="5YJSA1E2XLF000001"} 12345.6
+# HELP tesla_vehicle_asleep Whether the vehicle is asleep.
+# TYPE tesla_vehicle_asleep gauge
+tesla_vehicle_asleep{vin="5YJSA1E2XLF000001"} 0
+# HELP tesla_vehicle_info Vehicles on the account; always 1.
+# TYPE tesla_vehicle_info gauge
+tesla_vehicle_info{display_name="Test Vehicle",id="1",vin="5YJSA1E2XLF000001"} 1
+# HELP tesla_vehicle_online Whether the vehicle is online.
+# TYPE tesla_vehicle_online gauge
+tesla_vehicle_online{vin="5YJSA1E2XLF000001"} 1
+`
+
+	c := collector{poller: p}
+
+	require.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"tesla_battery_level_percent", "tesla_inside_temperature_celsius", "tesla_locked", "tesla_odometer_miles",
+		"tesla_vehicle_asleep", "tesla_vehicle_info", "tesla_vehicle_online"))
+
+	requests := len(srv.Requests())
+	assert.Equal(t, 16, testutil.CollectAndCount(c))
+	assert.Equal(t, requests, len(srv.Requests()), "collecting makes no requests")
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tesla-exporter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	srv.AddVehicle(1, vin)
+
+	configPath := filepath.Join(dir, "credentials.json")
+	require.NoError(t, credentials.Save(configPath, &credentials.Config{
+		ClientID:     teslatest.ClientID,
+		ClientSecret: teslatest.ClientSecret,
+		BaseURL:      srv.URL,
+		AccessToken:  "expired",
+		RefreshToken: teslatest.RefreshToken,
+	}))
+
+	e, err := setup([]string{"-config", configPath}, ioutil.Discard)
+	require.NoError(t, err)
+	require.NoError(t, e.poller.poll())
+
+	metrics := httptest.NewServer(e.server.Handler)
+	defer metrics.Close()
+
+	resp, err := http.Get(metrics.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `tesla_battery_level_percent{vin="5YJSA1E2XLF000001"} 60`)
+	assert.Contains(t, string(body), `tesla_requests_total{endpoint="charge_state",status="200"} 1`)
+
+	cfg, err := credentials.Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.AccessToken, cfg.AccessToken, "refreshed tokens are saved")
+}
+
+func TestRunStopsWhenDone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tesla-exporter")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv, _ := teslatest.NewTestServer(t)
+
+	configPath := filepath.Join(dir, "credentials.json")
+	require.NoError(t, credentials.Save(configPath, &credentials.Config{
+		ClientID:     teslatest.ClientID,
+		ClientSecret: teslatest.ClientSecret,
+		BaseURL:      srv.URL,
+		AccessToken:  teslatest.AccessToken,
+	}))
+
+	e, err := setup([]string{"-config", configPath, "-listen", "127.0.0.1:0", "-interval", "10ms"}, ioutil.Discard)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+
+	go func() { done <- e.run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("run did not return")
+	}
+
+	requests := len(srv.Requests())
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, requests, len(srv.Requests()), "polling stops")
+}
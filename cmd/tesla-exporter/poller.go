@@ -0,0 +1,142 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/idle"
+)
+
+// vehicleData is the latest data polled for a vehicle.
+type vehicleData struct {
+	vehicle tesla.Vehicle
+
+	charge  *tesla.ChargeState
+	climate *tesla.ClimateState
+	state   *tesla.VehicleState
+	drive   *tesla.DriveState
+
+	// updated is when the state was last polled.
+	updated time.Time
+}
+
+// poller polls the vehicles on an account without keeping them awake.
+//
+// The vehicle list is polled every time, as it never wakes a vehicle. The state of a vehicle is
+// polled as the idle package's Tracker allows: while it is in use, and for idleAfter once it is
+// not, then once every sleepWindow until it falls asleep.
+type poller struct {
+	conn    *tesla.Conn
+	tracker *idle.Tracker
+	now     func() time.Time
+
+	// call makes each request. It can be replaced to refresh the access token when it is rejected.
+	call func(fn func() error) error
+
+	mu       sync.Mutex
+	vehicles map[int]*vehicleData
+}
+
+func newPoller(conn *tesla.Conn, idleAfter, sleepWindow time.Duration) *poller {
+	return &poller{
+		conn:     conn,
+		tracker:  idle.NewTracker(idleAfter, sleepWindow),
+		now:      time.Now,
+		call:     func(fn func() error) error { return fn() },
+		vehicles: make(map[int]*vehicleData),
+	}
+}
+
+// poll polls every vehicle once. The first error is returned after every vehicle is polled.
+func (p *poller) poll() error {
+	var vehicles []tesla.Vehicle
+
+	err := p.call(func() (err error) {
+		vehicles, err = p.conn.GetVehicles()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	var first error
+
+	for _, v := range vehicles {
+		if err := p.pollVehicle(v); err != nil && first == nil {
+			first = err
+		}
+	}
+
+	return first
+}
+
+func (p *poller) pollVehicle(v tesla.Vehicle) error {
+	now := p.now()
+
+	p.mu.Lock()
+	d, ok := p.vehicles[v.ID]
+	if !ok {
+		d = &vehicleData{}
+		p.vehicles[v.ID] = d
+	}
+
+	d.vehicle = v
+	p.mu.Unlock()
+
+	if !p.tracker.ShouldPoll(v, now) {
+		return nil
+	}
+
+	var (
+		charge  *tesla.ChargeState
+		climate *tesla.ClimateState
+		state   *tesla.VehicleState
+		drive   *tesla.DriveState
+	)
+
+	err := p.call(func() (err error) {
+		if charge, err = p.conn.GetChargeState(v.ID); err != nil {
+			return err
+		}
+
+		if climate, err = p.conn.GetClimateState(v.ID); err != nil {
+			return err
+		}
+
+		if state, err = p.conn.GetVehicleState(v.ID); err != nil {
+			return err
+		}
+
+		drive, err = p.conn.GetDriveState(v.ID)
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	p.tracker.Polled(v.ID, now, idle.Active(charge, climate, state, drive))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	d.charge, d.climate, d.state, d.drive = charge, climate, state, drive
+	d.updated = now
+
+	return nil
+}
+
+// snapshot returns a copy of the data for every vehicle.
+func (p *poller) snapshot() []vehicleData {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data := make([]vehicleData, 0, len(p.vehicles))
+
+	for _, d := range p.vehicles {
+		data = append(data, *d)
+	}
+
+	return data
+}
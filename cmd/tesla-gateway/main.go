@@ -0,0 +1,129 @@
+// Command tesla-gateway serves the gateway API, so services can use the vehicles on an account
+// without holding its credentials. See package gateway for the API.
+//
+// Usage:
+//
+//	tesla-gateway -keys keys.json [-listen :8080]
+//
+// It uses the credentials saved by "tesla login", and saves them again whenever the access token
+// is refreshed. The keys file is a JSON list of API keys:
+//
+//	[
+//	  {"name": "dashboard", "key": "secret1"},
+//	  {"name": "automation", "key": "secret2", "commands": ["door_lock", "auto_conditioning_start"]}
+//	]
+//
+// Every key may read vehicle state; commands, including "wake_up", must be listed, or "*" allows
+// them all. Each request is logged to stderr for auditing.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/gateway"
+	"github.com/rickbassham/tesla/internal/credentials"
+)
+
+func main() {
+	srv, err := setup(os.Args[1:], os.Stderr)
+	if err == nil {
+		err = srv.ListenAndServe()
+	}
+
+	if err != nil && err != flag.ErrHelp {
+		fmt.Fprintln(os.Stderr, "tesla-gateway:", err)
+		os.Exit(1)
+	}
+}
+
+// readHeaderTimeout bounds how long a client may take to send its request headers.
+const readHeaderTimeout = 10 * time.Second
+
+// setup parses the flags and returns the server to run.
+func setup(args []string, stderr io.Writer) (*http.Server, error) {
+	flags := flag.NewFlagSet("tesla-gateway", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	listen := flags.String("listen", "localhost:8080", "address to listen on")
+	keysPath := flags.String("keys", os.Getenv("TESLA_GATEWAY_KEYS"), "path of the API keys file")
+	configPath := flags.String("config", os.Getenv("TESLA_CONFIG"), "path of the credentials file saved by tesla login")
+	retries := flags.Int("retries", 3, "times to retry a request the owner's API throttled")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *keysPath == "" {
+		return nil, errors.New("-keys is required")
+	}
+
+	keys, err := loadKeys(*keysPath)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := credentials.Open(*configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := session.Conn
+	conn.SetRateLimits(tesla.RateLimits{}, *retries)
+
+	logger := tesla.NewTextLogger(stderr)
+
+	gw := gateway.New(conn, gateway.Config{
+		Keys:  keys,
+		Audit: gateway.LogAudit(logger),
+		TokensRefreshed: func(*tesla.Conn) {
+			if err := session.SaveTokens(); err != nil {
+				logger.Error("error saving refreshed tokens", "error", err)
+			}
+		},
+	})
+
+	return &http.Server{
+		Addr:              *listen,
+		Handler:           gw,
+		ReadHeaderTimeout: readHeaderTimeout,
+	}, nil
+}
+
+// loadKeys reads the API keys file.
+func loadKeys(path string) ([]gateway.Key, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading keys: %w", err)
+	}
+
+	var keys []gateway.Key
+
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("error parsing keys %s: %w", path, err)
+	}
+
+	names := make(map[string]bool)
+
+	for _, key := range keys {
+		if key.Name == "" || key.Secret == "" {
+			return nil, fmt.Errorf("error parsing keys %s: every key needs a name and key", path)
+		}
+
+		if names[key.Name] {
+			return nil, fmt.Errorf("error parsing keys %s: duplicate key name %q", path, key.Name)
+		}
+
+		names[key.Name] = true
+	}
+
+	return keys, nil
+}
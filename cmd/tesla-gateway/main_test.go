@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func TestGateway(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tesla-gateway")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	srv.AddVehicle(1, "5YJSA1E2XLF000001")
+
+	configPath := filepath.Join(dir, "credentials.json")
+	require.NoError(t, credentials.Save(configPath, &credentials.Config{
+		ClientID:     teslatest.ClientID,
+		ClientSecret: teslatest.ClientSecret,
+		BaseURL:      srv.URL,
+		AccessToken:  "expired",
+		RefreshToken: teslatest.RefreshToken,
+	}))
+
+	keysPath := filepath.Join(dir, "keys.json")
+	require.NoError(t, ioutil.WriteFile(keysPath, []byte(`[
+		{"name": "dashboard", "key": "secret1"},
+		{"name": "automation", "key": "secret2", "commands": ["door_lock"]}
+	]`), 0600))
+
+	var stderr bytes.Buffer
+
+	server, err := setup([]string{"-config", configPath, "-keys", keysPath, "-listen", ":0"}, &stderr)
+	require.NoError(t, err)
+	assert.Equal(t, ":0", server.Addr)
+	assert.Equal(t, readHeaderTimeout, server.ReadHeaderTimeout)
+
+	gw := httptest.NewServer(server.Handler)
+	defer gw.Close()
+
+	conn := tesla.NewConn(http.DefaultTransport, gw.URL, "", "")
+	conn.SetAccessToken("secret2")
+
+	require.NoError(t, conn.LockDoors(1))
+	assert.Contains(t, stderr.String(), "automation")
+	assert.Contains(t, stderr.String(), "door_lock")
+
+	cfg, err := credentials.Load(configPath)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.AccessToken, cfg.AccessToken, "refreshed tokens are saved")
+}
+
+func TestSetupErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tesla-gateway")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, []byte(data), 0600))
+
+		return path
+	}
+
+	configPath := write("credentials.json", `{"access_token": "token"}`)
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"no keys", []string{"-config", configPath}, "-keys is required"},
+		{"missing keys", []string{"-config", configPath, "-keys", filepath.Join(dir, "missing.json")}, "error reading keys"},
+		{"invalid keys", []string{"-config", configPath, "-keys", write("invalid.json", `{`)}, "error parsing keys"},
+		{"unnamed key", []string{"-config", configPath, "-keys", write("unnamed.json", `[{"key": "a"}]`)}, "every key needs a name and key"},
+		{"duplicate key", []string{"-config", configPath, "-keys", write("dup.json", `[{"name": "a", "key": "a"}, {"name": "a", "key": "b"}]`)}, "duplicate key name"},
+		{"not logged in", []string{"-config", write("empty.json", `{}`), "-keys", write("keys.json", `[]`)}, "not logged in"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := setup(tt.args, ioutil.Discard)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// dashboard is everything shown on screen. It is only used from the main loop, so it needs no
+// locking.
+type dashboard struct {
+	vehicle tesla.Vehicle
+
+	charge  *tesla.ChargeState
+	climate *tesla.ClimateState
+	state   *tesla.VehicleState
+	drive   *tesla.DriveState
+	gui     *tesla.GUISettings
+
+	last    *tesla.StreamingMessage
+	session session
+	updated time.Time
+
+	status   string
+	statusAt time.Time
+
+	palette palette
+}
+
+// session summarizes the stream since the dashboard started.
+type session struct {
+	start         time.Time
+	startSOC      int
+	startOdometer float64
+	maxSpeed      int
+	peakPower     int
+	peakRegen     int
+	samples       int
+}
+
+func (d *dashboard) streamed(msg tesla.StreamingMessage) {
+	if d.session.samples == 0 {
+		d.session = session{
+			start:         msg.Timestamp,
+			startSOC:      msg.SOC,
+			startOdometer: msg.Odometer,
+		}
+	}
+
+	d.session.samples++
+
+	if msg.Speed > d.session.maxSpeed {
+		d.session.maxSpeed = msg.Speed
+	}
+
+	if msg.Power > d.session.peakPower {
+		d.session.peakPower = msg.Power
+	}
+
+	if msg.Power < d.session.peakRegen {
+		d.session.peakRegen = msg.Power
+	}
+
+	d.last = &msg
+	d.updated = msg.Timestamp
+}
+
+func (d *dashboard) setStatus(now time.Time, format string, args ...interface{}) {
+	d.status = fmt.Sprintf(format, args...)
+	d.statusAt = now
+}
+
+func (d *dashboard) units() *tesla.GUISettings {
+	if d.gui == nil {
+		return &tesla.GUISettings{}
+	}
+
+	return d.gui
+}
+
+// render returns the lines to draw on a screen of the given size.
+func (d *dashboard) render(width, height int, now time.Time) []string {
+	u := d.units()
+
+	var lines []string
+
+	add := func(label, format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf("%-9s %s", label, fmt.Sprintf(format, args...)))
+	}
+
+	title := fmt.Sprintf("tesla-top — %s (%s)  %s", d.vehicle.DisplayName, d.vehicle.VIN, d.vehicle.State)
+	if !d.updated.IsZero() {
+		title += "  updated " + d.updated.Local().Format("15:04:05")
+	}
+
+	lines = append(lines, title, strings.Repeat("─", width))
+
+	speed, power, heading := tesla.Speed(0), tesla.Power(0), 0
+	lat, lon := 0.0, 0.0
+
+	switch {
+	case d.last != nil:
+		speed, power, heading = d.last.CurrentSpeed(), d.last.CurrentPower(), d.last.Heading
+		lat, lon = d.last.EstLatitude, d.last.EstLongitude
+	case d.drive != nil:
+		speed, power, heading = d.drive.CurrentSpeed(), d.drive.CurrentPower(), d.drive.Heading
+		lat, lon = d.drive.Latitude, d.drive.Longitude
+	}
+
+	shift := "P"
+	if d.drive != nil && d.drive.ShiftState != nil {
+		shift = fmt.Sprint(d.drive.ShiftState)
+	}
+
+	add("DRIVE", "Speed %s  Power %s  Shift %s  Heading %d° %s", u.FormatSpeed(speed), power, shift, heading, compass(heading))
+	add("", "Position %.6f, %.6f", lat, lon)
+
+	switch {
+	case d.last != nil:
+		add("BATTERY", "SOC %d%%  Range %s (est %s)", d.last.SOC, u.FormatDistance(d.last.RangeDistance()), u.FormatDistance(d.last.EstRangeDistance()))
+	case d.charge != nil:
+		add("BATTERY", "SOC %d%%  Range %s (est %s)", d.charge.BatteryLevel, u.FormatDistance(d.charge.BatteryRangeDistance()), u.FormatDistance(d.charge.EstBatteryRangeDistance()))
+	default:
+		add("BATTERY", "-")
+	}
+
+	if c := d.charge; c != nil {
+		add("CHARGING", "%s  Limit %d%%", c.ChargingState, c.ChargeLimitSoc)
+
+		if c.ChargingState == tesla.ChargingStateCharging {
+			add("", "%s  %d A / %d V  +%s  +%s  %s to limit",
+				c.ChargingPower(), c.ChargerActualCurrent, c.ChargerVoltage, c.EnergyAdded(),
+				u.FormatDistance(c.RangeAddedRated()), hours(c.TimeToFullCharge))
+		}
+	} else {
+		add("CHARGING", "-")
+	}
+
+	if c := d.climate; c != nil {
+		add("CLIMATE", "Inside %s  Outside %s  Set %s / %s  %s",
+			u.FormatTemperature(c.InsideTemperature()), u.FormatTemperature(c.OutsideTemperature()),
+			u.FormatTemperature(c.DriverTemperature()), u.FormatTemperature(c.PassengerTemperature()),
+			onOff("HVAC", c.IsClimateOn))
+	} else {
+		add("CLIMATE", "-")
+	}
+
+	if s := d.state; s != nil {
+		add("SECURITY", "%s  %s  Doors %s  Windows %s  Trunk %s  Frunk %s",
+			lockedText(s.Locked), onOff("Sentry", s.SentryMode),
+			openClosed(s.Df, s.Dr, s.Pf, s.Pr), openClosed(s.FdWindow, s.FpWindow, s.RdWindow, s.RpWindow),
+			openClosed(s.Rt), openClosed(s.Ft))
+		add("", "Odometer %s", u.FormatDistance(s.OdometerDistance()))
+	} else {
+		add("SECURITY", "-")
+	}
+
+	if d.session.samples > 0 && d.last != nil {
+		s := d.session
+		add("SESSION", "%s  Distance %s  Max %s  SOC %+d%%  Peak %s  Regen %s",
+			d.last.Timestamp.Sub(s.start).Round(time.Second),
+			u.FormatDistance(tesla.Miles(d.last.Odometer-s.startOdometer)),
+			u.FormatSpeed(tesla.MilesPerHour(float64(s.maxSpeed))),
+			d.last.SOC-s.startSOC, tesla.Kilowatts(float64(s.peakPower)), tesla.Kilowatts(float64(s.peakRegen)))
+	}
+
+	lines = append(lines, strings.Repeat("─", width))
+
+	if d.palette.open {
+		lines = append(lines, d.palette.render(height-len(lines)-1)...)
+	}
+
+	footer := "[:] commands  [r] refresh  [q] quit"
+	if d.status != "" && now.Sub(d.statusAt) < 10*time.Second {
+		footer += "   " + d.status
+	}
+
+	lines = append(lines, footer)
+
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+
+	return lines
+}
+
+func compass(heading int) string {
+	points := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
+	return points[((heading%360+360)%360+22)/45%8]
+}
+
+func hours(h float64) string {
+	return (time.Duration(h * float64(time.Hour))).Round(time.Minute).String()
+}
+
+func onOff(label string, on bool) string {
+	if on {
+		return label + " on"
+	}
+
+	return label + " off"
+}
+
+func lockedText(locked bool) string {
+	if locked {
+		return "Locked"
+	}
+
+	return "Unlocked"
+}
+
+// openClosed reports "open" if any of the values, which the API reports as 0 for closed, is
+// non-zero.
+func openClosed(vals ...int) string {
+	for _, v := range vals {
+		if v != 0 {
+			return "open"
+		}
+	}
+
+	return "closed"
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+
+	return string(runes[:width])
+}
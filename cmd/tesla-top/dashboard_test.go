@@ -0,0 +1,121 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rickbassham/tesla"
+)
+
+func TestRender(t *testing.T) {
+	start := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	d := &dashboard{
+		vehicle: tesla.Vehicle{DisplayName: "Car", VIN: "VIN1", State: "online"},
+		charge: &tesla.ChargeState{
+			BatteryLevel:   50,
+			ChargingState:  tesla.ChargingStateStopped,
+			ChargeLimitSoc: 90,
+		},
+		climate: &tesla.ClimateState{InsideTemp: 20, OutsideTemp: 10, DriverTempSetting: 21, PassengerTempSetting: 21},
+		state:   &tesla.VehicleState{Locked: false, SentryMode: true, Df: 1},
+	}
+
+	d.streamed(tesla.StreamingMessage{Timestamp: start, Speed: 30, SOC: 80, Odometer: 100, Power: 40, Heading: 180})
+	d.streamed(tesla.StreamingMessage{Timestamp: start.Add(10 * time.Minute), Speed: 60, SOC: 78, Odometer: 110, Power: -20, Heading: 270})
+
+	lines := d.render(200, 40, start)
+	screen := strings.Join(lines, "\n")
+
+	assert.Contains(t, lines[0], "Car (VIN1)  online")
+	assert.Contains(t, screen, "Heading 270° W")
+	assert.Contains(t, screen, "SOC 78%")
+	assert.Contains(t, screen, "Stopped  Limit 90%")
+	assert.Contains(t, screen, "HVAC off")
+	assert.Contains(t, screen, "Unlocked  Sentry on  Doors open  Windows closed")
+	assert.Contains(t, screen, "SESSION   10m0s")
+	assert.Contains(t, screen, "SOC -2%")
+	assert.Equal(t, "[:] commands  [r] refresh  [q] quit", lines[len(lines)-1])
+
+	d.setStatus(start, "lock: ok")
+	lines = d.render(200, 40, start.Add(time.Second))
+	assert.Contains(t, lines[len(lines)-1], "lock: ok")
+
+	lines = d.render(200, 40, start.Add(time.Minute))
+	assert.NotContains(t, lines[len(lines)-1], "lock: ok", "the status expires")
+
+	lines = d.render(20, 5, start)
+	assert.Len(t, lines, 5)
+
+	for _, line := range lines {
+		assert.True(t, len([]rune(line)) <= 20, line)
+	}
+}
+
+func TestRenderEmpty(t *testing.T) {
+	d := &dashboard{vehicle: tesla.Vehicle{DisplayName: "Car", State: "asleep"}}
+
+	screen := strings.Join(d.render(80, 24, time.Now()), "\n")
+	assert.Contains(t, screen, "BATTERY   -")
+	assert.Contains(t, screen, "CHARGING  -")
+}
+
+func TestPalette(t *testing.T) {
+	var p palette
+
+	p.open = true
+	assert.Len(t, p.matches(), len(paletteCommands))
+
+	for _, k := range "char ope" {
+		p.key(key(k))
+	}
+
+	matches := p.matches()
+	assert.Equal(t, []string{"charge port open"}, names(matches))
+
+	p.key(keyBackspace)
+	p.key(keyBackspace)
+	p.key(keyBackspace)
+	assert.Equal(t, []string{"charge start", "charge stop", "charge port open", "charge port close"}, names(p.matches()))
+
+	p.key(keyDown)
+	p.key(keyDown)
+	p.key(keyUp)
+
+	lines := p.render(10)
+	assert.Equal(t, []string{"> char _", "  charge start", "▶ charge stop", "  charge port open", "  charge port close"}, lines)
+	assert.Len(t, p.render(2), 2)
+
+	cmd := p.key(keyEnter)
+	if assert.NotNil(t, cmd) {
+		assert.Equal(t, "charge stop", cmd.name)
+	}
+
+	assert.Equal(t, palette{}, p)
+
+	p.open = true
+	p.key('x')
+	p.key('x')
+	assert.Nil(t, p.key(keyEnter))
+
+	p.key(keyEscape)
+	assert.False(t, p.open)
+}
+
+func TestParseKeys(t *testing.T) {
+	keys := parseKeys([]byte("a\x1b[A\x1b[B\x1b\r\n\x08\x7fé"))
+	assert.Equal(t, []key{'a', keyUp, keyDown, keyEscape, keyEnter, keyEnter, keyBackspace, keyBackspace, 'é'}, keys)
+}
+
+func names(cmds []paletteCommand) []string {
+	var names []string
+
+	for _, cmd := range cmds {
+		names = append(names, cmd.name)
+	}
+
+	return names
+}
@@ -0,0 +1,276 @@
+// Command tesla-top is a live terminal dashboard for a vehicle. It shows speed, power, battery,
+// charging, climate, and security status, updating as streaming data arrives, and has a command
+// palette for issuing commands.
+//
+// Usage:
+//
+//	tesla-top [-vehicle id|vin|name] [-interval duration] [-idle-after duration] [-sleep-window duration]
+//
+// It uses the credentials saved by "tesla login". State that is not streamed is polled at the
+// given interval while the vehicle is online and in use. Once it has been idle for -idle-after,
+// polling stops for -sleep-window so the vehicle can fall asleep; press r to refresh anyway.
+//
+// Press : to open the command palette, type to filter, and press enter to run the selected
+// command. Press r to refresh and q to quit.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/internal/idle"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, "tesla-top:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	configPath := flag.String("config", os.Getenv("TESLA_CONFIG"), "path of the credentials file saved by tesla login")
+	vehicle := flag.String("vehicle", os.Getenv("TESLA_VEHICLE"), "id, VIN, or display name of the vehicle")
+	interval := flag.Duration("interval", 30*time.Second, "how often to poll state while the vehicle is online")
+	idleAfter := flag.Duration("idle-after", 15*time.Minute, "how long to keep polling a vehicle after it stops being used")
+	sleepWindow := flag.Duration("sleep-window", 30*time.Minute, "how long to stop polling an idle vehicle so it can sleep")
+	flag.Parse()
+
+	session, err := credentials.Open(*configPath)
+	if err != nil {
+		return err
+	}
+
+	v, err := session.Vehicle(*vehicle)
+	if errors.Is(err, credentials.ErrNoVehicleChosen) {
+		return fmt.Errorf("%w; choose one with -vehicle", err)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	t, err := openTerminal(os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+
+	top := &top{
+		conn:     session.Conn,
+		id:       v.ID,
+		interval: *interval,
+		tracker:  idle.NewTracker(*idleAfter, *sleepWindow),
+		now:      time.Now,
+		events:   make(chan func(d *dashboard), 16),
+		refresh:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	defer close(top.done)
+
+	keys := make(chan key, 16)
+	go readKeys(os.Stdin, keys)
+
+	return top.loop(&dashboard{vehicle: *v}, t, keys)
+}
+
+// top runs the dashboard. Background goroutines send updates to events, which the main loop
+// applies to the dashboard before redrawing it.
+type top struct {
+	conn     *tesla.Conn
+	id       int
+	interval time.Duration
+	tracker  *idle.Tracker
+	now      func() time.Time
+
+	// gotSettings is set once the GUI settings have been fetched; they rarely change.
+	gotSettings bool
+
+	events  chan func(d *dashboard)
+	refresh chan struct{}
+	done    chan struct{}
+}
+
+func (t *top) loop(d *dashboard, term *terminal, keys <-chan key) error {
+	go t.poll()
+	go t.stream()
+
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+
+	for {
+		width, height := term.size()
+		if err := term.draw(d.render(width, height, time.Now())); err != nil {
+			return err
+		}
+
+		select {
+		case k, ok := <-keys:
+			if !ok || k == keyCtrlC {
+				return nil
+			}
+
+			if d.palette.open {
+				if cmd := d.palette.key(k); cmd != nil {
+					t.runCommand(d, *cmd)
+				}
+
+				continue
+			}
+
+			switch k {
+			case 'q':
+				return nil
+			case ':', '/':
+				d.palette.open = true
+			case 'r':
+				t.requestRefresh()
+				d.setStatus(time.Now(), "refreshing")
+			}
+		case update := <-t.events:
+			update(d)
+		case <-tick.C:
+		}
+	}
+}
+
+func (t *top) send(update func(d *dashboard)) {
+	select {
+	case t.events <- update:
+	case <-t.done:
+	}
+}
+
+func (t *top) status(format string, args ...interface{}) {
+	t.send(func(d *dashboard) { d.setStatus(time.Now(), format, args...) })
+}
+
+func (t *top) requestRefresh() {
+	select {
+	case t.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// poll fetches state at each interval, or when a refresh is requested.
+func (t *top) poll() {
+	tick := time.NewTicker(t.interval)
+	defer tick.Stop()
+
+	forced := false
+
+	for {
+		t.pollOnce(forced)
+
+		select {
+		case <-tick.C:
+			forced = false
+		case <-t.refresh:
+			forced = true
+		case <-t.done:
+			return
+		}
+	}
+}
+
+// pollOnce fetches the vehicle summary, which does not wake the vehicle, then its state if the
+// tracker allows it or forced is set. The state is only fetched while the vehicle is online.
+func (t *top) pollOnce(forced bool) {
+	v, err := t.conn.GetVehicle(t.id)
+	if err != nil {
+		t.status("error: %s", err)
+		return
+	}
+
+	t.send(func(d *dashboard) { d.vehicle = *v })
+
+	now := t.now()
+
+	if !t.tracker.ShouldPoll(*v, now) && !(forced && v.State == "online") {
+		return
+	}
+
+	if !t.gotSettings {
+		if gui, err := t.conn.GetGUISettings(t.id); err == nil {
+			t.gotSettings = true
+			t.send(func(d *dashboard) { d.gui = gui })
+		}
+	}
+
+	charge, chargeErr := t.conn.GetChargeState(t.id)
+	climate, climateErr := t.conn.GetClimateState(t.id)
+	state, stateErr := t.conn.GetVehicleState(t.id)
+	drive, driveErr := t.conn.GetDriveState(t.id)
+
+	t.tracker.Polled(t.id, now, idle.Active(charge, climate, state, drive))
+
+	t.send(func(d *dashboard) {
+		if chargeErr == nil {
+			d.charge = charge
+		}
+
+		if climateErr == nil {
+			d.climate = climate
+		}
+
+		if stateErr == nil {
+			d.state = state
+		}
+
+		if driveErr == nil {
+			d.drive = drive
+		}
+
+		if d.last == nil {
+			d.updated = time.Now()
+		}
+	})
+}
+
+// stream receives streaming data, reconnecting whenever the stream ends. The streaming API only
+// sends data while the vehicle is driving or charging.
+func (t *top) stream() {
+	for {
+		s, err := t.conn.Stream(t.id, "")
+		if err == nil {
+			for msg := range s.Data() {
+				msg := msg
+				t.send(func(d *dashboard) { d.streamed(msg) })
+			}
+		}
+
+		select {
+		case <-time.After(10 * time.Second):
+		case <-t.done:
+			return
+		}
+	}
+}
+
+func (t *top) runCommand(d *dashboard, cmd paletteCommand) {
+	target := target{id: t.id}
+
+	switch {
+	case d.last != nil:
+		target.latitude, target.longitude = d.last.EstLatitude, d.last.EstLongitude
+	case d.drive != nil:
+		target.latitude, target.longitude = d.drive.Latitude, d.drive.Longitude
+	}
+
+	d.setStatus(time.Now(), "%s...", cmd.name)
+
+	go func() {
+		if err := cmd.run(t.conn, target); err != nil {
+			t.status("%s: %s", cmd.name, err)
+			return
+		}
+
+		t.status("%s: ok", cmd.name)
+		t.requestRefresh()
+	}()
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/idle"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func newTop(t *testing.T) (*top, *teslatest.Server) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	top := &top{
+		conn:     conn,
+		id:       1,
+		interval: time.Hour,
+		tracker:  idle.NewTracker(15*time.Minute, 30*time.Minute),
+		now:      time.Now,
+		events:   make(chan func(d *dashboard), 16),
+		refresh:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	t.Cleanup(func() { close(top.done) })
+
+	return top, srv
+}
+
+// apply applies updates from the top to the dashboard until done reports true.
+func apply(t *testing.T, top *top, d *dashboard, done func() bool) {
+	timeout := time.After(5 * time.Second)
+
+	for !done() {
+		select {
+		case update := <-top.events:
+			update(d)
+		case <-timeout:
+			t.Fatal("timed out waiting for update")
+		}
+	}
+}
+
+func TestPoll(t *testing.T) {
+	top, _ := newTop(t)
+
+	go top.poll()
+
+	d := &dashboard{}
+	apply(t, top, d, func() bool { return d.charge != nil && d.climate != nil && d.state != nil && d.drive != nil })
+
+	require.NotNil(t, d.gui)
+	assert.Equal(t, "online", d.vehicle.State)
+
+	screen := strings.Join(d.render(120, 40, time.Now()), "\n")
+	assert.Contains(t, screen, "SOC 60%")
+	assert.Contains(t, screen, "Disconnected  Limit 80%")
+	assert.Contains(t, screen, "Locked  Sentry off")
+	assert.Contains(t, screen, "Heading 90° E")
+}
+
+func TestPollAsleep(t *testing.T) {
+	top, srv := newTop(t)
+
+	srv.Vehicle(1).Sleep()
+
+	go top.poll()
+
+	d := &dashboard{}
+	apply(t, top, d, func() bool { return d.vehicle.State != "" })
+
+	for _, req := range srv.Requests() {
+		assert.NotContains(t, req.Path, "data_request", "polling must not wake the vehicle")
+	}
+}
+
+func TestPollLetsIdleVehicleSleep(t *testing.T) {
+	top, srv := newTop(t)
+
+	now := time.Unix(1580000000, 0)
+	top.now = func() time.Time { return now }
+
+	// poll polls once, discarding the updates, and returns the number of state requests made.
+	poll := func(forced bool) int {
+		before := len(srv.Requests())
+		top.pollOnce(forced)
+
+		for len(top.events) > 0 {
+			<-top.events
+		}
+
+		n := 0
+		for _, req := range srv.Requests()[before:] {
+			if strings.Contains(req.Path, "data_request") && !strings.HasSuffix(req.Path, "gui_settings") {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	assert.Equal(t, 4, poll(false))
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, 4, poll(false), "still within the idle time")
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, 0, poll(false), "idle, so the vehicle may sleep")
+	assert.Equal(t, 4, poll(true), "a refresh polls anyway")
+
+	now = now.Add(20 * time.Minute)
+	assert.Equal(t, 0, poll(false))
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, 4, poll(false), "still awake after the sleep window")
+}
+
+func TestStream(t *testing.T) {
+	top, srv := newTop(t)
+
+	go top.stream()
+
+	d := &dashboard{}
+	msg := tesla.StreamingMessage{Timestamp: time.Unix(1580000000, 0), Speed: 55, SOC: 70, Power: 30}
+
+	// The stream may not be connected yet, so keep sending until a message arrives.
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		for {
+			srv.Vehicle(1).Stream(msg)
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	apply(t, top, d, func() bool { return d.last != nil })
+
+	assert.Equal(t, 55, d.last.Speed)
+	assert.Equal(t, 70, d.session.startSOC)
+}
+
+func TestRunCommand(t *testing.T) {
+	top, srv := newTop(t)
+
+	d := &dashboard{}
+	d.palette.open = true
+
+	for _, k := range "lock" {
+		assert.Nil(t, d.palette.key(key(k)))
+	}
+
+	cmd := d.palette.key(keyEnter)
+	require.NotNil(t, cmd)
+	assert.Equal(t, "lock", cmd.name)
+	assert.False(t, d.palette.open)
+
+	top.runCommand(d, *cmd)
+	assert.Equal(t, "lock...", d.status)
+
+	apply(t, top, d, func() bool { return d.status != "lock..." })
+	assert.Equal(t, "lock: ok", d.status)
+
+	commands := srv.Vehicle(1).Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, "door_lock", commands[0].Name)
+
+	select {
+	case <-top.refresh:
+	default:
+		t.Error("a successful command should request a refresh")
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rickbassham/tesla"
+)
+
+// target is what a palette command acts on: the vehicle and its last known position, for the
+// commands that must be issued near it.
+type target struct {
+	id        int
+	latitude  float64
+	longitude float64
+}
+
+type paletteCommand struct {
+	name string
+	run  func(c *tesla.Conn, t target) error
+}
+
+var paletteCommands = []paletteCommand{
+	{"lock", func(c *tesla.Conn, t target) error { return c.LockDoors(t.id) }},
+	{"unlock", func(c *tesla.Conn, t target) error { return c.UnlockDoors(t.id) }},
+	{"honk horn", func(c *tesla.Conn, t target) error { return c.HonkHorn(t.id) }},
+	{"flash lights", func(c *tesla.Conn, t target) error { return c.FlashLights(t.id) }},
+	{"climate on", func(c *tesla.Conn, t target) error { return c.AutoConditioningStart(t.id) }},
+	{"climate off", func(c *tesla.Conn, t target) error { return c.AutoConditioningStop(t.id) }},
+	{"charge start", func(c *tesla.Conn, t target) error { return c.StartCharging(t.id) }},
+	{"charge stop", func(c *tesla.Conn, t target) error { return c.StopCharging(t.id) }},
+	{"charge port open", func(c *tesla.Conn, t target) error { return c.OpenChargePortDoor(t.id) }},
+	{"charge port close", func(c *tesla.Conn, t target) error { return c.CloseChargePortDoor(t.id) }},
+	{"sentry on", func(c *tesla.Conn, t target) error { return c.SetSentryMode(t.id, true) }},
+	{"sentry off", func(c *tesla.Conn, t target) error { return c.SetSentryMode(t.id, false) }},
+	{"open frunk", func(c *tesla.Conn, t target) error { return c.OpenTrunk(t.id, tesla.TrunkFront) }},
+	{"open trunk", func(c *tesla.Conn, t target) error { return c.OpenTrunk(t.id, tesla.TrunkRear) }},
+	{"vent windows", func(c *tesla.Conn, t target) error {
+		return c.ActuateWindows(t.id, tesla.WindowCommandVent, t.latitude, t.longitude)
+	}},
+	{"close windows", func(c *tesla.Conn, t target) error {
+		return c.ActuateWindows(t.id, tesla.WindowCommandClose, t.latitude, t.longitude)
+	}},
+	{"homelink", func(c *tesla.Conn, t target) error { return c.TriggerHomelink(t.id, t.latitude, t.longitude) }},
+}
+
+// palette is the command palette. Typing filters the commands; every word typed must appear in
+// the command name.
+type palette struct {
+	open     bool
+	filter   string
+	selected int
+}
+
+func (p *palette) matches() []paletteCommand {
+	words := strings.Fields(strings.ToLower(p.filter))
+
+	var matches []paletteCommand
+
+	for _, cmd := range paletteCommands {
+		match := true
+
+		for _, word := range words {
+			if !strings.Contains(cmd.name, word) {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			matches = append(matches, cmd)
+		}
+	}
+
+	return matches
+}
+
+// key handles a key press while the palette is open. It returns the command to run, if one was
+// chosen.
+func (p *palette) key(k key) *paletteCommand {
+	switch k {
+	case keyEscape:
+		p.close()
+	case keyUp:
+		if p.selected > 0 {
+			p.selected--
+		}
+	case keyDown:
+		if p.selected < len(p.matches())-1 {
+			p.selected++
+		}
+	case keyBackspace:
+		if p.filter != "" {
+			runes := []rune(p.filter)
+			p.filter = string(runes[:len(runes)-1])
+			p.selected = 0
+		}
+	case keyEnter:
+		matches := p.matches()
+		if p.selected >= len(matches) {
+			return nil
+		}
+
+		cmd := matches[p.selected]
+		p.close()
+
+		return &cmd
+	default:
+		if k >= ' ' {
+			p.filter += string(rune(k))
+			p.selected = 0
+		}
+	}
+
+	return nil
+}
+
+func (p *palette) close() {
+	*p = palette{}
+}
+
+// render returns at most max lines showing the filter and the matching commands.
+func (p *palette) render(max int) []string {
+	lines := []string{"> " + p.filter + "_"}
+
+	for i, cmd := range p.matches() {
+		if len(lines) >= max {
+			break
+		}
+
+		marker := "  "
+		if i == p.selected {
+			marker = "▶ "
+		}
+
+		lines = append(lines, fmt.Sprintf("%s%s", marker, cmd.name))
+	}
+
+	return lines
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// key is a key press: a rune, or one of the special keys below.
+type key rune
+
+const (
+	keyCtrlC     key = 3
+	keyBackspace key = 127
+	keyEnter     key = '\r'
+	keyEscape    key = 27
+	keyUp        key = -1
+	keyDown      key = -2
+)
+
+// parseKeys splits a chunk of terminal input into key presses. Arrow keys arrive as escape
+// sequences; a lone escape is the escape key.
+func parseKeys(buf []byte) []key {
+	var keys []key
+
+	for len(buf) > 0 {
+		switch {
+		case buf[0] == 27 && len(buf) >= 3 && (buf[1] == '[' || buf[1] == 'O'):
+			switch buf[2] {
+			case 'A':
+				keys = append(keys, keyUp)
+			case 'B':
+				keys = append(keys, keyDown)
+			}
+
+			buf = buf[3:]
+		case buf[0] == 8:
+			keys = append(keys, keyBackspace)
+			buf = buf[1:]
+		case buf[0] == '\n':
+			keys = append(keys, keyEnter)
+			buf = buf[1:]
+		default:
+			r, size := utf8.DecodeRune(buf)
+			keys = append(keys, key(r))
+			buf = buf[size:]
+		}
+	}
+
+	return keys
+}
+
+// terminal draws full screens on a terminal in raw mode.
+type terminal struct {
+	in  *os.File
+	out *bufio.Writer
+
+	state *term.State
+}
+
+func openTerminal(in, out *os.File) (*terminal, error) {
+	state, err := term.MakeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &terminal{
+		in:    in,
+		out:   bufio.NewWriter(out),
+		state: state,
+	}
+
+	// Switch to the alternate screen and hide the cursor.
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	t.out.Flush()
+
+	return t, nil
+}
+
+func (t *terminal) Close() error {
+	t.out.WriteString("\x1b[?25h\x1b[?1049l")
+	t.out.Flush()
+
+	return term.Restore(int(t.in.Fd()), t.state)
+}
+
+func (t *terminal) size() (int, int) {
+	width, height, err := term.GetSize(int(t.in.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+
+	return width, height
+}
+
+func (t *terminal) draw(lines []string) error {
+	t.out.WriteString("\x1b[H")
+
+	for _, line := range lines {
+		t.out.WriteString(line)
+		t.out.WriteString("\x1b[K\r\n")
+	}
+
+	t.out.WriteString("\x1b[J")
+
+	return t.out.Flush()
+}
+
+// readKeys sends key presses from r to keys until r fails.
+func readKeys(r io.Reader, keys chan<- key) {
+	buf := make([]byte, 64)
+
+	for {
+		n, err := r.Read(buf)
+
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+
+		if err != nil {
+			close(keys)
+			return
+		}
+	}
+}
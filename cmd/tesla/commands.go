@@ -0,0 +1,630 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/term"
+	"golang.org/x/text/language"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+)
+
+var commands = []command{
+	{name: "login", args: "[-email email] [-password password]", help: "log in and save the tokens", run: login, login: true},
+	{name: "logout", help: "forget the saved tokens", run: logout, login: true},
+	{name: "vehicles", help: "list the vehicles on the account", run: vehicles},
+	{name: "wake", args: "[-wait] [-timeout duration]", help: "wake the vehicle", run: wake},
+	{name: "stream", args: "[-n count]", help: "stream live data while driving", run: stream},
+
+	{name: "state charge", help: "show the charge state", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetChargeState(id) })},
+	{name: "state climate", help: "show the climate state", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetClimateState(id) })},
+	{name: "state drive", help: "show the drive state", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetDriveState(id) })},
+	{name: "state gui", help: "show the GUI settings", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetGUISettings(id) })},
+	{name: "state config", help: "show the vehicle config", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetVehicleConfig(id) })},
+	{name: "state vehicle", help: "show the vehicle state", run: state(func(a *app, id int) (interface{}, error) { return a.conn.GetVehicleState(id) })},
+
+	{name: "lock", help: "lock the doors", run: simple((*tesla.Conn).LockDoors)},
+	{name: "unlock", help: "unlock the doors", run: simple((*tesla.Conn).UnlockDoors)},
+	{name: "honk", help: "honk the horn", run: simple((*tesla.Conn).HonkHorn)},
+	{name: "flash", help: "flash the lights", run: simple((*tesla.Conn).FlashLights)},
+	{name: "remote-start", args: "[password]", help: "enable keyless driving", run: vehicleCommand(0, 1, remoteStart)},
+	{name: "homelink", args: "[latitude longitude]", help: "open the garage door", run: vehicleCommand(0, 2, homelink)},
+
+	{name: "speed-limit set", args: "<limit>", help: "set the speed limit, in mph or with a kph suffix", run: vehicleCommand(1, 1, speedLimitSet)},
+	{name: "speed-limit activate", args: "<pin>", help: "turn speed limit mode on", run: withPIN((*tesla.Conn).SpeedLimitActivate)},
+	{name: "speed-limit deactivate", args: "<pin>", help: "turn speed limit mode off", run: withPIN((*tesla.Conn).SpeedLimitDeactivate)},
+	{name: "speed-limit clear-pin", args: "<pin>", help: "clear the speed limit PIN", run: withPIN((*tesla.Conn).SpeedLimitClearPin)},
+
+	{name: "valet on", args: "[pin]", help: "turn valet mode on", run: vehicleCommand(0, 1, valet(true))},
+	{name: "valet off", args: "[pin]", help: "turn valet mode off", run: vehicleCommand(0, 1, valet(false))},
+	{name: "valet reset-pin", help: "clear the valet PIN", run: simple((*tesla.Conn).ResetValetPin)},
+	{name: "sentry", args: "on|off", help: "turn sentry mode on or off", run: withOnOff((*tesla.Conn).SetSentryMode)},
+
+	{name: "trunk", args: "front|rear", help: "open a trunk", run: vehicleCommand(1, 1, trunk)},
+	{name: "windows", args: "vent|close [latitude longitude]", help: "vent or close the windows", run: vehicleCommand(1, 3, windows)},
+	{name: "sunroof", args: "vent|close", help: "vent or close the sunroof", run: vehicleCommand(1, 1, sunroof)},
+
+	{name: "charge-port open", help: "open the charge port door", run: simple((*tesla.Conn).OpenChargePortDoor)},
+	{name: "charge-port close", help: "close the charge port door", run: simple((*tesla.Conn).CloseChargePortDoor)},
+	{name: "charge start", help: "start charging", run: simple((*tesla.Conn).StartCharging)},
+	{name: "charge stop", help: "stop charging", run: simple((*tesla.Conn).StopCharging)},
+	{name: "charge standard", help: "set the charge limit to standard", run: simple((*tesla.Conn).SetChargeLimitStandard)},
+	{name: "charge max-range", help: "set the charge limit to max range", run: simple((*tesla.Conn).SetChargeLimitMaxRange)},
+	{name: "charge limit", args: "<percent>", help: "set the charge limit", run: vehicleCommand(1, 1, chargeLimit)},
+
+	{name: "climate start", help: "start climate control", run: simple((*tesla.Conn).AutoConditioningStart)},
+	{name: "climate stop", help: "stop climate control", run: simple((*tesla.Conn).AutoConditioningStop)},
+	{name: "climate set-temp", args: "<driver> [passenger]", help: "set temperatures, in °C or with an F suffix", run: vehicleCommand(1, 2, setTemp)},
+	{name: "climate precondition-max", args: "on|off", help: "turn max defrost on or off", run: withOnOff((*tesla.Conn).SetPreconditioningMax)},
+	{name: "seat-heater", args: "<seat> <level>", help: "set a seat heater: driver, passenger, rear-left, rear-center, rear-right; 0-3", run: vehicleCommand(2, 2, seatHeater)},
+	{name: "steering-heater", args: "on|off", help: "turn the steering wheel heater on or off", run: withOnOff((*tesla.Conn).SetHeatedSteeringWheel)},
+
+	{name: "media toggle", help: "play or pause media", run: simple((*tesla.Conn).MediaTogglePlayback)},
+	{name: "media next", help: "skip to the next track", run: simple((*tesla.Conn).MediaNextTrack)},
+	{name: "media prev", help: "skip to the previous track", run: simple((*tesla.Conn).MediaPreviousTrack)},
+	{name: "media next-fav", help: "skip to the next favorite", run: simple((*tesla.Conn).MediaNextFavorite)},
+	{name: "media prev-fav", help: "skip to the previous favorite", run: simple((*tesla.Conn).MediaPreviousFavorite)},
+	{name: "media volume-up", help: "turn the volume up", run: simple((*tesla.Conn).MediaVolumeUp)},
+	{name: "media volume-down", help: "turn the volume down", run: simple((*tesla.Conn).MediaVolumeDown)},
+
+	{name: "share", args: "[-locale tag] <text>", help: "send an address or video to the vehicle", run: share},
+	{name: "software-update schedule", args: "<delay>", help: "schedule a software update", run: vehicleCommand(1, 1, scheduleUpdate)},
+	{name: "software-update cancel", help: "cancel a scheduled software update", run: simple((*tesla.Conn).CancelSoftwareUpdate)},
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return usageError{msg: err.Error()}
+	}
+
+	return nil
+}
+
+// vehicleCommand returns a command that calls fn with the selected vehicle and between min and
+// max arguments, and reports success.
+func vehicleCommand(min, max int, fn func(a *app, id int, args []string) error) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) < min || len(args) > max {
+			return usagef("wrong number of arguments")
+		}
+
+		id, err := a.vehicleID()
+		if err != nil {
+			return err
+		}
+
+		err = a.call(func() error { return fn(a, id, args) })
+		if err != nil {
+			return err
+		}
+
+		return a.printOK()
+	}
+}
+
+func simple(fn func(c *tesla.Conn, id int) error) func(a *app, args []string) error {
+	return vehicleCommand(0, 0, func(a *app, id int, args []string) error {
+		return fn(a.conn, id)
+	})
+}
+
+func withOnOff(fn func(c *tesla.Conn, id int, on bool) error) func(a *app, args []string) error {
+	return vehicleCommand(1, 1, func(a *app, id int, args []string) error {
+		on, err := parseOnOff(args[0])
+		if err != nil {
+			return err
+		}
+
+		return fn(a.conn, id, on)
+	})
+}
+
+func withPIN(fn func(c *tesla.Conn, id int, pin string) error) func(a *app, args []string) error {
+	return vehicleCommand(1, 1, func(a *app, id int, args []string) error {
+		return fn(a.conn, id, args[0])
+	})
+}
+
+func state(get func(a *app, id int) (interface{}, error)) func(a *app, args []string) error {
+	return func(a *app, args []string) error {
+		if len(args) != 0 {
+			return usagef("wrong number of arguments")
+		}
+
+		id, err := a.vehicleID()
+		if err != nil {
+			return err
+		}
+
+		var val interface{}
+
+		err = a.call(func() (err error) {
+			val, err = get(a, id)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		return a.print(val)
+	}
+}
+
+func login(a *app, args []string) error {
+	fs := newFlagSet("login")
+	email := fs.String("email", os.Getenv("TESLA_EMAIL"), "")
+	password := fs.String("password", os.Getenv("TESLA_PASSWORD"), "")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 0 {
+		return usagef("unexpected arguments")
+	}
+
+	if a.session.Config.ClientID == "" || a.session.Config.ClientSecret == "" {
+		return usagef("a client ID and secret are required; set -client-id and -client-secret")
+	}
+
+	scanner := bufio.NewScanner(a.stdin)
+
+	if *email == "" {
+		fmt.Fprint(a.stderr, "Email: ")
+
+		if !scanner.Scan() {
+			return usagef("email is required")
+		}
+
+		*email = strings.TrimSpace(scanner.Text())
+	}
+
+	// The password is used as typed, and is not echoed if stdin is a terminal.
+	if *password == "" {
+		fmt.Fprint(a.stderr, "Password: ")
+
+		if f, ok := a.stdin.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+			b, err := term.ReadPassword(int(f.Fd()))
+			fmt.Fprintln(a.stderr)
+
+			if err != nil {
+				return fmt.Errorf("error reading password: %w", err)
+			}
+
+			*password = string(b)
+		} else if scanner.Scan() {
+			*password = scanner.Text()
+		}
+
+		if *password == "" {
+			return usagef("password is required")
+		}
+	}
+
+	if err := a.conn.Authenticate(*email, *password); err != nil {
+		return err
+	}
+
+	if err := a.session.SaveTokens(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stderr, "logged in; credentials saved to %s\n", a.configPath)
+
+	return nil
+}
+
+func logout(a *app, args []string) error {
+	if len(args) != 0 {
+		return usagef("unexpected arguments")
+	}
+
+	a.session.Config.AccessToken = ""
+	a.session.Config.RefreshToken = ""
+
+	return credentials.Save(a.session.Path, a.session.Config)
+}
+
+func vehicles(a *app, args []string) error {
+	if len(args) != 0 {
+		return usagef("unexpected arguments")
+	}
+
+	var list []tesla.Vehicle
+
+	err := a.call(func() (err error) {
+		list, err = a.conn.GetVehicles()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return a.print(list)
+}
+
+func wake(a *app, args []string) error {
+	fs := newFlagSet("wake")
+	wait := fs.Bool("wait", false, "")
+	timeout := fs.Duration("timeout", 2*time.Minute, "")
+	interval := fs.Duration("interval", 2*time.Second, "")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	id, err := a.vehicleID()
+	if err != nil {
+		return err
+	}
+
+	var vehicle *tesla.Vehicle
+
+	err = a.call(func() (err error) {
+		vehicle, err = a.conn.WakeUp(id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(*timeout)
+
+	for *wait && vehicle.State != "online" {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("vehicle still %s after %s: %w", vehicle.State, *timeout, errWakeTimeout)
+		}
+
+		time.Sleep(*interval)
+
+		err = a.call(func() (err error) {
+			vehicle, err = a.conn.GetVehicle(id)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.print(vehicle)
+}
+
+func stream(a *app, args []string) error {
+	fs := newFlagSet("stream")
+	count := fs.Int("n", 0, "")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	id, err := a.vehicleID()
+	if err != nil {
+		return err
+	}
+
+	s, err := a.conn.Stream(id, "")
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	enc := json.NewEncoder(a.stdout)
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	if a.format == "table" {
+		fmt.Fprintln(w, "TIME\tSPEED\tPOWER\tSOC\tRANGE\tLATITUDE\tLONGITUDE\tHEADING\tSHIFT")
+	}
+
+	for n := 0; *count == 0 || n < *count; n++ {
+		var (
+			msg tesla.StreamingMessage
+			ok  bool
+		)
+
+		select {
+		case msg, ok = <-s.Data():
+		case <-interrupt:
+			return w.Flush()
+		}
+
+		if !ok {
+			w.Flush()
+			return s.Err()
+		}
+
+		if a.format == "json" {
+			if err := enc.Encode(msg); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%.6f\t%.6f\t%d\t%d\n",
+			msg.Timestamp.Format(time.RFC3339), msg.Speed, msg.Power, msg.SOC, msg.Range,
+			msg.EstLatitude, msg.EstLongitude, msg.Heading, msg.ShiftState)
+
+		// Flush every row, so the output is live.
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+func remoteStart(a *app, id int, args []string) error {
+	password := os.Getenv("TESLA_PASSWORD")
+	if len(args) > 0 {
+		password = args[0]
+	}
+
+	if password == "" {
+		return usagef("the account password is required")
+	}
+
+	return a.conn.RemoteStart(id, password)
+}
+
+// coordinates parses the latitude and longitude given in args.
+func coordinates(args []string) (float64, float64, error) {
+	if len(args) != 2 {
+		return 0, 0, usagef("give both latitude and longitude, or neither")
+	}
+
+	lat, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return 0, 0, usagef("invalid latitude %q", args[0])
+	}
+
+	lon, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return 0, 0, usagef("invalid longitude %q", args[1])
+	}
+
+	return lat, lon, nil
+}
+
+func homelink(a *app, id int, args []string) error {
+	if len(args) == 0 {
+		return a.conn.TriggerHomelinkAtVehicle(id)
+	}
+
+	lat, lon, err := coordinates(args)
+	if err != nil {
+		return err
+	}
+
+	return a.conn.TriggerHomelink(id, lat, lon)
+}
+
+func speedLimitSet(a *app, id int, args []string) error {
+	limit, err := parseSpeed(args[0])
+	if err != nil {
+		return err
+	}
+
+	return a.conn.SpeedLimitSetSpeed(id, limit)
+}
+
+func valet(on bool) func(a *app, id int, args []string) error {
+	return func(a *app, id int, args []string) error {
+		pin := ""
+		if len(args) > 0 {
+			pin = args[0]
+		}
+
+		return a.conn.SetValetMode(id, on, pin)
+	}
+}
+
+func trunk(a *app, id int, args []string) error {
+	switch args[0] {
+	case "front":
+		return a.conn.OpenTrunk(id, tesla.TrunkFront)
+	case "rear":
+		return a.conn.OpenTrunk(id, tesla.TrunkRear)
+	}
+
+	return usagef("unknown trunk %q", args[0])
+}
+
+func windows(a *app, id int, args []string) error {
+	var cmd tesla.WindowCommand
+
+	switch args[0] {
+	case "vent":
+		cmd = tesla.WindowCommandVent
+	case "close":
+		cmd = tesla.WindowCommandClose
+	default:
+		return usagef("unknown window command %q", args[0])
+	}
+
+	if len(args) == 1 {
+		if cmd == tesla.WindowCommandClose {
+			return a.conn.CloseWindowsAtVehicle(id)
+		}
+
+		// The location is ignored when venting.
+		return a.conn.ActuateWindows(id, cmd, 0, 0)
+	}
+
+	lat, lon, err := coordinates(args[1:])
+	if err != nil {
+		return err
+	}
+
+	return a.conn.ActuateWindows(id, cmd, lat, lon)
+}
+
+func sunroof(a *app, id int, args []string) error {
+	switch args[0] {
+	case "vent":
+		return a.conn.ActuateSunroof(id, tesla.SunroofCommandVent)
+	case "close":
+		return a.conn.ActuateSunroof(id, tesla.SunroofCommandClose)
+	}
+
+	return usagef("unknown sunroof command %q", args[0])
+}
+
+func chargeLimit(a *app, id int, args []string) error {
+	percent, err := strconv.Atoi(strings.TrimSuffix(args[0], "%"))
+	if err != nil {
+		return usagef("invalid percent %q", args[0])
+	}
+
+	return a.conn.SetChargeLimit(id, percent)
+}
+
+func setTemp(a *app, id int, args []string) error {
+	driver, err := parseTemperature(args[0])
+	if err != nil {
+		return err
+	}
+
+	passenger := driver
+
+	if len(args) > 1 {
+		passenger, err = parseTemperature(args[1])
+		if err != nil {
+			return err
+		}
+	}
+
+	return a.conn.SetTemperatureSettings(id, driver, passenger)
+}
+
+var seats = map[string]tesla.Seat{
+	"driver":      tesla.SeatFrontDriver,
+	"passenger":   tesla.SeatFrontPassenger,
+	"rear-left":   tesla.SeatRearDriver,
+	"rear-center": tesla.SeatRearCenter,
+	"rear-right":  tesla.SeatRearPassenger,
+}
+
+func seatHeater(a *app, id int, args []string) error {
+	seat, ok := seats[args[0]]
+	if !ok {
+		return usagef("unknown seat %q", args[0])
+	}
+
+	level, err := strconv.Atoi(args[1])
+	if err != nil || level < 0 || level > 3 {
+		return usagef("invalid level %q; must be 0-3", args[1])
+	}
+
+	return a.conn.SetSeatHeater(id, seat, tesla.SeatHeatLevel(level))
+}
+
+func share(a *app, args []string) error {
+	fs := newFlagSet("share")
+	locale := fs.String("locale", "en-US", "")
+
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	tag, err := language.Parse(*locale)
+	if err != nil {
+		return usagef("invalid locale %q", *locale)
+	}
+
+	if fs.NArg() == 0 {
+		return usagef("text is required")
+	}
+
+	text := strings.Join(fs.Args(), " ")
+
+	return vehicleCommand(0, 0, func(a *app, id int, args []string) error {
+		return a.conn.Share(id, tag, text)
+	})(a, nil)
+}
+
+func scheduleUpdate(a *app, id int, args []string) error {
+	delay, err := time.ParseDuration(args[0])
+	if err != nil {
+		return usagef("invalid delay %q", args[0])
+	}
+
+	return a.conn.ScheduleSoftwareUpdate(id, delay)
+}
+
+func parseOnOff(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "on", "true", "1":
+		return true, nil
+	case "off", "false", "0":
+		return false, nil
+	}
+
+	return false, usagef("expected on or off, got %q", s)
+}
+
+// parseTemperature parses a temperature in degrees Celsius, or Fahrenheit with an F suffix.
+func parseTemperature(s string) (tesla.Temperature, error) {
+	upper := strings.ToUpper(strings.TrimSuffix(s, "°"))
+
+	unit := "C"
+	if strings.HasSuffix(upper, "F") || strings.HasSuffix(upper, "C") {
+		unit = upper[len(upper)-1:]
+		upper = strings.TrimSuffix(upper[:len(upper)-1], "°")
+	}
+
+	val, err := strconv.ParseFloat(upper, 64)
+	if err != nil {
+		return 0, usagef("invalid temperature %q", s)
+	}
+
+	if unit == "F" {
+		return tesla.Fahrenheit(val), nil
+	}
+
+	return tesla.Celsius(val), nil
+}
+
+// parseSpeed parses a speed in miles per hour, or kilometers per hour with a kph or km/h suffix.
+func parseSpeed(s string) (tesla.Speed, error) {
+	lower := strings.ToLower(strings.TrimSpace(s))
+
+	for _, suffix := range []string{"kph", "km/h"} {
+		if strings.HasSuffix(lower, suffix) {
+			val, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(lower, suffix)), 64)
+			if err != nil {
+				return 0, usagef("invalid speed %q", s)
+			}
+
+			return tesla.KilometersPerHour(val), nil
+		}
+	}
+
+	val, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(lower, "mph")), 64)
+	if err != nil {
+		return 0, usagef("invalid speed %q", s)
+	}
+
+	return tesla.MilesPerHour(val), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+)
+
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitUsage         = 2
+	exitAuth          = 3
+	exitUnavailable   = 4
+	exitRateLimited   = 5
+	exitCommandFailed = 6
+	exitUnsupported   = 7
+	exitNotFound      = 8
+)
+
+var errWakeTimeout = errors.New("timed out waiting for the vehicle to wake")
+
+// exitCode maps an error to the exit status documented in the package comment.
+func exitCode(err error) int {
+	var (
+		usageErr  usageError
+		statusErr tesla.HTTPStatusError
+	)
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &usageErr):
+		return exitUsage
+	case errors.Is(err, tesla.ErrMissingAccessToken), errors.Is(err, tesla.ErrMissingRefreshToken):
+		return exitAuth
+	case errors.Is(err, tesla.ErrCommandError):
+		return exitCommandFailed
+	case errors.Is(err, tesla.ErrUnsupported), errors.Is(err, tesla.ErrInvalidParameter):
+		return exitUnsupported
+	case errors.Is(err, credentials.ErrVehicleNotFound):
+		return exitNotFound
+	case errors.Is(err, errWakeTimeout):
+		return exitUnavailable
+	case errors.As(err, &statusErr):
+		switch statusErr.StatusCode() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return exitAuth
+		case http.StatusRequestTimeout, http.StatusServiceUnavailable, http.StatusBadGateway:
+			return exitUnavailable
+		case http.StatusTooManyRequests:
+			return exitRateLimited
+		case http.StatusNotFound:
+			return exitNotFound
+		}
+	}
+
+	return exitError
+}
@@ -0,0 +1,18 @@
+module github.com/rickbassham/tesla/cmd/tesla
+
+go 1.26.0
+
+require (
+	github.com/rickbassham/tesla v0.0.0
+	github.com/stretchr/testify v1.12.1
+	golang.org/x/term v0.46.0
+	golang.org/x/text v0.3.0
+)
+
+require (
+	github.com/gorilla/websocket v1.4.1 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+)
+
+replace github.com/rickbassham/tesla => ../../
@@ -0,0 +1,259 @@
+// Command tesla is a command-line client for the Tesla Owner's API.
+//
+// Usage:
+//
+//	tesla [flags] <command> [arguments]
+//
+// Log in once with "tesla login"; the tokens are saved and refreshed automatically. Commands that
+// act on a vehicle use the vehicle given by -vehicle (an id, VIN, or display name), or the only
+// vehicle on the account. Run "tesla help" for the list of commands.
+//
+// The exit status is 0 on success, 1 for unexpected errors, 2 for usage errors, 3 if not logged
+// in or the credentials were rejected, 4 if the vehicle is asleep or unavailable, 5 if rate
+// limited, 6 if the vehicle rejected a command, 7 if the vehicle does not support a command or a
+// parameter is out of range, and 8 if the vehicle was not found.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// app holds the state shared by every command.
+type app struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath   string
+	format       string
+	vehicle      string
+	baseURL      string
+	streamingURL string
+	clientID     string
+	clientSecret string
+
+	session *credentials.Session
+	conn    *tesla.Conn
+}
+
+// command is a subcommand. Names may contain spaces, such as "charge start"; the longest name
+// matching the arguments is run.
+type command struct {
+	name  string
+	args  string
+	help  string
+	run   func(a *app, args []string) error
+	login bool
+}
+
+// usageError is returned when a command is called with invalid arguments.
+type usageError struct {
+	msg string
+}
+
+func (err usageError) Error() string {
+	return err.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	fs := flag.NewFlagSet("tesla", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&a.configPath, "config", os.Getenv("TESLA_CONFIG"), "path of the credentials file (default is in the user config directory)")
+	fs.StringVar(&a.format, "format", "table", "output format: table or json")
+	fs.StringVar(&a.vehicle, "vehicle", os.Getenv("TESLA_VEHICLE"), "id, VIN, or display name of the vehicle")
+	fs.StringVar(&a.baseURL, "base-url", "", "URL of the owner's API")
+	fs.StringVar(&a.streamingURL, "streaming-url", "", "URL of the streaming API")
+	fs.StringVar(&a.clientID, "client-id", os.Getenv("TESLA_CLIENT_ID"), "OAuth client ID, saved on login")
+	fs.StringVar(&a.clientSecret, "client-secret", os.Getenv("TESLA_CLIENT_SECRET"), "OAuth client secret, saved on login")
+	fs.Usage = func() { a.usage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+
+		return exitUsage
+	}
+
+	if a.format != "table" && a.format != "json" {
+		fmt.Fprintf(stderr, "tesla: unknown format %q\n", a.format)
+		return exitUsage
+	}
+
+	args = fs.Args()
+	if len(args) == 0 || args[0] == "help" {
+		a.usage(fs)
+
+		if len(args) == 0 {
+			return exitUsage
+		}
+
+		return exitOK
+	}
+
+	cmd, rest := findCommand(args)
+	if cmd == nil {
+		fmt.Fprintf(stderr, "tesla: unknown command %q; run \"tesla help\" for usage\n", strings.Join(args, " "))
+		return exitUsage
+	}
+
+	err := a.setup(cmd.login)
+	if err == nil {
+		err = cmd.run(a, rest)
+	}
+
+	if err != nil {
+		var usageErr usageError
+		if errors.As(err, &usageErr) {
+			fmt.Fprintf(stderr, "tesla %s: %s\nusage: tesla %s %s\n", cmd.name, err, cmd.name, cmd.args)
+		} else {
+			fmt.Fprintf(stderr, "tesla %s: %s\n", cmd.name, err)
+		}
+
+		return exitCode(err)
+	}
+
+	return exitOK
+}
+
+func findCommand(args []string) (*command, []string) {
+	var (
+		found *command
+		words int
+	)
+
+	for i := range commands {
+		cmd := &commands[i]
+		name := strings.Fields(cmd.name)
+
+		if len(name) <= words || len(name) > len(args) {
+			continue
+		}
+
+		match := true
+		for j, word := range name {
+			if args[j] != word {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			found, words = cmd, len(name)
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	return found, args[words:]
+}
+
+func (a *app) usage(fs *flag.FlagSet) {
+	fmt.Fprintln(a.stderr, "usage: tesla [flags] <command> [arguments]")
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "commands:")
+
+	sorted := make([]command, len(commands))
+	copy(sorted, commands)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	for _, cmd := range sorted {
+		fmt.Fprintf(a.stderr, "  %-42s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.help)
+	}
+
+	fmt.Fprintln(a.stderr)
+	fmt.Fprintln(a.stderr, "flags:")
+	fs.PrintDefaults()
+}
+
+// setup loads the saved credentials and creates the connection. Unless login is true, it is an
+// error not to be logged in.
+func (a *app) setup(login bool) error {
+	if a.configPath == "" {
+		path, err := credentials.DefaultPath()
+		if err != nil {
+			return err
+		}
+
+		a.configPath = path
+	}
+
+	cfg, err := credentials.Load(a.configPath)
+	if err != nil {
+		return err
+	}
+
+	if a.clientID != "" {
+		cfg.ClientID = a.clientID
+	}
+
+	if a.clientSecret != "" {
+		cfg.ClientSecret = a.clientSecret
+	}
+
+	if a.baseURL != "" {
+		cfg.BaseURL = a.baseURL
+	}
+
+	if a.streamingURL != "" {
+		cfg.StreamingURL = a.streamingURL
+	}
+
+	if !login && cfg.AccessToken == "" {
+		return fmt.Errorf("not logged in; run \"tesla login\": %w", tesla.ErrMissingAccessToken)
+	}
+
+	a.session = credentials.NewSession(a.configPath, cfg)
+	a.conn = a.session.Conn
+
+	return nil
+}
+
+// call runs fn, refreshing and saving the access token if it has expired.
+func (a *app) call(fn func() error) error {
+	return a.session.Call(fn)
+}
+
+// vehicleID returns the id of the selected vehicle.
+func (a *app) vehicleID() (int, error) {
+	if id, err := strconv.Atoi(a.vehicle); err == nil {
+		return id, nil
+	}
+
+	v, err := a.session.Vehicle(a.vehicle)
+	if errors.Is(err, credentials.ErrNoVehicleChosen) {
+		return 0, usagef("%s; choose one with -vehicle", err)
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return v.ID, nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+type cli struct {
+	t          *testing.T
+	srv        *teslatest.Server
+	configPath string
+}
+
+func newCLI(t *testing.T) *cli {
+	dir, err := ioutil.TempDir("", "tesla-cli")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	srv, _ := teslatest.NewTestServer(t)
+
+	return &cli{
+		t:          t,
+		srv:        srv,
+		configPath: filepath.Join(dir, "credentials.json"),
+	}
+}
+
+func (c *cli) run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+
+	args = append([]string{
+		"-config", c.configPath,
+		"-base-url", c.srv.URL,
+		"-streaming-url", c.srv.StreamingURL,
+	}, args...)
+
+	code := run(args, strings.NewReader(""), &stdout, &stderr)
+
+	return code, stdout.String(), stderr.String()
+}
+
+func (c *cli) login() {
+	code, _, stderr := c.run("-client-id", teslatest.ClientID, "-client-secret", teslatest.ClientSecret,
+		"login", "-email", teslatest.Email, "-password", teslatest.Password)
+	require.Equal(c.t, exitOK, code, stderr)
+}
+
+func TestLogin(t *testing.T) {
+	c := newCLI(t)
+
+	code, _, _ := c.run("vehicles")
+	assert.Equal(t, exitAuth, code)
+
+	code, _, _ = c.run("-client-id", teslatest.ClientID, "-client-secret", teslatest.ClientSecret,
+		"login", "-email", teslatest.Email, "-password", "wrong")
+	assert.Equal(t, exitAuth, code)
+
+	c.login()
+
+	info, err := os.Stat(c.configPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	cfg, err := credentials.Load(c.configPath)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.AccessToken, cfg.AccessToken)
+	assert.Equal(t, teslatest.RefreshToken, cfg.RefreshToken)
+	assert.Equal(t, teslatest.ClientID, cfg.ClientID)
+}
+
+func TestLoginPrompts(t *testing.T) {
+	c := newCLI(t)
+
+	login := func(stdin string) int {
+		return run([]string{
+			"-config", c.configPath,
+			"-base-url", c.srv.URL,
+			"-client-id", teslatest.ClientID,
+			"-client-secret", teslatest.ClientSecret,
+			"login",
+		}, strings.NewReader(stdin), ioutil.Discard, ioutil.Discard)
+	}
+
+	assert.Equal(t, exitAuth, login(" "+teslatest.Email+" \n "+teslatest.Password+"\n"), "the password is not trimmed")
+	assert.Equal(t, exitUsage, login(teslatest.Email+"\n"))
+	assert.Equal(t, exitOK, login(" "+teslatest.Email+" \n"+teslatest.Password+"\n"))
+}
+
+func TestRefreshesExpiredToken(t *testing.T) {
+	c := newCLI(t)
+
+	c.login()
+
+	cfg, err := credentials.Load(c.configPath)
+	require.NoError(t, err)
+	cfg.AccessToken = "expired"
+	require.NoError(t, credentials.Save(c.configPath, cfg))
+
+	code, stdout, stderr := c.run("vehicles")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, teslatest.VIN)
+
+	cfg, err = credentials.Load(c.configPath)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.AccessToken, cfg.AccessToken)
+}
+
+func TestVehiclesAndState(t *testing.T) {
+	c := newCLI(t)
+
+	v := c.srv.Vehicle(1)
+	v.ChargeState.BatteryLevel = 64
+	c.login()
+
+	code, stdout, _ := c.run("vehicles")
+	require.Equal(t, exitOK, code)
+	assert.Contains(t, stdout, "ID")
+	assert.Contains(t, stdout, teslatest.VIN)
+	assert.Contains(t, stdout, "online")
+
+	code, stdout, _ = c.run("-format", "json", "state", "charge")
+	require.Equal(t, exitOK, code)
+
+	var state struct {
+		BatteryLevel int `json:"battery_level"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &state))
+	assert.Equal(t, 64, state.BatteryLevel)
+
+	code, stdout, _ = c.run("state", "vehicle")
+	require.Equal(t, exitOK, code)
+	assert.Regexp(t, `(?m)^locked\s+true$`, stdout)
+	assert.Regexp(t, `(?m)^speed_limit_mode\.active\s+false$`, stdout)
+}
+
+func TestCommands(t *testing.T) {
+	c := newCLI(t)
+
+	v := c.srv.Vehicle(1)
+	v.PlugIn(false)
+	c.login()
+
+	tests := []struct {
+		args    []string
+		command string
+	}{
+		{args: []string{"unlock"}, command: "door_unlock"},
+		{args: []string{"honk"}, command: "honk_horn"},
+		{args: []string{"charge", "limit", "80"}, command: "set_charge_limit"},
+		{args: []string{"charge", "start"}, command: "charge_start"},
+		{args: []string{"climate", "set-temp", "70F", "21"}, command: "set_temps"},
+		{args: []string{"seat-heater", "driver", "2"}, command: "remote_seat_heater_request"},
+		{args: []string{"sentry", "on"}, command: "set_sentry_mode"},
+		{args: []string{"windows", "vent"}, command: "window_control"},
+		{args: []string{"windows", "close"}, command: "window_control"},
+		{args: []string{"homelink"}, command: "trigger_homelink"},
+		{args: []string{"software-update", "schedule", "1h"}, command: "schedule_software_update"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			code, stdout, stderr := c.run(tt.args...)
+			require.Equal(t, exitOK, code, stderr)
+			assert.Equal(t, "ok\n", stdout)
+
+			commands := v.Commands()
+			require.NotEmpty(t, commands)
+			assert.Equal(t, tt.command, commands[len(commands)-1].Name)
+		})
+	}
+
+	v.Lock()
+	assert.InDelta(t, 21.1, v.ClimateState.DriverTempSetting, 0.1)
+	assert.Equal(t, 21.0, v.ClimateState.PassengerTempSetting)
+	v.Unlock()
+}
+
+func TestExitCodes(t *testing.T) {
+	c := newCLI(t)
+
+	v := c.srv.Vehicle(1)
+	c.srv.AddVehicle(2, "5YJ3E1EA2KF317000")
+	c.login()
+
+	v.Lock()
+	v.CommandResults = map[string]teslatest.CommandResult{
+		"door_lock": {Result: false, Reason: "user_present"},
+	}
+	v.Unlock()
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{name: "unknown command", args: []string{"fly"}, code: exitUsage},
+		{name: "bad arguments", args: []string{"-vehicle", "1", "charge", "limit"}, code: exitUsage},
+		{name: "ambiguous vehicle", args: []string{"honk"}, code: exitUsage},
+		{name: "unknown vehicle", args: []string{"-vehicle", "nope", "honk"}, code: exitNotFound},
+		{name: "by vin", args: []string{"-vehicle", "5yj3e1ea2kf317000", "honk"}, code: exitOK},
+		{name: "command rejected", args: []string{"-vehicle", "1", "lock"}, code: exitCommandFailed},
+		{name: "invalid parameter", args: []string{"-vehicle", "1", "charge", "limit", "150"}, code: exitUnsupported},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := c.run(tt.args...)
+			assert.Equal(t, tt.code, code, stderr)
+		})
+	}
+
+	c.srv.Vehicle(2).Sleep()
+
+	code, _, _ := c.run("-vehicle", "2", "state", "charge")
+	assert.Equal(t, exitUnavailable, code)
+
+	c.srv.Fail("/charge_state", 429)
+
+	code, _, _ = c.run("-vehicle", "1", "state", "charge")
+	assert.Equal(t, exitRateLimited, code)
+}
+
+func TestWake(t *testing.T) {
+	c := newCLI(t)
+
+	v := c.srv.Vehicle(1)
+	v.Sleep()
+	c.login()
+
+	code, _, _ := c.run("-format", "json", "wake", "-wait", "-timeout", "100ms", "-interval", "10ms")
+	assert.Equal(t, exitUnavailable, code)
+
+	c.srv.Advance(v.WakeDelay)
+
+	code, stdout, stderr := c.run("-format", "json", "wake", "-wait", "-interval", "10ms")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, `"state": "online"`)
+}
+
+func TestStream(t *testing.T) {
+	c := newCLI(t)
+
+	v := c.srv.Vehicle(1)
+	c.login()
+
+	type result struct {
+		code   int
+		stdout string
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		code, stdout, _ := c.run("stream", "-n", "2")
+		done <- result{code, stdout}
+	}()
+
+	// The subscription is processed asynchronously, so keep sending until the command exits.
+	var res result
+
+	for finished := false; !finished; {
+		v.Stream(tesla.StreamingMessage{Speed: 42, SOC: 80, EstLatitude: 37.5, EstLongitude: -122.1})
+
+		select {
+		case res = <-done:
+			finished = true
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+
+	require.Equal(t, exitOK, res.code)
+
+	lines := strings.Split(strings.TrimSpace(res.stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasPrefix(lines[0], "TIME"))
+	assert.Contains(t, lines[1], "37.500000")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rickbassham/tesla"
+)
+
+// print writes v in the selected format. Tables of structs list one field per row, named by its
+// JSON key; nested structs are flattened with dotted names.
+func (a *app) print(v interface{}) error {
+	if a.format == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(v)
+	}
+
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	switch val := v.(type) {
+	case []tesla.Vehicle:
+		fmt.Fprintln(w, "ID\tVIN\tNAME\tSTATE")
+
+		for _, vehicle := range val {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", vehicle.ID, vehicle.VIN, vehicle.DisplayName, vehicle.State)
+		}
+	default:
+		flatten("", reflect.ValueOf(v), func(key, value string) {
+			fmt.Fprintf(w, "%s\t%s\n", key, value)
+		})
+	}
+
+	return w.Flush()
+}
+
+func flatten(prefix string, v reflect.Value, add func(key, value string)) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			add(prefix, "")
+			return
+		}
+
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		add(prefix, fmt.Sprint(v.Interface()))
+		return
+	}
+
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		flatten(name, v.Field(i), add)
+	}
+}
+
+// printOK reports the success of a command.
+func (a *app) printOK() error {
+	if a.format == "json" {
+		return a.print(map[string]bool{"result": true})
+	}
+
+	_, err := fmt.Fprintln(a.stdout, "ok")
+
+	return err
+}
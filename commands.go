@@ -2,6 +2,7 @@ package tesla
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"time"
 
@@ -10,8 +11,14 @@ import (
 
 // WakeUp will wake up the vehicle to make it available to receive other commands.
 func (c *Conn) WakeUp(id int) (*Vehicle, error) {
+	val, _, err := c.WakeUpRaw(id)
+	return val, err
+}
+
+// WakeUpRaw is like WakeUp, but also returns the raw HTTP response.
+func (c *Conn) WakeUpRaw(id int) (*Vehicle, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -20,12 +27,12 @@ func (c *Conn) WakeUp(id int) (*Vehicle, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodPost, fmt.Sprintf("/api/1/vehicles/%d/wake_up", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodPost, fmt.Sprintf("/api/1/vehicles/%d/wake_up", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	return &respBody.Response, raw, nil
 }
 
 func (c *Conn) doCommand(url string, reqBody interface{}) error {
@@ -34,22 +41,29 @@ func (c *Conn) doCommand(url string, reqBody interface{}) error {
 	}
 
 	type response struct {
-		Reason string `json:"reason"`
-		Result bool   `json:"result"`
+		Response struct {
+			Reason string `json:"reason"`
+			Result bool   `json:"result"`
+		} `json:"response"`
 	}
 
 	var respBody response
 
-	err := c.doRequest(http.MethodPost, url, reqBody, &respBody)
-	if err != nil {
-		return err
-	}
+	_, err := c.doObservedRequest(http.MethodPost, url, reqBody, &respBody, func(info *RequestInfo) error {
+		c.commandResult(info, respBody.Response.Result, respBody.Response.Reason)
 
-	if !respBody.Result {
-		return fmt.Errorf("%s: %w", respBody.Reason, ErrCommandError)
+		if !respBody.Response.Result {
+			return fmt.Errorf("%s: %w", respBody.Response.Reason, ErrCommandError)
+		}
+
+		return nil
+	})
+
+	if err == nil && c.cache != nil {
+		c.cache.changed(newRequestInfo(http.MethodPost, url))
 	}
 
-	return nil
+	return err
 }
 
 // HonkHorn honks the horn twice.
@@ -66,6 +80,11 @@ func (c *Conn) FlashLights(id int) error {
 // start driving the car. The password provided is the password for the authenticated tesla.com
 // account.
 func (c *Conn) RemoteStart(id int, password string) error {
+	err := c.requireStateCapability(id, "remote start", func(caps Capabilities) bool { return caps.RemoteStartSupported })
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Password string `json:"password"`
 	}
@@ -80,6 +99,11 @@ func (c *Conn) RemoteStart(id int, password string) error {
 // TriggerHomelink opens or closes the primary Homelink device. The provided location must be in
 // proximity of stored location of the Homelink device.
 func (c *Conn) TriggerHomelink(id int, latitude, longitude float64) error {
+	err := c.requireStateCapability(id, "homelink", func(caps Capabilities) bool { return caps.HomelinkAvailable })
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Latitude  float64 `json:"lat"`
 		Longitude float64 `json:"lon"`
@@ -93,8 +117,14 @@ func (c *Conn) TriggerHomelink(id int, latitude, longitude float64) error {
 	return c.doCommand(fmt.Sprintf("/api/1/vehicles/%d/command/trigger_homelink", id), &reqBody)
 }
 
-// SpeedLimitSetLimit sets the maximum speed allowed when Speed Limit Mode is active.
+// SpeedLimitSetLimit sets the maximum speed allowed when Speed Limit Mode is active. If the
+// vehicle state has been retrieved, the limit is checked against the allowed range first.
 func (c *Conn) SpeedLimitSetLimit(id int, limitMPH int) error {
+	err := c.validateSpeedLimit(id, limitMPH)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		LimitMPH int `json:"limit_mph"`
 	}
@@ -106,8 +136,20 @@ func (c *Conn) SpeedLimitSetLimit(id int, limitMPH int) error {
 	return c.doCommand(fmt.Sprintf("/api/1/vehicles/%d/command/speed_limit_set_limit", id), &reqBody)
 }
 
+// SpeedLimitSetSpeed sets the maximum speed allowed when Speed Limit Mode is active, like
+// SpeedLimitSetLimit, but accepts the speed in any unit. The speed is rounded to the nearest mile
+// per hour.
+func (c *Conn) SpeedLimitSetSpeed(id int, limit Speed) error {
+	return c.SpeedLimitSetLimit(id, int(math.Round(limit.MilesPerHour())))
+}
+
 // SpeedLimitActivate activates Speed Limit Mode at the currently set speed.
 func (c *Conn) SpeedLimitActivate(id int, pin string) error {
+	err := validatePIN(pin)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Pin string `json:"pin"`
 	}
@@ -121,6 +163,11 @@ func (c *Conn) SpeedLimitActivate(id int, pin string) error {
 
 // SpeedLimitDeactivate deactivates Speed Limit Mode if it is currently active.
 func (c *Conn) SpeedLimitDeactivate(id int, pin string) error {
+	err := validatePIN(pin)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Pin string `json:"pin"`
 	}
@@ -134,6 +181,11 @@ func (c *Conn) SpeedLimitDeactivate(id int, pin string) error {
 
 // SpeedLimitClearPin clears the currently set PIN for Speed Limit Mode.
 func (c *Conn) SpeedLimitClearPin(id int, pin string) error {
+	err := validatePIN(pin)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Pin string `json:"pin"`
 	}
@@ -151,6 +203,13 @@ func (c *Conn) SpeedLimitClearPin(id int, pin string) error {
 // Homelink, Bluetooth and Wifi settings, and the ability to disable mobile access to the car. It
 // also hides your favorites, home, and work locations in navigation.
 func (c *Conn) SetValetMode(id int, on bool, pin string) error {
+	if pin != "" {
+		err := validatePIN(pin)
+		if err != nil {
+			return err
+		}
+	}
+
 	type request struct {
 		On  bool   `json:"on"`
 		Pin string `json:"password"`
@@ -172,6 +231,11 @@ func (c *Conn) ResetValetPin(id int) error {
 
 // SetSentryMode turns sentry mode on or off.
 func (c *Conn) SetSentryMode(id int, on bool) error {
+	err := c.requireStateCapability(id, "sentry mode", func(caps Capabilities) bool { return caps.SentryModeAvailable })
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		On bool `json:"on"`
 	}
@@ -206,6 +270,13 @@ var (
 // OpenTrunk opens either the front or rear trunk. On the Model S and X, it will also close the rear
 // trunk.
 func (c *Conn) OpenTrunk(id int, trunk Trunk) error {
+	if trunk == TrunkRear {
+		err := c.requireConfigCapability(id, "rear trunk", func(caps Capabilities) bool { return caps.CanActuateTrunks })
+		if err != nil {
+			return err
+		}
+	}
+
 	type request struct {
 		Trunk Trunk `json:"which_trunk"`
 	}
@@ -260,6 +331,11 @@ var (
 
 // ActuateSunroof controls the panoramic sunroof on the Model S.
 func (c *Conn) ActuateSunroof(id int, cmd SunroofCommand) error {
+	err := c.requireConfigCapability(id, "sunroof", func(caps Capabilities) bool { return caps.SunRoofInstalled })
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		SunroofCommand SunroofCommand `json:"state"`
 	}
@@ -278,6 +354,11 @@ func (c *Conn) OpenChargePortDoor(id int) error {
 
 // CloseChargePortDoor closes the charge port for vehicles with a motorized charge port door.
 func (c *Conn) CloseChargePortDoor(id int) error {
+	err := c.requireConfigCapability(id, "motorized charge port", func(caps Capabilities) bool { return caps.MotorizedChargePort })
+	if err != nil {
+		return err
+	}
+
 	return c.doCommand(fmt.Sprintf("/api/1/vehicles/%d/command/charge_port_door_close", id), nil)
 }
 
@@ -302,8 +383,14 @@ func (c *Conn) SetChargeLimitMaxRange(id int) error {
 	return c.doCommand(fmt.Sprintf("/api/1/vehicles/%d/command/charge_max_range", id), nil)
 }
 
-// SetChargeLimit sets the charge limit to the given value.
+// SetChargeLimit sets the charge limit to the given value. If the charge state has been
+// retrieved, the limit is checked against the allowed range first.
 func (c *Conn) SetChargeLimit(id int, percent int) error {
+	err := c.validateChargeLimit(id, percent)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Percent int `json:"percent"`
 	}
@@ -329,8 +416,19 @@ func (c *Conn) AutoConditioningStop(id int) error {
 // SetTemperatures sets the target temperature for the climate control (HVAC) system.
 //
 // Note: The parameters are always in Celsius, regardless of the region the car is in or the
-// display settings of the car.
+// display settings of the car. If the climate state has been retrieved, the temperatures are
+// checked against the allowed range first.
 func (c *Conn) SetTemperatures(id int, driver, passenger float64) error {
+	err := c.validateTemperature(id, "driver temperature", driver)
+	if err != nil {
+		return err
+	}
+
+	err = c.validateTemperature(id, "passenger temperature", passenger)
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Driver    float64 `json:"driver_temp"`
 		Passenger float64 `json:"passenger_temp"`
@@ -344,6 +442,12 @@ func (c *Conn) SetTemperatures(id int, driver, passenger float64) error {
 	return c.doCommand(fmt.Sprintf("/api/1/vehicles/%d/command/set_temps", id), &reqBody)
 }
 
+// SetTemperatureSettings sets the target temperature for the climate control (HVAC) system, like
+// SetTemperatures, but accepts temperatures in any unit.
+func (c *Conn) SetTemperatureSettings(id int, driver, passenger Temperature) error {
+	return c.SetTemperatures(id, driver.Celsius(), passenger.Celsius())
+}
+
 // SetPreconditioningMax toggles the climate controls between Max Defrost and the previous setting.
 func (c *Conn) SetPreconditioningMax(id int, on bool) error {
 	type request struct {
@@ -389,6 +493,13 @@ var (
 
 // SetSeatHeater sets the specified seat's heater level.
 func (c *Conn) SetSeatHeater(id int, seat Seat, heatLevel SeatHeatLevel) error {
+	if seat != SeatFrontDriver && seat != SeatFrontPassenger {
+		err := c.requireConfigCapability(id, "rear seat heaters", func(caps Capabilities) bool { return caps.RearSeatHeaters })
+		if err != nil {
+			return err
+		}
+	}
+
 	type request struct {
 		Seat      Seat          `json:"heater"`
 		HeatLevel SeatHeatLevel `json:"level"`
@@ -454,6 +565,11 @@ func (c *Conn) MediaVolumeDown(id int) error {
 
 // Share sends a location for the car to start navigation or play a video in theatre mode.
 func (c *Conn) Share(id int, tag language.Tag, text string) error {
+	err := c.requireConfigCapability(id, "navigation requests", func(caps Capabilities) bool { return caps.CanAcceptNavigationRequests })
+	if err != nil {
+		return err
+	}
+
 	type request struct {
 		Type  string `json:"on"`
 		Value struct {
@@ -7,24 +7,50 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 )
 
 const (
 	// DefaultBaseURL is the URL for the Tesla owner's API.
 	DefaultBaseURL = "https://owner-api.teslamotors.com"
+	// DefaultStreamingURL is the URL for the Tesla streaming API.
+	DefaultStreamingURL = "wss://streaming.vn.teslamotors.com/streaming/"
 )
 
 // Conn represents a connection to the Tesla owner's API.
 type Conn struct {
 	rt           http.RoundTripper
 	baseURL      string
+	streamingURL string
 	clientID     string
 	clientSecret string
 
 	accessToken  string
 	refreshToken string
 
-	debugMode bool
+	logger    Logger
+	logBodies bool
+
+	unknownFieldsHandler func(url string, fields []string)
+
+	hooks []Hooks
+
+	limiterOnce sync.Once
+	limiter     *rateLimiter
+
+	cache *responseCache
+
+	capsMu    sync.RWMutex
+	caps      map[int]Capabilities
+	capsCheck bool
+
+	limitsMu sync.RWMutex
+	limits   map[int]commandLimits
+
+	locationsMu    sync.RWMutex
+	locations      map[int]Location
+	maxLocationAge time.Duration
 }
 
 // NewConn creates a new connection.
@@ -32,21 +58,15 @@ func NewConn(rt http.RoundTripper, baseURL, clientID, clientSecret string) *Conn
 	return &Conn{
 		rt:           rt,
 		baseURL:      baseURL,
+		streamingURL: DefaultStreamingURL,
 		clientID:     clientID,
 		clientSecret: clientSecret,
 	}
 }
 
-// SetDebugMode turns debug mode on or off. If on, all requests and responses are dumped in their
-// raw state to stdout.
-func (c *Conn) SetDebugMode(debug bool) {
-	if debug {
-		c.rt = &debugTransport{
-			r: c.rt,
-		}
-	} else if rt, ok := c.rt.(*debugTransport); ok {
-		c.rt = rt.r
-	}
+// SetStreamingURL allows you to override the websocket URL used by Stream.
+func (c *Conn) SetStreamingURL(url string) {
+	c.streamingURL = url
 }
 
 // SetRefreshToken allows you to override the refresh token received from Authenticate.
@@ -59,13 +79,69 @@ func (c *Conn) SetAccessToken(accessToken string) {
 	c.accessToken = accessToken
 }
 
+// RefreshToken returns the current refresh token, so it can be saved and later restored with
+// SetRefreshToken.
+func (c *Conn) RefreshToken() string {
+	return c.refreshToken
+}
+
+// AccessToken returns the current access token, so it can be saved and later restored with
+// SetAccessToken.
+func (c *Conn) AccessToken() string {
+	return c.accessToken
+}
+
 func (c *Conn) doRequest(method, url string, reqBody, respBody interface{}) error {
+	_, err := c.doRawRequest(method, url, reqBody, respBody)
+	return err
+}
+
+// doRawRequest performs the request and decodes the response into respBody. The raw response is
+// returned whenever one was received, even if it could not be decoded.
+func (c *Conn) doRawRequest(method, url string, reqBody, respBody interface{}) (*RawResponse, error) {
+	return c.doCachedRequest(method, url, reqBody, respBody)
+}
+
+// doObservedRequest performs the request, calling any hooks before and after it. If check is not
+// nil, it is called after the response is decoded, and its error is returned as the result.
+func (c *Conn) doObservedRequest(method, url string, reqBody, respBody interface{}, check func(info *RequestInfo) error) (*RawResponse, error) {
+	info := newRequestInfo(method, url)
+
+	for {
+		if c.limiter != nil {
+			c.limiter.wait(info)
+		}
+
+		info.Start = time.Now()
+
+		c.beforeRequest(info)
+
+		raw, err := c.send(method, url, reqBody, respBody)
+		if err == nil && check != nil {
+			err = check(info)
+		}
+
+		c.afterRequest(info, raw, err)
+
+		if c.limiter != nil && raw != nil && raw.StatusCode == http.StatusTooManyRequests && c.limiter.throttled(info, raw) {
+			retry := *info
+			retry.Retries++
+			info = &retry
+
+			continue
+		}
+
+		return raw, err
+	}
+}
+
+func (c *Conn) send(method, url string, reqBody, respBody interface{}) (*RawResponse, error) {
 	var reqBodyReader io.Reader
 
 	if reqBody != nil {
 		reqBytes, err := json.Marshal(reqBody)
 		if err != nil {
-			return fmt.Errorf("error marshaling request body: %w", err)
+			return nil, fmt.Errorf("error marshaling request body: %w", err)
 		}
 
 		reqBodyReader = bytes.NewReader(reqBytes)
@@ -73,7 +149,7 @@ func (c *Conn) doRequest(method, url string, reqBody, respBody interface{}) erro
 
 	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.baseURL, url), reqBodyReader)
 	if err != nil {
-		return fmt.Errorf("error creating http request: %w", err)
+		return nil, fmt.Errorf("error creating http request: %w", err)
 	}
 
 	if reqBodyReader != nil {
@@ -84,6 +160,10 @@ func (c *Conn) doRequest(method, url string, reqBody, respBody interface{}) erro
 		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", c.accessToken))
 	}
 
+	c.logRequest(req, reqBody)
+
+	start := time.Now()
+
 	resp, err := c.rt.RoundTrip(req)
 
 	if resp != nil {
@@ -91,24 +171,42 @@ func (c *Conn) doRequest(method, url string, reqBody, respBody interface{}) erro
 	}
 
 	if err != nil {
-		return fmt.Errorf("error performing http request: %w", err)
+		c.logError(req, err)
+		return nil, fmt.Errorf("error performing http request: %w", err)
+	}
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+
+	raw := &RawResponse{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       respBytes,
+		Start:      start,
+		Duration:   time.Since(start),
 	}
 
+	c.logResponse(req, raw)
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%w", HTTPStatusError{
+		return raw, fmt.Errorf("%w", HTTPStatusError{
 			statusCode: resp.StatusCode,
 		})
 	}
 
-	respBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return fmt.Errorf("error reading http response body: %w", err)
+		return raw, fmt.Errorf("error reading http response body: %w", err)
 	}
 
 	err = json.Unmarshal(respBytes, respBody)
 	if err != nil {
-		return fmt.Errorf("error unmarshaling response: %w", err)
+		return raw, fmt.Errorf("error unmarshaling response: %w", err)
+	}
+
+	if c.unknownFieldsHandler != nil {
+		if unknown := UnknownFields(respBytes, respBody); len(unknown) > 0 {
+			c.unknownFieldsHandler(url, unknown)
+		}
 	}
 
-	return nil
+	return raw, nil
 }
@@ -0,0 +1,531 @@
+package tesla_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func TestAuthenticate(t *testing.T) {
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	conn := srv.Conn()
+
+	_, err := conn.GetVehicles()
+	assert.True(t, errors.Is(err, tesla.ErrMissingAccessToken))
+
+	err = conn.Authenticate(teslatest.Email, "wrong")
+	var statusErr tesla.HTTPStatusError
+	require.True(t, errors.As(err, &statusErr))
+
+	require.NoError(t, conn.Authenticate(teslatest.Email, teslatest.Password))
+	require.NoError(t, conn.UpdateRefreshToken())
+}
+
+func TestGetVehicles(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	srv.AddVehicle(2, "5YJ3E1EA2KF317000")
+
+	vehicles, err := conn.GetVehicles()
+	require.NoError(t, err)
+	require.Len(t, vehicles, 2)
+	assert.Equal(t, teslatest.VIN, vehicles[0].VIN)
+	assert.Equal(t, "5YJ3E1EA2KF317000", vehicles[1].VIN)
+
+	vehicle, err := conn.GetVehicle(2)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.StateOnline, vehicle.State)
+}
+
+func TestSleepAndWake(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+	v.Sleep()
+
+	_, err := conn.GetChargeState(1)
+	var statusErr tesla.HTTPStatusError
+	require.True(t, errors.As(err, &statusErr))
+	assert.Contains(t, err.Error(), "408")
+
+	err = conn.HonkHorn(1)
+	assert.Error(t, err)
+
+	vehicle, err := conn.WakeUp(1)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.StateAsleep, vehicle.State)
+
+	srv.Advance(v.WakeDelay)
+
+	vehicle, err = conn.GetVehicle(1)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.StateOnline, vehicle.State)
+
+	_, err = conn.GetChargeState(1)
+	assert.NoError(t, err)
+}
+
+func TestChargingProgresses(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	err := conn.StartCharging(1)
+	assert.True(t, errors.Is(err, tesla.ErrCommandError))
+
+	v.PlugIn(false)
+	require.NoError(t, conn.StartCharging(1))
+
+	state, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, tesla.ChargingStateCharging, state.ChargingState)
+	assert.Equal(t, 60, state.BatteryLevel)
+
+	srv.Advance(time.Hour)
+
+	state, err = conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, 70, state.BatteryLevel)
+	assert.InDelta(t, 7.5, state.ChargeEnergyAdded, 0.01)
+
+	srv.Advance(2 * time.Hour)
+
+	state, err = conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, tesla.ChargingStateComplete, state.ChargingState)
+	assert.Equal(t, state.ChargeLimitSoc, state.BatteryLevel)
+}
+
+func TestCommands(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	require.NoError(t, conn.UnlockDoors(1))
+	require.NoError(t, conn.SetTemperatures(1, 22, 23))
+
+	state, err := conn.GetVehicleState(1)
+	require.NoError(t, err)
+	assert.False(t, state.Locked)
+
+	climate, err := conn.GetClimateState(1)
+	require.NoError(t, err)
+	assert.Equal(t, 22.0, climate.DriverTempSetting)
+	assert.Equal(t, 23.0, climate.PassengerTempSetting)
+
+	commands := v.Commands()
+	require.Len(t, commands, 2)
+	assert.Equal(t, "door_unlock", commands[0].Name)
+	assert.Equal(t, "set_temps", commands[1].Name)
+
+	v.Lock()
+	v.CommandResults = map[string]teslatest.CommandResult{
+		"door_lock": {Result: false, Reason: "user_present"},
+	}
+	v.Unlock()
+
+	err = conn.LockDoors(1)
+	assert.True(t, errors.Is(err, tesla.ErrCommandError))
+	assert.Contains(t, err.Error(), "user_present")
+}
+
+func TestLocationCommands(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	require.NoError(t, conn.CloseWindowsAtVehicle(1))
+
+	commands := v.Commands()
+	require.Len(t, commands, 1)
+	assert.Equal(t, "window_control", commands[0].Name)
+	assert.Equal(t, map[string]interface{}{"command": "close", "lat": 37.4919, "lon": -121.9447}, commands[0].Body)
+
+	v.Lock()
+	v.DriveState.GpsAsOf = int(time.Now().Add(-10 * time.Minute).Unix())
+	v.Unlock()
+
+	err := conn.TriggerHomelinkAtVehicle(1)
+	assert.True(t, errors.Is(err, tesla.ErrStaleLocation))
+	assert.Contains(t, err.Error(), "last GPS fix was 10m")
+	assert.Len(t, v.Commands(), 1, "no command is sent without a fresh location")
+
+	conn.SetMaxLocationAge(time.Hour)
+	require.NoError(t, conn.TriggerHomelinkAtVehicle(1))
+	conn.SetMaxLocationAge(0)
+
+	v.Lock()
+	v.DriveState.Latitude, v.DriveState.Longitude = 0, 0
+	v.DriveState.GpsAsOf = int(time.Now().Unix())
+	v.Unlock()
+
+	err = conn.CloseWindowsAtVehicle(1)
+	assert.EqualError(t, err, "vehicle location is stale: no GPS fix")
+
+	v.Lock()
+	v.DriveState.Latitude, v.DriveState.Longitude = 37.4919, -121.9447
+	v.DriveState.GpsAsOf = 0
+	v.Unlock()
+
+	err = conn.CloseWindowsAtVehicle(1)
+	assert.EqualError(t, err, "vehicle location is stale: no GPS fix time")
+	assert.Len(t, v.Commands(), 2)
+}
+
+func TestLocationFromStream(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	stream, err := conn.Stream(1, "")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	require.Eventually(t, func() bool {
+		v.Stream(tesla.StreamingMessage{Timestamp: time.Now(), EstLatitude: 37.5, EstLongitude: -122.1})
+
+		select {
+		case <-stream.Data():
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	requests := len(srv.Requests())
+
+	loc, err := conn.CurrentLocation(1)
+	require.NoError(t, err)
+	assert.Equal(t, 37.5, loc.Latitude)
+	assert.Equal(t, -122.1, loc.Longitude)
+	assert.Equal(t, requests, len(srv.Requests()), "a recent stream sample is used without a request")
+}
+
+func TestLocationFromStaleStream(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	stream, err := conn.Stream(1, "")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	require.Eventually(t, func() bool {
+		v.Stream(tesla.StreamingMessage{Timestamp: time.Now().Add(-10 * time.Minute), EstLatitude: 37.5, EstLongitude: -122.1})
+
+		select {
+		case <-stream.Data():
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	v.Lock()
+	v.DriveState.Latitude, v.DriveState.Longitude = 37.4919, -121.9447
+	v.DriveState.GpsAsOf = int(time.Now().Unix())
+	v.Unlock()
+
+	requests := len(srv.Requests())
+
+	loc, err := conn.CurrentLocation(1)
+	require.NoError(t, err)
+	assert.Equal(t, 37.4919, loc.Latitude)
+	assert.Equal(t, -121.9447, loc.Longitude)
+	assert.Equal(t, requests+1, len(srv.Requests()), "a stale stream sample falls back to the drive state")
+}
+
+func TestInjectedErrors(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	srv.Fail("/charge_state", http.StatusTooManyRequests)
+
+	_, err := conn.GetClimateState(1)
+	require.NoError(t, err)
+
+	_, err = conn.GetChargeState(1)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "429")
+
+	_, err = conn.GetChargeState(1)
+	require.NoError(t, err)
+}
+
+func TestStream(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+
+	stream, err := conn.Stream(1, "")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	sent := tesla.StreamingMessage{
+		Timestamp:    time.Unix(1580000000, 250*int64(time.Millisecond)),
+		Speed:        65,
+		Odometer:     12400.5,
+		SOC:          72,
+		Elevation:    30,
+		EstHeading:   180,
+		EstLatitude:  37.5,
+		EstLongitude: -122.1,
+		Power:        25,
+		Range:        190,
+		EstRange:     170,
+		Heading:      181,
+	}
+
+	// The subscription is processed asynchronously, so keep sending until it is received.
+	var received tesla.StreamingMessage
+
+	require.Eventually(t, func() bool {
+		v.Stream(sent)
+
+		select {
+		case received = <-stream.Data():
+			return true
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.True(t, sent.Timestamp.Equal(received.Timestamp))
+	sent.Timestamp = received.Timestamp
+	assert.Equal(t, sent, received)
+}
+
+func TestUnknownFields(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+	v.Lock()
+	v.ChargeState.Extra = map[string]json.RawMessage{
+		"off_peak_charging_enabled": json.RawMessage(`true`),
+	}
+	v.Unlock()
+
+	var reported []string
+
+	conn.SetUnknownFieldsHandler(func(url string, fields []string) {
+		reported = append(reported, fields...)
+	})
+
+	state, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"response.off_peak_charging_enabled"}, reported)
+	assert.Equal(t, json.RawMessage(`true`), state.Extra["off_peak_charging_enabled"])
+}
+
+func TestRawResponse(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	state, raw, err := conn.GetChargeStateRaw(1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, raw.StatusCode)
+	assert.Equal(t, "application/json", raw.Header.Get("Content-Type"))
+
+	var body struct {
+		Response tesla.ChargeState `json:"response"`
+	}
+	require.NoError(t, json.Unmarshal(raw.Body, &body))
+	assert.Equal(t, *state, body.Response)
+
+	srv.Fail("/charge_state", http.StatusTooManyRequests)
+
+	_, raw, err = conn.GetChargeStateRaw(1)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, raw.StatusCode)
+	assert.Contains(t, string(raw.Body), "Too Many Requests")
+}
+
+func TestLoggerRedactsSecrets(t *testing.T) {
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	srv.AddVehicle(1, "5YJSA1E27HF000001")
+
+	var buf bytes.Buffer
+
+	conn := srv.Conn()
+	conn.SetLogger(tesla.NewTextLogger(&buf))
+	conn.SetLogBodies(true)
+
+	require.NoError(t, conn.Authenticate(teslatest.Email, teslatest.Password))
+	require.NoError(t, conn.SpeedLimitActivate(1, "4321"))
+	require.NoError(t, conn.RemoteStart(1, teslatest.Password))
+
+	logged := buf.String()
+	assert.Contains(t, logged, "speed_limit_activate")
+	assert.NotContains(t, logged, teslatest.Password)
+	assert.NotContains(t, logged, teslatest.ClientSecret)
+	assert.NotContains(t, logged, teslatest.AccessToken)
+	assert.NotContains(t, logged, teslatest.RefreshToken)
+
+	// The PIN is digits, which may also appear in the server's port, so check the logged body.
+	assert.Contains(t, logged, `body="{\"pin\":\"[REDACTED]\"}"`)
+	assert.Contains(t, logged, `body="{\"password\":\"[REDACTED]\"}"`)
+}
+
+func TestHooks(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	v := srv.Vehicle(1)
+	v.Lock()
+	v.CommandResults = map[string]teslatest.CommandResult{
+		"door_lock": {Result: false, Reason: "user_present"},
+	}
+	v.Unlock()
+
+	var (
+		events   []string
+		before   *tesla.RequestInfo
+		results  []tesla.RequestResult
+		messages = make(chan tesla.StreamingMessage, 10)
+	)
+
+	conn.AddHooks(tesla.Hooks{
+		BeforeRequest: func(info *tesla.RequestInfo) {
+			before = info
+			events = append(events, "before "+info.Endpoint)
+		},
+		AfterRequest: func(info *tesla.RequestInfo, result tesla.RequestResult) {
+			assert.True(t, info == before)
+			events = append(events, "after "+info.Endpoint)
+			results = append(results, result)
+		},
+		CommandResult: func(info *tesla.RequestInfo, result bool, reason string) {
+			assert.Equal(t, 1, info.VehicleID)
+			events = append(events, "command "+reason)
+		},
+		StreamMessage: func(vehicleID int, msg tesla.StreamingMessage) {
+			messages <- msg
+		},
+	})
+
+	_, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+
+	err = conn.LockDoors(1)
+	require.Error(t, err)
+
+	srv.Fail("/vehicles", http.StatusTooManyRequests)
+
+	_, err = conn.GetVehicles()
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"before charge_state", "after charge_state",
+		"before command/door_lock", "command user_present", "after command/door_lock",
+		"before vehicles", "after vehicles",
+	}, events)
+
+	require.Len(t, results, 3)
+	assert.Equal(t, http.StatusOK, results[0].StatusCode)
+	assert.NoError(t, results[0].Err)
+	assert.True(t, errors.Is(results[1].Err, tesla.ErrCommandError))
+	assert.Equal(t, http.StatusTooManyRequests, results[2].StatusCode)
+
+	stream, err := conn.Stream(1, "")
+	require.NoError(t, err)
+	defer stream.Close()
+
+	require.Eventually(t, func() bool {
+		v.Stream(tesla.StreamingMessage{Timestamp: time.Now(), Speed: 30})
+
+		select {
+		case msg := <-messages:
+			return msg.Speed == 30
+		case <-time.After(50 * time.Millisecond):
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestRateLimitRetriesAfterThrottling(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	srv.FailWithRetryAfter("/charge_state", http.StatusTooManyRequests, time.Second)
+
+	var retries []int
+
+	conn.AddHooks(tesla.Hooks{
+		AfterRequest: func(info *tesla.RequestInfo, result tesla.RequestResult) {
+			retries = append(retries, info.Retries)
+		},
+	})
+	conn.SetRateLimits(tesla.RateLimits{Data: tesla.Every(time.Millisecond, 10)}, 1)
+
+	start := time.Now()
+
+	_, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+
+	assert.True(t, time.Since(start) >= time.Second)
+	assert.Equal(t, []int{0, 1}, retries)
+}
+
+func TestCache(t *testing.T) {
+	srv, conn := teslatest.NewTestServer(t)
+
+	ttls := tesla.DefaultCacheTTLs()
+	ttls["charge_state"] = time.Minute
+	conn.SetCache(ttls)
+
+	countRequests := func(path string) int {
+		n := 0
+		for _, req := range srv.Requests() {
+			if req.Path == path {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	for i := 0; i < 3; i++ {
+		config, err := conn.GetVehicleConfig(1)
+		require.NoError(t, err)
+		assert.NotEmpty(t, config.CarType)
+	}
+	assert.Equal(t, 1, countRequests("/api/1/vehicles/1/data_request/vehicle_config"))
+
+	// A related command discards the cached response.
+	state, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetChargeLimit(1, state.ChargeLimitSoc-10))
+
+	state2, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, state.ChargeLimitSoc-10, state2.ChargeLimitSoc)
+	assert.Equal(t, 2, countRequests("/api/1/vehicles/1/data_request/charge_state"))
+
+	// Unrelated commands do not.
+	require.NoError(t, conn.HonkHorn(1))
+	_, err = conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, countRequests("/api/1/vehicles/1/data_request/charge_state"))
+
+	// Invalidating forces a fresh read.
+	conn.InvalidateCache(1, "charge_state")
+	_, err = conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, 3, countRequests("/api/1/vehicles/1/data_request/charge_state"))
+
+	// Endpoints without a lifetime are never cached.
+	for i := 0; i < 2; i++ {
+		_, err = conn.GetClimateState(1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, countRequests("/api/1/vehicles/1/data_request/climate_state"))
+}
@@ -0,0 +1,80 @@
+package tesla
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	update = flag.Bool("update", false, "rewrite the golden files in testdata")
+	strict = flag.Bool("strict", false, "fail when a fixture contains fields the structs do not decode")
+)
+
+// TestDecodeFixtures decodes every API response in testdata/<endpoint>/*.json and compares the
+// result to the matching .golden file. Run with -update after adding a fixture or changing a
+// struct, and review the diff.
+func TestDecodeFixtures(t *testing.T) {
+	endpoints := map[string]func() interface{}{
+		"charge_state":          func() interface{} { return &ChargeState{} },
+		"climate_state":         func() interface{} { return &ClimateState{} },
+		"drive_state":           func() interface{} { return &DriveState{} },
+		"gui_settings":          func() interface{} { return &GUISettings{} },
+		"vehicle_config":        func() interface{} { return &VehicleConfig{} },
+		"vehicle_state":         func() interface{} { return &VehicleState{} },
+		"nearby_charging_sites": func() interface{} { return &ChargingSites{} },
+	}
+
+	for endpoint, newValue := range endpoints {
+		fixtures, err := filepath.Glob(filepath.Join("testdata", endpoint, "*.json"))
+		require.NoError(t, err)
+		require.NotEmpty(t, fixtures, "no fixtures for %s", endpoint)
+
+		for _, fixture := range fixtures {
+			fixture := fixture
+			newValue := newValue
+
+			t.Run(strings.TrimSuffix(filepath.ToSlash(fixture), ".json"), func(t *testing.T) {
+				data, err := ioutil.ReadFile(fixture)
+				require.NoError(t, err)
+
+				var raw struct {
+					Response json.RawMessage `json:"response"`
+				}
+				require.NoError(t, json.Unmarshal(data, &raw))
+
+				value := newValue()
+				require.NoError(t, json.Unmarshal(raw.Response, value))
+
+				unknown := UnknownFields(raw.Response, value)
+				if len(unknown) > 0 {
+					if *strict {
+						t.Errorf("fields not decoded: %s", strings.Join(unknown, ", "))
+					} else {
+						t.Logf("fields not decoded: %s", strings.Join(unknown, ", "))
+					}
+				}
+
+				actual, err := json.MarshalIndent(value, "", "  ")
+				require.NoError(t, err)
+				actual = append(actual, '\n')
+
+				golden := strings.TrimSuffix(fixture, ".json") + ".golden"
+
+				if *update {
+					require.NoError(t, ioutil.WriteFile(golden, actual, 0644))
+				}
+
+				expected, err := ioutil.ReadFile(golden)
+				require.NoError(t, err, "run go test -update to create the golden file")
+				assert.Equal(t, string(expected), string(actual))
+			})
+		}
+	}
+}
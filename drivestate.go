@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // DriveState is the current state of driving for the vehicle.
@@ -19,12 +21,67 @@ type DriveState struct {
 	ShiftState              interface{} `json:"shift_state"`
 	Speed                   interface{} `json:"speed"`
 	Timestamp               int64       `json:"timestamp"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the drive state, keeping any unrecognized fields in Extra.
+func (s *DriveState) UnmarshalJSON(data []byte) error {
+	type plain DriveState
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the drive state, including any fields in Extra.
+func (s DriveState) MarshalJSON() ([]byte, error) {
+	type plain DriveState
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// CurrentSpeed returns the speed of the vehicle. It is zero when the vehicle is parked, as the
+// API reports null.
+func (s *DriveState) CurrentSpeed() Speed {
+	if val, ok := s.Speed.(float64); ok {
+		return MilesPerHour(val)
+	}
+
+	return 0
+}
+
+// CurrentPower returns the power being drawn from the battery.
+func (s *DriveState) CurrentPower() Power {
+	return Kilowatts(float64(s.Power))
+}
+
+// Time returns when the drive state was recorded by the vehicle.
+func (s *DriveState) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
+}
+
+// GpsTime returns when the vehicle last received a GPS fix.
+func (s *DriveState) GpsTime() time.Time {
+	return timeFromSeconds(int64(s.GpsAsOf))
 }
 
 // GetDriveState retrieves the driving and position state of the vehicle.
 func (c *Conn) GetDriveState(id int) (*DriveState, error) {
+	val, _, err := c.GetDriveStateRaw(id)
+	return val, err
+}
+
+// GetDriveStateRaw is like GetDriveState, but also returns the raw HTTP response.
+func (c *Conn) GetDriveStateRaw(id int) (*DriveState, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -33,10 +90,10 @@ func (c *Conn) GetDriveState(id int) (*DriveState, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/drive_state", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/drive_state", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	return &respBody.Response, raw, nil
 }
@@ -19,6 +19,11 @@ func (err HTTPStatusError) Error() string {
 	return fmt.Sprintf("http status error \"%s\": %d", err.message, err.statusCode)
 }
 
+// StatusCode returns the HTTP status code of the response.
+func (err HTTPStatusError) StatusCode() int {
+	return err.statusCode
+}
+
 var (
 	// ErrMissingRefreshToken is returned when an API call is made without the required refresh token.
 	ErrMissingRefreshToken = errors.New("missing refresh token")
@@ -26,4 +31,12 @@ var (
 	ErrMissingAccessToken = errors.New("missing access token, authenticate first")
 	// ErrCommandError is returned with executing a command against the vehicle and the Tesla API returns an error message.
 	ErrCommandError = errors.New("error executing command")
+	// ErrInvalidVIN is returned when a VIN is not 17 characters or contains invalid characters.
+	ErrInvalidVIN = errors.New("invalid vin")
+	// ErrUnsupported is returned when capability checks are on and a command is issued for a feature the vehicle does not have.
+	ErrUnsupported = errors.New("not supported by vehicle")
+	// ErrInvalidParameter is wrapped by every ValidationError returned when a command parameter is out of range.
+	ErrInvalidParameter = errors.New("invalid command parameter")
+	// ErrStaleLocation is returned when a command needs the vehicle's location and its last GPS fix is too old.
+	ErrStaleLocation = errors.New("vehicle location is stale")
 )
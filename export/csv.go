@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// CSVWriter writes records as CSV, with a header row.
+type CSVWriter struct {
+	w     *csv.Writer
+	table *Table
+	row   []string
+}
+
+// NewCSVWriter returns a writer of records of the type of example, and writes the header.
+func NewCSVWriter(w io.Writer, example interface{}) (*CSVWriter, error) {
+	table, err := NewTable(example)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCSVWriter(w, table)
+}
+
+func newCSVWriter(w io.Writer, table *Table) (*CSVWriter, error) {
+	cw := &CSVWriter{w: csv.NewWriter(w), table: table}
+
+	if err := cw.w.Write(table.Header()); err != nil {
+		return nil, fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return cw, nil
+}
+
+// Write writes a record. Missing values are empty, and times are RFC 3339 in UTC.
+func (w *CSVWriter) Write(record interface{}) error {
+	values, err := w.table.Values(record)
+	if err != nil {
+		return err
+	}
+
+	w.row = w.row[:0]
+
+	for _, value := range values {
+		w.row = append(w.row, formatCSV(value))
+	}
+
+	if err := w.w.Write(w.row); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return nil
+}
+
+// Flush writes any buffered rows.
+func (w *CSVWriter) Flush() error {
+	w.w.Flush()
+
+	if err := w.w.Error(); err != nil {
+		return fmt.Errorf("error writing CSV: %w", err)
+	}
+
+	return nil
+}
+
+func formatCSV(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return formatFloat(v)
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	}
+
+	return ""
+}
+
+// WriteCSV writes a slice of records, such as a []tesla.StreamingMessage or []*tesla.ChargeState,
+// as CSV. The header is written even if the slice is empty.
+func WriteCSV(w io.Writer, records interface{}) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("records are %T, not a slice", records)
+	}
+
+	table, err := newTable(v.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	cw, err := newCSVWriter(w, table)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := cw.Write(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return cw.Flush()
+}
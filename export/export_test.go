@@ -0,0 +1,121 @@
+package export_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/export"
+)
+
+func TestWriteCSVStream(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, export.WriteCSV(&buf, []tesla.StreamingMessage{
+		{Timestamp: time.Unix(1580000000, 250000000), Speed: 55, Odometer: 12345.6, SOC: 70, EstLatitude: 37.4, EstLongitude: -122.1, ShiftState: 4},
+		{},
+	}))
+
+	assert.Equal(t, `timestamp,speed,odometer,soc,elevation,est_heading,est_lat,est_lng,power,shift_state,range,est_range,heading
+2020-01-26T00:53:20.25Z,55,12345.6,70,0,0,37.4,-122.1,0,4,0,0,0
+,0,0,0,0,0,0,0,0,0,0,0,0
+`, buf.String())
+}
+
+func TestWriteCSVStates(t *testing.T) {
+	var buf bytes.Buffer
+
+	startTime := 1580000000
+
+	require.NoError(t, export.WriteCSV(&buf, []*tesla.ChargeState{
+		{BatteryLevel: 60, ChargingState: tesla.ChargingStateCharging, ScheduledChargingStartTime: &startTime},
+		{BatteryLevel: 61},
+	}))
+
+	rows := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, rows, 3)
+
+	header := strings.Split(rows[0], ",")
+	first := strings.Split(rows[1], ",")
+	second := strings.Split(rows[2], ",")
+
+	column := func(row []string, name string) string {
+		for i, n := range header {
+			if n == name {
+				return row[i]
+			}
+		}
+
+		t.Fatalf("no column %s", name)
+
+		return ""
+	}
+
+	assert.Equal(t, "60", column(first, "battery_level"))
+	assert.Equal(t, "Charging", column(first, "charging_state"))
+	assert.Equal(t, "1580000000", column(first, "scheduled_charging_start_time"))
+	assert.Equal(t, "", column(second, "scheduled_charging_start_time"), "nil pointers are empty")
+	assert.NotContains(t, header, "Extra")
+}
+
+func TestTable(t *testing.T) {
+	table, err := export.NewTable(tesla.VehicleState{})
+	require.NoError(t, err)
+
+	assert.Contains(t, table.Header(), "speed_limit_mode.current_limit_mph", "nested objects are flattened")
+
+	var state tesla.VehicleState
+	state.SpeedLimitMode.CurrentLimitMph = 65
+
+	values, err := table.Values(&state)
+	require.NoError(t, err)
+
+	for i, c := range table.Columns() {
+		if c.Name == "speed_limit_mode.current_limit_mph" {
+			assert.Equal(t, export.KindFloat, c.Kind)
+			assert.Equal(t, 65.0, values[i])
+		}
+	}
+
+	drive, err := export.NewTable(&tesla.DriveState{})
+	require.NoError(t, err)
+
+	values, err = drive.Values(tesla.DriveState{ShiftState: "D", Speed: 30.0})
+	require.NoError(t, err)
+
+	for i, c := range drive.Columns() {
+		switch c.Name {
+		case "shift_state":
+			assert.True(t, c.Optional)
+			assert.Equal(t, "D", values[i])
+		case "speed":
+			assert.Equal(t, "30", values[i])
+		}
+	}
+
+	values, err = drive.Values(tesla.DriveState{})
+	require.NoError(t, err)
+	assert.Contains(t, values, nil, "null values are missing")
+
+	_, err = drive.Values(tesla.ChargeState{})
+	assert.EqualError(t, err, "record is tesla.ChargeState, not tesla.DriveState")
+
+	_, err = export.NewTable(42)
+	assert.EqualError(t, err, "unsupported record type int")
+}
+
+func TestCSVWriterHeaderOnly(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := export.NewCSVWriter(&buf, tesla.GUISettings{})
+	require.NoError(t, err)
+	require.NoError(t, w.Flush())
+
+	assert.Equal(t, 1, strings.Count(buf.String(), "\n"), "the header is written without records")
+	assert.True(t, strings.HasPrefix(buf.String(), "gui_24_hour_time,"))
+}
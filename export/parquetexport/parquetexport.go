@@ -0,0 +1,187 @@
+// Package parquetexport writes vehicle telemetry as Parquet files, a columnar format that is
+// compact and fast to query for large histories. It writes the same columns as the export
+// package's CSV, and is a separate module so that the export package does not depend on a Parquet
+// implementation.
+//
+//	w, err := parquetexport.NewWriter(f, tesla.StreamingMessage{})
+//	...
+//	err = w.Write(msg)
+//	...
+//	err = w.Close()
+//
+// Fields of nested objects are written as groups. Times are timestamps in milliseconds, UTC.
+package parquetexport
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/rickbassham/tesla/export"
+)
+
+// Writer writes records as a Parquet file.
+type Writer struct {
+	w      *parquet.Writer
+	table  *export.Table
+	leaves []leaf
+	row    parquet.Row
+}
+
+// leaf is where a table column is in the Parquet schema.
+type leaf struct {
+	index int
+	// definition is the definition level of a value that is present.
+	definition int
+}
+
+// NewWriter returns a writer of records of the type of example, which must be a struct or a
+// pointer to one. Close must be called to finish the file.
+func NewWriter(w io.Writer, example interface{}) (*Writer, error) {
+	table, err := export.NewTable(example)
+	if err != nil {
+		return nil, err
+	}
+
+	root := parquet.Group{}
+
+	for _, c := range table.Columns() {
+		node := leafNode(c.Kind)
+		if c.Optional {
+			node = parquet.Optional(node)
+		}
+
+		group := root
+		path := strings.Split(c.Name, ".")
+
+		for _, name := range path[:len(path)-1] {
+			child, ok := group[name].(parquet.Group)
+			if !ok {
+				child = parquet.Group{}
+				group[name] = child
+			}
+
+			group = child
+		}
+
+		group[path[len(path)-1]] = node
+	}
+
+	typ := reflect.TypeOf(example)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	schema := parquet.NewSchema(typ.Name(), root)
+
+	// The schema orders columns by name, so find where each table column went.
+	index := make(map[string]int)
+	for i, path := range schema.Columns() {
+		index[strings.Join(path, ".")] = i
+	}
+
+	leaves := make([]leaf, 0, len(table.Columns()))
+	for _, c := range table.Columns() {
+		l := leaf{index: index[c.Name]}
+		if c.Optional {
+			l.definition = 1
+		}
+
+		leaves = append(leaves, l)
+	}
+
+	return &Writer{
+		w:      parquet.NewWriter(w, schema),
+		table:  table,
+		leaves: leaves,
+		row:    make(parquet.Row, len(leaves)),
+	}, nil
+}
+
+func leafNode(kind export.Kind) parquet.Node {
+	switch kind {
+	case export.KindBool:
+		return parquet.Leaf(parquet.BooleanType)
+	case export.KindInt:
+		return parquet.Int(64)
+	case export.KindFloat:
+		return parquet.Leaf(parquet.DoubleType)
+	case export.KindTime:
+		return parquet.Timestamp(parquet.Millisecond)
+	}
+
+	return parquet.String()
+}
+
+// Write writes a record. Records are buffered and written in row groups.
+func (w *Writer) Write(record interface{}) error {
+	values, err := w.table.Values(record)
+	if err != nil {
+		return err
+	}
+
+	for i, value := range values {
+		l := w.leaves[i]
+
+		var v parquet.Value
+
+		switch value := value.(type) {
+		case nil:
+			w.row[l.index] = parquet.Value{}.Level(0, 0, l.index)
+			continue
+		case bool:
+			v = parquet.BooleanValue(value)
+		case int64:
+			v = parquet.Int64Value(value)
+		case float64:
+			v = parquet.DoubleValue(value)
+		case string:
+			v = parquet.ByteArrayValue([]byte(value))
+		case time.Time:
+			v = parquet.Int64Value(value.UnixNano() / int64(time.Millisecond))
+		}
+
+		w.row[l.index] = v.Level(0, l.definition, l.index)
+	}
+
+	if _, err := w.w.WriteRows([]parquet.Row{w.row}); err != nil {
+		return fmt.Errorf("error writing Parquet: %w", err)
+	}
+
+	return nil
+}
+
+// Close writes any buffered records and the file footer. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.w.Close(); err != nil {
+		return fmt.Errorf("error writing Parquet: %w", err)
+	}
+
+	return nil
+}
+
+// Write writes a slice of records, such as a []tesla.StreamingMessage or []*tesla.ChargeState, as
+// a Parquet file.
+func Write(w io.Writer, records interface{}) error {
+	v := reflect.ValueOf(records)
+	if v.Kind() != reflect.Slice {
+		return fmt.Errorf("records are %T, not a slice", records)
+	}
+
+	pw, err := NewWriter(w, reflect.Zero(v.Type().Elem()).Interface())
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < v.Len(); i++ {
+		if err := pw.Write(v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return pw.Close()
+}
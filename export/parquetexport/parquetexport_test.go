@@ -0,0 +1,112 @@
+package parquetexport_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/export/parquetexport"
+)
+
+// readColumns reads a Parquet file into its values by column path.
+func readColumns(t *testing.T, data []byte) map[string][]parquet.Value {
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	r := parquet.NewReader(f)
+	defer r.Close()
+
+	columns := make(map[string][]parquet.Value)
+	paths := f.Schema().Columns()
+
+	rows := make([]parquet.Row, 10)
+
+	for {
+		n, err := r.ReadRows(rows)
+
+		for _, row := range rows[:n] {
+			row.Range(func(i int, values []parquet.Value) bool {
+				name := ""
+				for j, p := range paths[i] {
+					if j > 0 {
+						name += "."
+					}
+					name += p
+				}
+
+				columns[name] = append(columns[name], values...)
+
+				return true
+			})
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	return columns
+}
+
+func TestWriteStream(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, parquetexport.Write(&buf, []tesla.StreamingMessage{
+		{Timestamp: time.Unix(1580000000, 0), Speed: 55, EstLatitude: 37.4},
+		{Timestamp: time.Unix(1580000001, 0), Speed: 56, EstLatitude: 37.5},
+		{},
+	}))
+
+	columns := readColumns(t, buf.Bytes())
+
+	require.Len(t, columns["speed"], 3)
+	assert.Equal(t, int64(55), columns["speed"][0].Int64())
+	assert.Equal(t, int64(56), columns["speed"][1].Int64())
+	assert.Equal(t, 37.5, columns["est_lat"][1].Double())
+	assert.Equal(t, int64(1580000001000), columns["timestamp"][1].Int64())
+	assert.True(t, columns["timestamp"][2].IsNull(), "the zero time is null")
+}
+
+func TestWriteStates(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := parquetexport.NewWriter(&buf, &tesla.VehicleState{})
+	require.NoError(t, err)
+
+	var state tesla.VehicleState
+	state.Locked = true
+	state.CarVersion = "2020.4.1"
+	state.SpeedLimitMode.CurrentLimitMph = 65
+
+	require.NoError(t, w.Write(&state))
+	require.NoError(t, w.Write(tesla.VehicleState{}))
+	assert.EqualError(t, w.Write(tesla.ChargeState{}), "record is tesla.ChargeState, not tesla.VehicleState")
+	require.NoError(t, w.Close())
+
+	columns := readColumns(t, buf.Bytes())
+
+	assert.Equal(t, true, columns["locked"][0].Boolean())
+	assert.Equal(t, false, columns["locked"][1].Boolean())
+	assert.Equal(t, "2020.4.1", columns["car_version"][0].String())
+	assert.Equal(t, 65.0, columns["speed_limit_mode.current_limit_mph"][0].Double(), "nested objects are groups")
+}
+
+func TestWriteOptional(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, parquetexport.Write(&buf, []*tesla.DriveState{
+		{ShiftState: "D", Speed: 30.0},
+		{},
+	}))
+
+	columns := readColumns(t, buf.Bytes())
+
+	assert.Equal(t, "D", columns["shift_state"][0].String())
+	assert.True(t, columns["shift_state"][1].IsNull())
+	assert.Equal(t, "30", columns["speed"][0].String())
+}
@@ -0,0 +1,264 @@
+// Package export writes vehicle telemetry as flat files for analysis. Records are streaming
+// messages or states, such as *tesla.ChargeState, and become rows with a column per field.
+//
+//	err := export.WriteCSV(f, msgs)
+//
+// Columns are named by the fields' JSON tags, which are the names used by the API, so headers are
+// stable across releases of this package. Fields of nested objects are flattened, with names
+// joined by a dot, such as speed_limit_mode.active. Fields not decoded by the tesla package, kept
+// in Extra, are not exported.
+//
+// The parquetexport module writes the same columns to Parquet files, for large histories.
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of the values in a column.
+type Kind int
+
+// The kinds of column. Values of each kind are bool, int64, float64, string, and time.Time.
+const (
+	KindBool Kind = iota
+	KindInt
+	KindFloat
+	KindString
+	KindTime
+)
+
+// Column is a column of a table.
+type Column struct {
+	Name string
+	Kind Kind
+	// Optional is true if values may be missing, such as for a nil pointer or interface field.
+	Optional bool
+
+	index []int
+}
+
+// Table maps records of a struct type to rows.
+type Table struct {
+	typ     reflect.Type
+	columns []Column
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// NewTable returns the table for records of the type of example, which must be a struct or a
+// pointer to one.
+func NewTable(example interface{}) (*Table, error) {
+	return newTable(reflect.TypeOf(example))
+}
+
+func newTable(typ reflect.Type) (*Table, error) {
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+
+	if typ == nil || typ.Kind() != reflect.Struct || typ == timeType {
+		return nil, fmt.Errorf("unsupported record type %v", typ)
+	}
+
+	t := &Table{typ: typ}
+	t.addColumns(typ, "", nil)
+
+	return t, nil
+}
+
+// addColumns adds a column for each exported field of typ, named as encoding/json would.
+func (t *Table) addColumns(typ reflect.Type, prefix string, index []int) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		fieldType := field.Type
+
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			t.addColumns(fieldType, prefix, fieldIndex)
+			continue
+		}
+
+		optional := false
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			optional = true
+		}
+
+		if fieldType.Kind() == reflect.Struct && fieldType != timeType && !optional {
+			t.addColumns(fieldType, prefix+name+".", fieldIndex)
+			continue
+		}
+
+		kind, kindOptional := kindOf(fieldType)
+
+		t.columns = append(t.columns, Column{
+			Name:     prefix + name,
+			Kind:     kind,
+			Optional: optional || kindOptional,
+			index:    fieldIndex,
+		})
+	}
+}
+
+// jsonName returns the name encoding/json uses for a field, and false if it is not encoded.
+func jsonName(field reflect.StructField) (string, bool) {
+	if field.PkgPath != "" && !field.Anonymous {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	if i := strings.Index(tag, ","); i >= 0 {
+		tag = tag[:i]
+	}
+
+	if tag == "" {
+		return field.Name, true
+	}
+
+	return tag, true
+}
+
+// kindOf returns the kind of column for a field type, and whether its values may be missing. The
+// zero time is missing. Interface, slice, map, and pointer-to-struct fields are strings, holding
+// JSON unless they are a plain value.
+func kindOf(typ reflect.Type) (Kind, bool) {
+	switch {
+	case typ == timeType:
+		return KindTime, true
+	case typ.Kind() == reflect.Bool:
+		return KindBool, false
+	case typ.Kind() >= reflect.Int && typ.Kind() <= reflect.Uint64:
+		return KindInt, false
+	case typ.Kind() == reflect.Float32 || typ.Kind() == reflect.Float64:
+		return KindFloat, false
+	case typ.Kind() == reflect.String:
+		return KindString, false
+	}
+
+	return KindString, true
+}
+
+// Columns returns the columns of the table.
+func (t *Table) Columns() []Column {
+	return t.columns
+}
+
+// Header returns the names of the columns.
+func (t *Table) Header() []string {
+	names := make([]string, 0, len(t.columns))
+
+	for _, c := range t.columns {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// Values returns the values of the record's columns, in the column's Kind, or nil if missing.
+// The record must be of the table's type, or a pointer to it.
+func (t *Table) Values(record interface{}) ([]interface{}, error) {
+	v := reflect.ValueOf(record)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, errors.New("record is nil")
+		}
+
+		v = v.Elem()
+	}
+
+	if !v.IsValid() || v.Type() != t.typ {
+		return nil, fmt.Errorf("record is %T, not %v", record, t.typ)
+	}
+
+	values := make([]interface{}, 0, len(t.columns))
+
+	for _, c := range t.columns {
+		value, err := columnValue(v, c)
+		if err != nil {
+			return nil, fmt.Errorf("error exporting %s: %w", c.Name, err)
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func columnValue(record reflect.Value, c Column) (interface{}, error) {
+	v := record
+
+	for _, i := range c.index {
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil, nil
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(i)
+	}
+
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, nil
+		}
+
+		v = v.Elem()
+	}
+
+	switch c.Kind {
+	case KindTime:
+		if t := v.Interface().(time.Time); !t.IsZero() {
+			return t, nil
+		}
+
+		return nil, nil
+	case KindBool:
+		return v.Bool(), nil
+	case KindInt:
+		if v.Kind() >= reflect.Uint && v.Kind() <= reflect.Uint64 {
+			return int64(v.Uint()), nil
+		}
+
+		return v.Int(), nil
+	case KindFloat:
+		return v.Float(), nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Float32, reflect.Float64:
+		return formatFloat(v.Float()), nil
+	}
+
+	data, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
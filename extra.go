@@ -0,0 +1,290 @@
+package tesla
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// knownFieldsCache maps a struct type to the set of JSON keys it decodes.
+var knownFieldsCache sync.Map
+
+// jsonFields is the set of JSON keys a struct type decodes, and the type each decodes into.
+type jsonFields struct {
+	types map[string]reflect.Type
+	// names are the keys in field order, for case-insensitive matching.
+	names []string
+}
+
+// lookup returns the type key decodes into. As in encoding/json, an exact match is preferred,
+// and otherwise the first key that matches case-insensitively is used.
+func (f *jsonFields) lookup(key string) (reflect.Type, bool) {
+	if t, ok := f.types[key]; ok {
+		return t, true
+	}
+
+	for _, name := range f.names {
+		if strings.EqualFold(name, key) {
+			return f.types[name], true
+		}
+	}
+
+	return nil, false
+}
+
+// jsonField is a field of a struct as encoding/json sees it.
+type jsonField struct {
+	name   string
+	typ    reflect.Type
+	depth  int
+	tagged bool
+}
+
+// knownFields returns the keys t decodes, following the rules of encoding/json: unexported fields
+// are skipped, untagged fields use the Go field name, and the fields of embedded structs are
+// promoted. If promoted fields share a name, the shallowest is used, then the tagged one; if that
+// leaves more than one, the name is ambiguous and none is used.
+func knownFields(t reflect.Type) *jsonFields {
+	if fields, ok := knownFieldsCache.Load(t); ok {
+		return fields.(*jsonFields)
+	}
+
+	var all []jsonField
+
+	collectFields(t, 0, make(map[reflect.Type]bool), &all)
+
+	byName := make(map[string][]jsonField)
+
+	for _, f := range all {
+		byName[f.name] = append(byName[f.name], f)
+	}
+
+	fields := &jsonFields{types: make(map[string]reflect.Type)}
+
+	for _, f := range all {
+		if _, ok := fields.types[f.name]; ok {
+			continue
+		}
+
+		if dominant, ok := dominantField(byName[f.name]); ok {
+			fields.types[f.name] = dominant.typ
+			fields.names = append(fields.names, f.name)
+		}
+	}
+
+	knownFieldsCache.Store(t, fields)
+
+	return fields
+}
+
+// collectFields appends the fields of t, and of the structs it embeds, in field order.
+func collectFields(t reflect.Type, depth int, visiting map[reflect.Type]bool, fields *[]jsonField) {
+	if visiting[t] {
+		return
+	}
+
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+
+		if f.Anonymous {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if name == "" && ft.Kind() == reflect.Struct {
+				collectFields(ft, depth+1, visiting, fields)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+
+		tagged := name != ""
+		if !tagged {
+			name = f.Name
+		}
+
+		*fields = append(*fields, jsonField{name: name, typ: f.Type, depth: depth, tagged: tagged})
+	}
+}
+
+// dominantField returns the field a key decodes into, given every field with that name.
+func dominantField(fields []jsonField) (jsonField, bool) {
+	depth := fields[0].depth
+
+	for _, f := range fields {
+		if f.depth < depth {
+			depth = f.depth
+		}
+	}
+
+	var shallowest, tagged []jsonField
+
+	for _, f := range fields {
+		if f.depth != depth {
+			continue
+		}
+
+		shallowest = append(shallowest, f)
+
+		if f.tagged {
+			tagged = append(tagged, f)
+		}
+	}
+
+	switch {
+	case len(shallowest) == 1:
+		return shallowest[0], true
+	case len(tagged) == 1:
+		return tagged[0], true
+	}
+
+	return jsonField{}, false
+}
+
+// unmarshalExtra decodes data into v, a pointer to a struct, and returns the keys that v does not
+// decode.
+func unmarshalExtra(data []byte, v interface{}) (map[string]json.RawMessage, error) {
+	err := json.Unmarshal(data, v)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj map[string]json.RawMessage
+
+	err = json.Unmarshal(data, &obj)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := knownFields(reflect.TypeOf(v).Elem())
+
+	var extra map[string]json.RawMessage
+
+	for key, val := range obj {
+		if _, ok := fields.lookup(key); ok {
+			continue
+		}
+
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+
+		extra[key] = val
+	}
+
+	return extra, nil
+}
+
+// marshalExtra encodes v, a struct, and appends the extra keys after its own fields. Keys that v
+// already encodes are not duplicated.
+func marshalExtra(v interface{}, extra map[string]json.RawMessage) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil || len(extra) == 0 {
+		return data, err
+	}
+
+	fields := knownFields(reflect.TypeOf(v))
+
+	keys := make([]string, 0, len(extra))
+	for key := range extra {
+		if _, ok := fields.lookup(key); !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+
+	buf.Write(data[:len(data)-1])
+
+	for i, key := range keys {
+		if i > 0 || len(data) > 2 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(extra[key])
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnknownFields returns the dotted paths of every key in data that would not be decoded into v,
+// including keys in nested objects and arrays. It is used to detect changes to the API.
+func UnknownFields(data []byte, v interface{}) []string {
+	var unknown []string
+
+	collectUnknownFields(bytes.TrimSpace(data), reflect.TypeOf(v), "", &unknown)
+	sort.Strings(unknown)
+
+	return unknown
+}
+
+func collectUnknownFields(data []byte, t reflect.Type, prefix string, unknown *[]string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return
+		}
+
+		fields := knownFields(t)
+
+		for key, val := range obj {
+			fieldType, ok := fields.lookup(key)
+			if !ok {
+				*unknown = append(*unknown, prefix+key)
+				continue
+			}
+
+			collectUnknownFields(val, fieldType, prefix+key+".", unknown)
+		}
+	case reflect.Slice, reflect.Array:
+		var items []json.RawMessage
+		if json.Unmarshal(data, &items) != nil {
+			return
+		}
+
+		for _, item := range items {
+			collectUnknownFields(item, t.Elem(), prefix, unknown)
+		}
+	}
+}
+
+// SetUnknownFieldsHandler turns on strict mode. Every response is checked for keys the structs in
+// this package do not decode, and the handler is called with the request URL and their dotted
+// paths, such as "response.tpms_pressure_fl". This is useful for monitoring changes to the API.
+// Pass nil to turn strict mode off.
+func (c *Conn) SetUnknownFieldsHandler(handler func(url string, fields []string)) {
+	c.unknownFieldsHandler = handler
+}
@@ -0,0 +1,83 @@
+package tesla
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type extraBase struct {
+	Shared string
+	Base   int `json:"base"`
+}
+
+type extraOther struct {
+	Shared string
+	Other  int `json:"other"`
+}
+
+type extraNested struct {
+	Inner int `json:"inner"`
+}
+
+type extraFields struct {
+	extraBase
+	extraOther
+	Tagged   int `json:"tagged"`
+	Untagged int
+	Skipped  int `json:"-"`
+	Dash     int `json:"-,"`
+	hidden   int
+	Nested   extraNested `json:"nested"`
+}
+
+func TestKnownFields(t *testing.T) {
+	fields := knownFields(reflect.TypeOf(extraFields{}))
+
+	assert.Equal(t, []string{"base", "other", "tagged", "Untagged", "-", "nested"}, fields.names,
+		"Shared is ambiguous between the embedded structs")
+
+	for _, tc := range []struct {
+		key   string
+		known bool
+	}{
+		{"tagged", true},
+		{"TAGGED", true},
+		{"Untagged", true},
+		{"untagged", true},
+		{"base", true},
+		{"other", true},
+		{"-", true},
+		{"Shared", false},
+		{"Skipped", false},
+		{"hidden", false},
+		{"inner", false},
+	} {
+		_, ok := fields.lookup(tc.key)
+		assert.Equal(t, tc.known, ok, tc.key)
+	}
+}
+
+func TestUnknownFieldsFollowsEncodingJSON(t *testing.T) {
+	data := []byte(`{"Tagged": 1, "untagged": 2, "base": 3, "other": 4, "shared": "x", "hidden": 5,
+		"nested": {"INNER": 6, "outer": 7}}`)
+
+	var v extraFields
+	require.NoError(t, json.Unmarshal(data, &v))
+	assert.Equal(t, 1, v.Tagged)
+	assert.Equal(t, 2, v.Untagged)
+	assert.Equal(t, 6, v.Nested.Inner)
+	assert.Zero(t, v.hidden)
+
+	assert.Equal(t, []string{"hidden", "nested.outer", "shared"}, UnknownFields(data, v))
+
+	extra, err := unmarshalExtra(data, &v)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]json.RawMessage{
+		"hidden": json.RawMessage(`5`),
+		"shared": json.RawMessage(`"x"`),
+	}, extra)
+}
@@ -0,0 +1,478 @@
+// Package gateway exposes the vehicles on a Conn over a local HTTP API, so services can read
+// vehicle state and issue commands without holding the owner's credentials.
+//
+// The API mirrors the owner's API, so a service can use a tesla.Conn pointed at the gateway, with
+// its gateway API key as the access token:
+//
+//	conn := tesla.NewConn(http.DefaultTransport, "http://localhost:8080", "", "")
+//	conn.SetAccessToken(apiKey)
+//
+// The supported endpoints are:
+//
+//	GET  /api/1/vehicles
+//	GET  /api/1/vehicles/{id}
+//	GET  /api/1/vehicles/{id}/data_request/{charge_state,climate_state,drive_state,gui_settings,vehicle_config,vehicle_state}
+//	GET  /api/1/vehicles/{id}/mobile_enabled
+//	GET  /api/1/vehicles/{id}/nearby_charging_sites
+//	POST /api/1/vehicles/{id}/wake_up
+//	POST /api/1/vehicles/{id}/command/{name}
+//
+// Every key may read state. Waking the vehicle and commands are only allowed for the keys whose
+// scope includes them. remote_start_drive is not offered, as it needs the owner's password.
+// Responses are cached by the Conn, so many services polling the gateway make few requests to the
+// owner's API; see DefaultCacheTTLs.
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/commands"
+)
+
+// Key is an API key for the gateway.
+type Key struct {
+	// Name identifies the key in the audit log.
+	Name string `json:"name"`
+	// Secret is the key itself, sent by clients as a bearer token.
+	Secret string `json:"key"`
+	// Commands are the names of the commands the key may issue, such as "door_lock", or "*" for
+	// all commands. "wake_up" allows waking the vehicle.
+	Commands []string `json:"commands"`
+}
+
+// Allows reports whether the key may issue the named command.
+func (k Key) Allows(command string) bool {
+	for _, allowed := range k.Commands {
+		if allowed == "*" || allowed == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AuditEntry records a request made to the gateway.
+type AuditEntry struct {
+	Time      time.Time     `json:"time"`
+	Key       string        `json:"key"`
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	VehicleID int           `json:"vehicle_id,omitempty"`
+	Command   string        `json:"command,omitempty"`
+	Status    int           `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// LogAudit returns an audit function that logs each entry at info level. Requests that were
+// rejected or failed are logged at warn level.
+func LogAudit(logger tesla.Logger) func(AuditEntry) {
+	return func(entry AuditEntry) {
+		args := []interface{}{
+			"key", entry.Key,
+			"method", entry.Method,
+			"path", entry.Path,
+			"status", entry.Status,
+			"duration", entry.Duration,
+		}
+
+		if entry.Command != "" {
+			args = append(args, "command", entry.Command)
+		}
+
+		if entry.Error != "" {
+			args = append(args, "error", entry.Error)
+			logger.Warn("gateway request", args...)
+
+			return
+		}
+
+		logger.Info("gateway request", args...)
+	}
+}
+
+// Config configures a Gateway.
+type Config struct {
+	// Keys are the API keys accepted by the gateway.
+	Keys []Key
+
+	// CacheTTLs are passed to the Conn's SetCache. If nil, DefaultCacheTTLs is used. An empty map
+	// caches nothing.
+	CacheTTLs map[string]time.Duration
+
+	// Audit, if set, is called after every request.
+	Audit func(AuditEntry)
+
+	// TokensRefreshed, if set, is called after the gateway refreshes the Conn's access token, so
+	// the new tokens can be saved.
+	TokensRefreshed func(conn *tesla.Conn)
+}
+
+// StateCacheTTL is how long DefaultCacheTTLs caches the charge, climate, drive, and vehicle
+// state. It is short so services still see changes promptly, but services polling at the same
+// time share a request.
+const StateCacheTTL = 10 * time.Second
+
+// maxBodySize limits the size of a request body. Command parameters are only a few bytes.
+const maxBodySize = 64 << 10
+
+// DefaultCacheTTLs returns tesla.DefaultCacheTTLs with StateCacheTTL added for the vehicle state
+// endpoints. Successful commands discard the state they change, so it is not served stale.
+func DefaultCacheTTLs() map[string]time.Duration {
+	ttls := tesla.DefaultCacheTTLs()
+
+	for _, endpoint := range []string{"charge_state", "climate_state", "drive_state", "vehicle_state"} {
+		ttls[endpoint] = StateCacheTTL
+	}
+
+	return ttls
+}
+
+// Gateway is an http.Handler serving the gateway API.
+type Gateway struct {
+	conn *tesla.Conn
+	cfg  Config
+	now  func() time.Time
+
+	// mu is held for reading during requests to the Conn, and for writing while refreshing its
+	// access token.
+	mu sync.RWMutex
+}
+
+// New returns a gateway for the vehicles on conn. It enables the Conn's response cache.
+func New(conn *tesla.Conn, cfg Config) *Gateway {
+	ttls := cfg.CacheTTLs
+	if ttls == nil {
+		ttls = DefaultCacheTTLs()
+	}
+
+	conn.SetCache(ttls)
+
+	return &Gateway{
+		conn: conn,
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// errorResponse is an error to write to the client.
+type errorResponse struct {
+	status  int
+	message string
+}
+
+func (err *errorResponse) Error() string {
+	return err.message
+}
+
+func errorf(status int, format string, args ...interface{}) *errorResponse {
+	return &errorResponse{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// ServeHTTP serves a gateway API request.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := g.now()
+
+	entry := AuditEntry{
+		Time:   start,
+		Method: r.Method,
+		Path:   r.URL.Path,
+	}
+
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+	}
+
+	resp, err := g.serve(r, &entry)
+
+	if err != nil {
+		var errResp *errorResponse
+		if !errors.As(err, &errResp) {
+			errResp = upstreamError(err)
+		}
+
+		entry.Status = errResp.status
+		entry.Error = errResp.message
+
+		writeJSON(w, errResp.status, map[string]interface{}{
+			"response": nil,
+			"error":    errResp.message,
+		})
+	} else {
+		entry.Status = http.StatusOK
+
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"response": resp,
+		})
+	}
+
+	if g.cfg.Audit != nil {
+		entry.Duration = g.now().Sub(start)
+		g.cfg.Audit(entry)
+	}
+}
+
+func (g *Gateway) serve(r *http.Request, entry *AuditEntry) (interface{}, error) {
+	key, ok := g.authenticate(r)
+	if !ok {
+		return nil, errorf(http.StatusUnauthorized, "invalid api key")
+	}
+
+	entry.Key = key.Name
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 3 || parts[0] != "api" || parts[1] != "1" || parts[2] != "vehicles" {
+		return nil, errorf(http.StatusNotFound, "not found")
+	}
+
+	parts = parts[3:]
+
+	if len(parts) == 0 {
+		if r.Method != http.MethodGet {
+			return nil, errorf(http.StatusMethodNotAllowed, "method not allowed")
+		}
+
+		var vehicles []tesla.Vehicle
+
+		err := g.call(func() (err error) {
+			vehicles, err = g.conn.GetVehicles()
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range vehicles {
+			vehicles[i] = redact(vehicles[i])
+		}
+
+		return vehicles, nil
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errorf(http.StatusNotFound, "invalid vehicle id %q", parts[0])
+	}
+
+	entry.VehicleID = id
+	parts = parts[1:]
+
+	switch {
+	case len(parts) == 0 && r.Method == http.MethodGet:
+		var v *tesla.Vehicle
+
+		err := g.call(func() (err error) {
+			v, err = g.conn.GetVehicle(id)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return redact(*v), nil
+	case len(parts) == 2 && parts[0] == "data_request" && r.Method == http.MethodGet:
+		return g.read(dataRequests, id, parts[1])
+	case len(parts) == 1 && parts[0] != "wake_up" && r.Method == http.MethodGet:
+		return g.read(vehicleRequests, id, parts[0])
+	case len(parts) == 1 && parts[0] == "wake_up" && r.Method == http.MethodPost:
+		entry.Command = parts[0]
+
+		if !key.Allows(parts[0]) {
+			return nil, errorf(http.StatusForbidden, "api key may not wake the vehicle")
+		}
+
+		var v *tesla.Vehicle
+
+		err := g.call(func() (err error) {
+			v, err = g.conn.WakeUp(id)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return redact(*v), nil
+	case len(parts) == 2 && parts[0] == "command" && r.Method == http.MethodPost:
+		entry.Command = parts[1]
+
+		return g.command(r, key, id, parts[1])
+	}
+
+	return nil, errorf(http.StatusNotFound, "not found")
+}
+
+// authenticate returns the key sent as a bearer token.
+func (g *Gateway) authenticate(r *http.Request) (Key, bool) {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return Key{}, false
+	}
+
+	secret := []byte(strings.TrimPrefix(auth, "Bearer "))
+
+	for _, key := range g.cfg.Keys {
+		if key.Secret != "" && subtle.ConstantTimeCompare(secret, []byte(key.Secret)) == 1 {
+			return key, true
+		}
+	}
+
+	return Key{}, false
+}
+
+type reader func(c *tesla.Conn, id int) (interface{}, error)
+
+// dataRequests are the endpoints under /api/1/vehicles/{id}/data_request.
+var dataRequests = map[string]reader{
+	"charge_state":   func(c *tesla.Conn, id int) (interface{}, error) { return c.GetChargeState(id) },
+	"climate_state":  func(c *tesla.Conn, id int) (interface{}, error) { return c.GetClimateState(id) },
+	"drive_state":    func(c *tesla.Conn, id int) (interface{}, error) { return c.GetDriveState(id) },
+	"gui_settings":   func(c *tesla.Conn, id int) (interface{}, error) { return c.GetGUISettings(id) },
+	"vehicle_config": func(c *tesla.Conn, id int) (interface{}, error) { return c.GetVehicleConfig(id) },
+	"vehicle_state":  func(c *tesla.Conn, id int) (interface{}, error) { return c.GetVehicleState(id) },
+}
+
+// vehicleRequests are the other endpoints under /api/1/vehicles/{id} that read data.
+var vehicleRequests = map[string]reader{
+	"mobile_enabled":        func(c *tesla.Conn, id int) (interface{}, error) { return c.GetMobileEnabled(id) },
+	"nearby_charging_sites": func(c *tesla.Conn, id int) (interface{}, error) { return c.GetNearbyChargingSites(id) },
+}
+
+func (g *Gateway) read(readers map[string]reader, id int, name string) (interface{}, error) {
+	reader, ok := readers[name]
+	if !ok {
+		return nil, errorf(http.StatusNotFound, "not found")
+	}
+
+	var resp interface{}
+
+	err := g.call(func() (err error) {
+		resp, err = reader(g.conn, id)
+		return err
+	})
+
+	return resp, err
+}
+
+func (g *Gateway) command(r *http.Request, key Key, id int, name string) (interface{}, error) {
+	if !commands.Known(name) {
+		return nil, errorf(http.StatusNotFound, "unknown command %q", name)
+	}
+
+	if !key.Allows(name) {
+		return nil, errorf(http.StatusForbidden, "api key may not issue command %q", name)
+	}
+
+	var params json.RawMessage
+
+	if r.Body != nil {
+		err := json.NewDecoder(r.Body).Decode(&params)
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, errorf(http.StatusRequestEntityTooLarge, "request body is larger than %d bytes", tooLarge.Limit)
+		}
+
+		if err != nil && err != io.EOF {
+			return nil, errorf(http.StatusBadRequest, "invalid request body: %s", err)
+		}
+	}
+
+	type result struct {
+		Result bool   `json:"result"`
+		Reason string `json:"reason"`
+	}
+
+	err := g.call(func() error { return commands.Run(g.conn, id, name, params) })
+
+	// A command the vehicle refused is a successful response, as from the owner's API.
+	if errors.Is(err, tesla.ErrCommandError) {
+		reason := strings.TrimSuffix(err.Error(), ": "+tesla.ErrCommandError.Error())
+		return result{Result: false, Reason: reason}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result{Result: true}, nil
+}
+
+// call calls fn. If the access token has expired, it is refreshed and fn is called again.
+func (g *Gateway) call(fn func() error) error {
+	g.mu.RLock()
+	token := g.conn.AccessToken()
+	err := fn()
+	g.mu.RUnlock()
+
+	var statusErr tesla.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode() != http.StatusUnauthorized {
+		return err
+	}
+
+	g.mu.Lock()
+
+	// Another request may have refreshed the token while this one waited for the lock.
+	if g.conn.AccessToken() == token {
+		if err := g.conn.UpdateRefreshToken(); err != nil {
+			g.mu.Unlock()
+			return fmt.Errorf("error refreshing access token: %w", err)
+		}
+
+		if g.cfg.TokensRefreshed != nil {
+			g.cfg.TokensRefreshed(g.conn)
+		}
+	}
+
+	g.mu.Unlock()
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return fn()
+}
+
+// upstreamError converts an error from the Conn into the response for the client.
+func upstreamError(err error) *errorResponse {
+	var statusErr tesla.HTTPStatusError
+
+	switch {
+	case errors.Is(err, tesla.ErrInvalidParameter), errors.Is(err, tesla.ErrUnsupported), errors.Is(err, commands.ErrInvalidParams):
+		return errorf(http.StatusBadRequest, "%s", err)
+	case errors.As(err, &statusErr):
+		status := statusErr.StatusCode()
+
+		// The owner's credentials are the gateway's problem, not the client's.
+		if status == http.StatusUnauthorized || status == http.StatusForbidden {
+			status = http.StatusBadGateway
+		}
+
+		return errorf(status, "%s", err)
+	}
+
+	return errorf(http.StatusBadGateway, "%s", err)
+}
+
+// redact removes the streaming and backseat tokens from a vehicle, so they are not shared with
+// clients.
+func redact(v tesla.Vehicle) tesla.Vehicle {
+	v.Tokens = nil
+	v.BackseatToken = nil
+
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
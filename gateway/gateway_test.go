@@ -0,0 +1,278 @@
+package gateway_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/gateway"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+type fixture struct {
+	srv   *teslatest.Server
+	owner *tesla.Conn
+	gw    *httptest.Server
+
+	mu    sync.Mutex
+	audit []gateway.AuditEntry
+}
+
+func newFixture(t *testing.T) *fixture {
+	srv, owner := teslatest.NewTestServer(t)
+
+	f := &fixture{srv: srv, owner: owner}
+
+	gw := gateway.New(owner, gateway.Config{
+		Keys: []gateway.Key{
+			{Name: "reader", Secret: "reader-key"},
+			{Name: "locker", Secret: "locker-key", Commands: []string{"door_lock", "door_unlock"}},
+			{Name: "admin", Secret: "admin-key", Commands: []string{"*"}},
+		},
+		Audit: func(entry gateway.AuditEntry) {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			f.audit = append(f.audit, entry)
+		},
+	})
+
+	f.gw = httptest.NewServer(gw)
+	t.Cleanup(f.gw.Close)
+
+	return f
+}
+
+// client returns a connection to the gateway using the given API key.
+func (f *fixture) client(key string) *tesla.Conn {
+	conn := tesla.NewConn(http.DefaultTransport, f.gw.URL, "", "")
+	conn.SetAccessToken(key)
+
+	return conn
+}
+
+func (f *fixture) ownerRequests(path string) int {
+	n := 0
+
+	for _, req := range f.srv.Requests() {
+		if req.Path == path {
+			n++
+		}
+	}
+
+	return n
+}
+
+func statusCode(err error) int {
+	var statusErr tesla.HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode()
+	}
+
+	return 0
+}
+
+func TestRead(t *testing.T) {
+	f := newFixture(t)
+
+	conn := f.client("reader-key")
+
+	vehicles, err := conn.GetVehicles()
+	require.NoError(t, err)
+	require.Len(t, vehicles, 1)
+	assert.Equal(t, "5YJSA1E2XLF000001", vehicles[0].VIN)
+	assert.Nil(t, vehicles[0].Tokens, "streaming tokens are not shared")
+
+	v, err := conn.GetVehicle(1)
+	require.NoError(t, err)
+	assert.Equal(t, "online", v.State)
+
+	charge, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+	assert.Equal(t, 60, charge.BatteryLevel)
+
+	_, err = conn.GetClimateState(1)
+	require.NoError(t, err)
+
+	_, err = conn.GetMobileEnabled(1)
+	require.NoError(t, err)
+
+	_, err = conn.GetChargeState(2)
+	assert.Equal(t, http.StatusNotFound, statusCode(err))
+}
+
+func TestAuthentication(t *testing.T) {
+	f := newFixture(t)
+
+	_, err := f.client("wrong-key").GetVehicles()
+	assert.Equal(t, http.StatusUnauthorized, statusCode(err))
+
+	_, err = f.client(teslatest.AccessToken).GetVehicles()
+	assert.Equal(t, http.StatusUnauthorized, statusCode(err), "the owner's token is not a gateway key")
+
+	assert.Equal(t, 0, f.ownerRequests("/api/1/vehicles"))
+}
+
+func TestCommandScopes(t *testing.T) {
+	f := newFixture(t)
+
+	err := f.client("reader-key").LockDoors(1)
+	assert.Equal(t, http.StatusForbidden, statusCode(err))
+
+	_, err = f.client("locker-key").WakeUp(1)
+	assert.Equal(t, http.StatusForbidden, statusCode(err))
+
+	err = f.client("locker-key").HonkHorn(1)
+	assert.Equal(t, http.StatusForbidden, statusCode(err))
+
+	require.NoError(t, f.client("locker-key").UnlockDoors(1))
+	require.NoError(t, f.client("admin-key").SetSentryMode(1, true))
+
+	_, err = f.client("admin-key").WakeUp(1)
+	require.NoError(t, err)
+
+	var names []string
+	for _, cmd := range f.srv.Vehicle(1).Commands() {
+		names = append(names, cmd.Name)
+	}
+
+	assert.Equal(t, []string{"door_unlock", "set_sentry_mode"}, names)
+	assert.True(t, f.srv.Vehicle(1).VehicleState.SentryMode)
+}
+
+func TestCommandErrors(t *testing.T) {
+	f := newFixture(t)
+
+	conn := f.client("admin-key")
+
+	err := conn.StartCharging(1)
+	assert.True(t, errors.Is(err, tesla.ErrCommandError), "%v", err)
+	assert.Contains(t, err.Error(), "disconnected")
+
+	post := func(path, body string) int {
+		req, err := http.NewRequest(http.MethodPost, f.gw.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer admin-key")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	// The gateway's Conn validates parameters against the state it has seen.
+	_, err = f.owner.GetChargeState(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusBadRequest, post("/api/1/vehicles/1/command/set_charge_limit", `{"percent": 200}`))
+	assert.Equal(t, http.StatusBadRequest, post("/api/1/vehicles/1/command/set_charge_limit", `{"percent": "high"}`))
+	assert.Equal(t, http.StatusNotFound, post("/api/1/vehicles/1/command/self_destruct", ``))
+	assert.Equal(t, http.StatusNotFound, post("/api/1/vehicles/1/command/remote_start_drive", `{"password": "secret"}`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge,
+		post("/api/1/vehicles/1/command/set_charge_limit", `{"percent": 85, "note": "`+strings.Repeat("x", 1<<20)+`"}`))
+	assert.Equal(t, http.StatusOK, post("/api/1/vehicles/1/command/set_charge_limit", `{"percent": 85}`))
+	assert.Equal(t, 85, f.srv.Vehicle(1).ChargeState.ChargeLimitSoc)
+
+	f.srv.Vehicle(1).Sleep()
+
+	err = conn.HonkHorn(1)
+	assert.Equal(t, http.StatusRequestTimeout, statusCode(err))
+}
+
+func TestCache(t *testing.T) {
+	f := newFixture(t)
+
+	for _, key := range []string{"reader-key", "locker-key", "admin-key"} {
+		_, err := f.client(key).GetVehicles()
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, f.ownerRequests("/api/1/vehicles"))
+
+	for _, key := range []string{"reader-key", "admin-key"} {
+		_, err := f.client(key).GetChargeState(1)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, f.ownerRequests("/api/1/vehicles/1/data_request/charge_state"))
+
+	require.NoError(t, f.client("admin-key").SetChargeLimit(1, 70))
+
+	_, err := f.client("reader-key").GetChargeState(1)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, f.ownerRequests("/api/1/vehicles/1/data_request/charge_state"), "commands discard the state they change")
+}
+
+func TestAudit(t *testing.T) {
+	f := newFixture(t)
+
+	_, _ = f.client("reader-key").GetChargeState(1)
+	_ = f.client("reader-key").LockDoors(1)
+	_ = f.client("locker-key").LockDoors(1)
+	_, _ = f.client("wrong-key").GetVehicles()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	require.Len(t, f.audit, 4)
+
+	assert.Equal(t, "reader", f.audit[0].Key)
+	assert.Equal(t, http.MethodGet, f.audit[0].Method)
+	assert.Equal(t, "/api/1/vehicles/1/data_request/charge_state", f.audit[0].Path)
+	assert.Equal(t, 1, f.audit[0].VehicleID)
+	assert.Equal(t, http.StatusOK, f.audit[0].Status)
+
+	assert.Equal(t, "reader", f.audit[1].Key)
+	assert.Equal(t, "door_lock", f.audit[1].Command)
+	assert.Equal(t, http.StatusForbidden, f.audit[1].Status)
+	assert.NotEmpty(t, f.audit[1].Error)
+
+	assert.Equal(t, "locker", f.audit[2].Key)
+	assert.Equal(t, "door_lock", f.audit[2].Command)
+	assert.Equal(t, http.StatusOK, f.audit[2].Status)
+
+	assert.Equal(t, "", f.audit[3].Key)
+	assert.Equal(t, http.StatusUnauthorized, f.audit[3].Status)
+}
+
+func TestRefreshesOwnerToken(t *testing.T) {
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	srv.AddVehicle(1, "5YJSA1E2XLF000001")
+
+	owner := srv.Conn()
+	owner.SetAccessToken("expired")
+	owner.SetRefreshToken(teslatest.RefreshToken)
+
+	refreshed := 0
+
+	gw := httptest.NewServer(gateway.New(owner, gateway.Config{
+		Keys:            []gateway.Key{{Name: "reader", Secret: "reader-key"}},
+		TokensRefreshed: func(conn *tesla.Conn) { refreshed++ },
+	}))
+	defer gw.Close()
+
+	client := tesla.NewConn(http.DefaultTransport, gw.URL, "", "")
+	client.SetAccessToken("reader-key")
+
+	_, err := client.GetVehicles()
+	require.NoError(t, err)
+	assert.Equal(t, 1, refreshed)
+	assert.Equal(t, teslatest.AccessToken, owner.AccessToken())
+
+	owner.SetRefreshToken("revoked")
+	owner.SetAccessToken("expired")
+
+	_, err = client.GetVehicle(1)
+	assert.Equal(t, http.StatusBadGateway, statusCode(err), "the owner's credentials are not the client's problem")
+}
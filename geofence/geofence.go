@@ -0,0 +1,293 @@
+// Package geofence reports when vehicles enter, leave, and stay in named zones, such as home or
+// the office.
+//
+//	fences := geofence.New(geofence.Config{Dwell: 5 * time.Minute})
+//	err := fences.AddZone(geofence.Circle("home", geofence.Point{Latitude: 37.49, Longitude: -121.94}, 100))
+//	...
+//	for msg := range stream.Data() {
+//		for _, event := range fences.UpdateStream(id, msg) {
+//			...
+//		}
+//	}
+//
+// GPS positions jitter by several meters, so a vehicle parked at the edge of a zone could appear
+// to leave and enter it over and over. To avoid that, a vehicle enters a zone when it is inside
+// it, but only leaves once it is more than the hysteresis distance outside.
+package geofence
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// DefaultHysteresis is the default distance, in meters, a vehicle must be outside a zone to leave it.
+const DefaultHysteresis = 50
+
+// EventType is the type of geofence event.
+type EventType int
+
+// The types of geofence event.
+const (
+	// Enter is when a vehicle enters a zone.
+	Enter EventType = iota
+	// Exit is when a vehicle leaves a zone.
+	Exit
+	// Dwell is when a vehicle has been in a zone for the dwell time. It is reported once per visit.
+	Dwell
+)
+
+func (t EventType) String() string {
+	switch t {
+	case Enter:
+		return "enter"
+	case Exit:
+		return "exit"
+	case Dwell:
+		return "dwell"
+	}
+
+	return "unknown"
+}
+
+// Event is a vehicle entering, leaving, or staying in a zone.
+type Event struct {
+	Type      EventType
+	VehicleID int
+	Zone      string
+	// Time and Position are of the update that caused the event. For a Dwell event from Tick, Time
+	// is the time given to Tick and Position is the vehicle's last position.
+	Time     time.Time
+	Position Point
+}
+
+// Config configures an Engine.
+type Config struct {
+	// Hysteresis is how far, in meters, a vehicle must be outside a zone to leave it. Zero uses
+	// DefaultHysteresis.
+	Hysteresis float64
+	// Dwell is how long a vehicle must stay in a zone before a Dwell event. Zero reports no Dwell
+	// events. Dwell events are found by Update, so a parked vehicle that stops reporting positions
+	// needs Tick to be called periodically for them to be reported.
+	Dwell time.Duration
+}
+
+// Engine tracks which zones each vehicle is in. It is safe for concurrent use.
+type Engine struct {
+	cfg Config
+
+	mu       sync.Mutex
+	zones    map[string]Zone
+	vehicles map[int]*vehicle
+}
+
+// vehicle is what an Engine knows about a vehicle.
+type vehicle struct {
+	updated  time.Time
+	position Point
+	// visits are the zones the vehicle is in, by name.
+	visits map[string]*visit
+}
+
+type visit struct {
+	since   time.Time
+	dwelled bool
+}
+
+// New returns an engine with no zones.
+func New(cfg Config) *Engine {
+	if cfg.Hysteresis == 0 {
+		cfg.Hysteresis = DefaultHysteresis
+	}
+
+	return &Engine{
+		cfg:      cfg,
+		zones:    make(map[string]Zone),
+		vehicles: make(map[int]*vehicle),
+	}
+}
+
+// AddZone adds a zone, replacing any zone of the same name. Vehicles in a replaced zone stay in it
+// until their next update.
+func (e *Engine) AddZone(z Zone) error {
+	if err := z.validate(); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.zones[z.Name] = z
+
+	return nil
+}
+
+// RemoveZone removes a zone. No Exit events are reported for vehicles in it.
+func (e *Engine) RemoveZone(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.zones, name)
+
+	for _, v := range e.vehicles {
+		delete(v.visits, name)
+	}
+}
+
+// Zones returns the names of the zones, sorted.
+func (e *Engine) Zones() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	names := make([]string, 0, len(e.zones))
+	for name := range e.zones {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Inside returns the names of the zones the vehicle is in, sorted.
+func (e *Engine) Inside(vehicleID int) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var names []string
+
+	if v, ok := e.vehicles[vehicleID]; ok {
+		for name := range v.visits {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Update records the position of a vehicle at t and returns the resulting events, ordered by zone
+// name. Positions older than the vehicle's last update are ignored.
+//
+// The first position of a vehicle sets the zones it is in without Enter events, so that starting
+// up with a vehicle parked at home does not look like it just arrived.
+func (e *Engine) Update(vehicleID int, t time.Time, p Point) []Event {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	v, known := e.vehicles[vehicleID]
+	if !known {
+		v = &vehicle{visits: make(map[string]*visit)}
+		e.vehicles[vehicleID] = v
+	} else if t.Before(v.updated) {
+		return nil
+	}
+
+	v.updated = t
+	v.position = p
+
+	names := make([]string, 0, len(e.zones))
+	for name := range e.zones {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	var events []Event
+
+	event := func(typ EventType, zone string) {
+		events = append(events, Event{Type: typ, VehicleID: vehicleID, Zone: zone, Time: t, Position: p})
+	}
+
+	for _, name := range names {
+		outside := e.zones[name].outside(p)
+		in, wasInside := v.visits[name]
+
+		switch {
+		case !wasInside && outside == 0:
+			v.visits[name] = &visit{since: t}
+
+			if known {
+				event(Enter, name)
+			}
+		case wasInside && outside > e.cfg.Hysteresis:
+			delete(v.visits, name)
+			event(Exit, name)
+		case wasInside && !in.dwelled && e.cfg.Dwell > 0 && t.Sub(in.since) >= e.cfg.Dwell:
+			in.dwelled = true
+			event(Dwell, name)
+		}
+	}
+
+	return events
+}
+
+// UpdateStream records the position in a streaming message. Messages without a GPS fix are
+// ignored.
+func (e *Engine) UpdateStream(vehicleID int, msg tesla.StreamingMessage) []Event {
+	if msg.EstLatitude == 0 && msg.EstLongitude == 0 {
+		return nil
+	}
+
+	return e.Update(vehicleID, msg.Timestamp, Point{Latitude: msg.EstLatitude, Longitude: msg.EstLongitude})
+}
+
+// UpdateDriveState records the position in a drive state, as of its GPS fix. States without a GPS
+// fix are ignored.
+func (e *Engine) UpdateDriveState(vehicleID int, s *tesla.DriveState) []Event {
+	if s.Latitude == 0 && s.Longitude == 0 {
+		return nil
+	}
+
+	t := s.GpsTime()
+	if t.IsZero() {
+		t = s.Time()
+	}
+
+	return e.Update(vehicleID, t, Point{Latitude: s.Latitude, Longitude: s.Longitude})
+}
+
+// Tick returns the Dwell events that are due by now, ordered by vehicle ID then zone name. Call it
+// periodically so that Dwell events are reported for vehicles that have stopped sending positions.
+func (e *Engine) Tick(now time.Time) []Event {
+	if e.cfg.Dwell == 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	ids := make([]int, 0, len(e.vehicles))
+	for id := range e.vehicles {
+		ids = append(ids, id)
+	}
+
+	sort.Ints(ids)
+
+	var events []Event
+
+	for _, id := range ids {
+		v := e.vehicles[id]
+
+		names := make([]string, 0, len(v.visits))
+		for name := range v.visits {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, name := range names {
+			in := v.visits[name]
+
+			if !in.dwelled && now.Sub(in.since) >= e.cfg.Dwell {
+				in.dwelled = true
+				events = append(events, Event{Type: Dwell, VehicleID: id, Zone: name, Time: now, Position: v.position})
+			}
+		}
+	}
+
+	return events
+}
@@ -0,0 +1,149 @@
+package geofence_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/geofence"
+)
+
+var (
+	t0   = time.Unix(1580000000, 0)
+	home = geofence.Point{Latitude: 37.4900, Longitude: -121.9400}
+)
+
+// north returns the point m meters north of p.
+func north(p geofence.Point, m float64) geofence.Point {
+	return geofence.Point{Latitude: p.Latitude + m/111195, Longitude: p.Longitude}
+}
+
+func types(events []geofence.Event) []string {
+	var names []string
+
+	for _, e := range events {
+		names = append(names, e.Type.String()+" "+e.Zone)
+	}
+
+	return names
+}
+
+func TestDistance(t *testing.T) {
+	assert.InDelta(t, 1000, geofence.Distance(home, north(home, 1000)), 1)
+	assert.InDelta(t, 0, geofence.Distance(home, home), 0.001)
+}
+
+func TestCircle(t *testing.T) {
+	e := geofence.New(geofence.Config{})
+	require.NoError(t, e.AddZone(geofence.Circle("home", home, 100)))
+
+	assert.Empty(t, e.Update(1, t0, north(home, 1000)), "the first position reports no events")
+
+	events := e.Update(1, t0.Add(time.Minute), north(home, 90))
+	require.Len(t, events, 1)
+	assert.Equal(t, geofence.Event{
+		Type:      geofence.Enter,
+		VehicleID: 1,
+		Zone:      "home",
+		Time:      t0.Add(time.Minute),
+		Position:  north(home, 90),
+	}, events[0])
+	assert.Equal(t, []string{"home"}, e.Inside(1))
+
+	assert.Empty(t, e.Update(1, t0.Add(2*time.Minute), north(home, 140)), "jitter within the hysteresis does not leave")
+	assert.Empty(t, e.Update(1, t0.Add(3*time.Minute), north(home, 95)))
+
+	assert.Equal(t, []string{"exit home"}, types(e.Update(1, t0.Add(4*time.Minute), north(home, 160))))
+	assert.Empty(t, e.Inside(1))
+
+	assert.Empty(t, e.Update(1, t0.Add(time.Minute), home), "positions older than the last are ignored")
+	assert.Empty(t, e.Update(2, t0, home), "vehicles are tracked separately")
+	assert.Equal(t, []string{"home"}, e.Inside(2))
+}
+
+func TestPolygon(t *testing.T) {
+	e := geofence.New(geofence.Config{Hysteresis: 20})
+	require.NoError(t, e.AddZone(geofence.Polygon("office",
+		geofence.Point{Latitude: 37.0, Longitude: -122.0},
+		geofence.Point{Latitude: 37.0, Longitude: -121.99},
+		geofence.Point{Latitude: 37.01, Longitude: -121.99},
+		geofence.Point{Latitude: 37.01, Longitude: -122.0},
+	)))
+
+	edge := geofence.Point{Latitude: 37.01, Longitude: -121.995}
+
+	e.Update(1, t0, north(edge, 100))
+	assert.Equal(t, []string{"enter office"}, types(e.Update(1, t0.Add(time.Second), north(edge, -10))))
+	assert.Empty(t, e.Update(1, t0.Add(2*time.Second), north(edge, 15)))
+	assert.Equal(t, []string{"exit office"}, types(e.Update(1, t0.Add(3*time.Second), north(edge, 25))))
+}
+
+func TestDwell(t *testing.T) {
+	e := geofence.New(geofence.Config{Dwell: 5 * time.Minute})
+	require.NoError(t, e.AddZone(geofence.Circle("home", home, 100)))
+	require.NoError(t, e.AddZone(geofence.Circle("street", north(home, 120), 100)))
+
+	e.Update(1, t0, north(home, 1000))
+	assert.Equal(t, []string{"enter home", "enter street"}, types(e.Update(1, t0.Add(time.Minute), north(home, 75))))
+
+	assert.Empty(t, e.Update(1, t0.Add(5*time.Minute), home))
+	assert.Equal(t, []string{"dwell home", "dwell street"}, types(e.Update(1, t0.Add(6*time.Minute), home)),
+		"home is outside street, but within the hysteresis")
+	assert.Empty(t, e.Update(1, t0.Add(20*time.Minute), home), "dwell is reported once per visit")
+
+	e.RemoveZone("street")
+	assert.Equal(t, []string{"home"}, e.Inside(1))
+	assert.Equal(t, []string{"home"}, e.Zones())
+}
+
+func TestTick(t *testing.T) {
+	e := geofence.New(geofence.Config{Dwell: 5 * time.Minute})
+	require.NoError(t, e.AddZone(geofence.Circle("home", home, 100)))
+	require.NoError(t, e.AddZone(geofence.Circle("work", north(home, 5000), 100)))
+
+	e.Update(1, t0, north(home, 1000))
+	e.Update(2, t0, north(home, 1000))
+	e.Update(1, t0.Add(time.Minute), home)
+	e.Update(2, t0.Add(2*time.Minute), north(home, 5000))
+
+	assert.Empty(t, e.Tick(t0.Add(5*time.Minute)))
+
+	events := e.Tick(t0.Add(6 * time.Minute))
+	require.Len(t, events, 1)
+	assert.Equal(t, geofence.Event{Type: geofence.Dwell, VehicleID: 1, Zone: "home", Time: t0.Add(6 * time.Minute), Position: home}, events[0])
+
+	events = e.Tick(t0.Add(10 * time.Minute))
+	require.Len(t, events, 1)
+	assert.Equal(t, 2, events[0].VehicleID)
+	assert.Equal(t, "work", events[0].Zone)
+
+	assert.Empty(t, e.Tick(t0.Add(time.Hour)), "dwell is reported once per visit")
+	assert.Empty(t, e.Update(1, t0.Add(time.Hour), home), "dwell is reported once per visit")
+
+	assert.Empty(t, geofence.New(geofence.Config{}).Tick(t0), "no dwell time")
+}
+
+func TestUpdateFromVehicle(t *testing.T) {
+	e := geofence.New(geofence.Config{})
+	require.NoError(t, e.AddZone(geofence.Circle("home", home, 100)))
+
+	assert.Empty(t, e.UpdateDriveState(1, &tesla.DriveState{GpsAsOf: 1580000000, Latitude: 37.5, Longitude: -121.94}))
+	assert.Empty(t, e.UpdateStream(1, tesla.StreamingMessage{Timestamp: t0.Add(time.Second)}), "no GPS fix")
+	assert.Equal(t, []string{"enter home"}, types(e.UpdateStream(1, tesla.StreamingMessage{
+		Timestamp:    t0.Add(2 * time.Second),
+		EstLatitude:  home.Latitude,
+		EstLongitude: home.Longitude,
+	})))
+}
+
+func TestAddZoneValidates(t *testing.T) {
+	e := geofence.New(geofence.Config{})
+
+	assert.EqualError(t, e.AddZone(geofence.Circle("", home, 100)), "zone has no name")
+	assert.EqualError(t, e.AddZone(geofence.Circle("home", home, 0)), "zone home has no radius")
+	assert.EqualError(t, e.AddZone(geofence.Polygon("lot", home, north(home, 10))), "zone lot has 2 vertices; a polygon needs at least 3")
+	assert.Empty(t, e.Zones())
+}
@@ -0,0 +1,131 @@
+package geofence
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// earthRadius is the mean radius of the Earth in meters.
+const earthRadius = 6371008.8
+
+// Point is a position in degrees.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Distance returns the great-circle distance between two points in meters.
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude), radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// Zone is a named area: a circle, or a polygon if it has vertices.
+type Zone struct {
+	Name string
+
+	// Center and Radius, in meters, define a circular zone.
+	Center Point
+	Radius float64
+
+	// Polygon defines a polygonal zone, by its vertices in order. The last vertex connects to the
+	// first.
+	Polygon []Point
+}
+
+// Circle returns a circular zone.
+func Circle(name string, center Point, radius float64) Zone {
+	return Zone{Name: name, Center: center, Radius: radius}
+}
+
+// Polygon returns a polygonal zone. Zones should be small enough, a few kilometers across, that
+// their edges can be treated as straight lines.
+func Polygon(name string, vertices ...Point) Zone {
+	return Zone{Name: name, Polygon: vertices}
+}
+
+func (z Zone) validate() error {
+	if z.Name == "" {
+		return errors.New("zone has no name")
+	}
+
+	if z.Polygon != nil {
+		if len(z.Polygon) < 3 {
+			return fmt.Errorf("zone %s has %d vertices; a polygon needs at least 3", z.Name, len(z.Polygon))
+		}
+
+		return nil
+	}
+
+	if z.Radius <= 0 {
+		return fmt.Errorf("zone %s has no radius", z.Name)
+	}
+
+	return nil
+}
+
+// outside returns how far p is outside the zone in meters, or 0 if it is inside.
+func (z Zone) outside(p Point) float64 {
+	if z.Polygon == nil {
+		return math.Max(0, Distance(z.Center, p)-z.Radius)
+	}
+
+	if z.contains(p) {
+		return 0
+	}
+
+	// Project the polygon onto a plane around p, which is accurate enough at the scale of a zone.
+	scale := math.Cos(radians(p.Latitude))
+	project := func(v Point) (float64, float64) {
+		return radians(v.Longitude-p.Longitude) * scale * earthRadius, radians(v.Latitude-p.Latitude) * earthRadius
+	}
+
+	nearest := math.Inf(1)
+
+	for i := range z.Polygon {
+		ax, ay := project(z.Polygon[i])
+		bx, by := project(z.Polygon[(i+1)%len(z.Polygon)])
+
+		nearest = math.Min(nearest, distanceToSegment(ax, ay, bx, by))
+	}
+
+	return nearest
+}
+
+// contains reports whether p is inside the polygon, by counting the edges a ray from p crosses.
+func (z Zone) contains(p Point) bool {
+	inside := false
+
+	for i, j := 0, len(z.Polygon)-1; i < len(z.Polygon); j, i = i, i+1 {
+		a, b := z.Polygon[i], z.Polygon[j]
+
+		if (a.Latitude > p.Latitude) != (b.Latitude > p.Latitude) &&
+			p.Longitude < (b.Longitude-a.Longitude)*(p.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+
+	return inside
+}
+
+// distanceToSegment returns the distance from the origin to the segment from a to b.
+func distanceToSegment(ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+
+	t := 0.0
+	if length := dx*dx + dy*dy; length > 0 {
+		t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+	}
+
+	return math.Hypot(ax+t*dx, ay+t*dy)
+}
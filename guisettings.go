@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // GUISettings represents the configured settings in the vehicle GUI.
@@ -14,12 +16,47 @@ type GUISettings struct {
 	GUITemperatureUnits string `json:"gui_temperature_units"`
 	ShowRangeUnits      bool   `json:"show_range_units"`
 	Timestamp           int64  `json:"timestamp"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the settings, keeping any unrecognized fields in Extra.
+func (s *GUISettings) UnmarshalJSON(data []byte) error {
+	type plain GUISettings
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the settings, including any fields in Extra.
+func (s GUISettings) MarshalJSON() ([]byte, error) {
+	type plain GUISettings
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// Time returns when the settings were recorded by the vehicle.
+func (s *GUISettings) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
 }
 
 // GetGUISettings retrieves the current GUI settings for the vehicle.
 func (c *Conn) GetGUISettings(id int) (*GUISettings, error) {
+	val, _, err := c.GetGUISettingsRaw(id)
+	return val, err
+}
+
+// GetGUISettingsRaw is like GetGUISettings, but also returns the raw HTTP response.
+func (c *Conn) GetGUISettingsRaw(id int) (*GUISettings, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -28,10 +65,10 @@ func (c *Conn) GetGUISettings(id int) (*GUISettings, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/gui_settings", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/gui_settings", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	return &respBody.Response, raw, nil
 }
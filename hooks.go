@@ -0,0 +1,168 @@
+package tesla
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RequestInfo describes an API request, for use by hooks.
+type RequestInfo struct {
+	// Endpoint is a short, stable name for the endpoint, suitable for use as a metric label, such
+	// as "vehicles", "vehicle", "charge_state", "wake_up", "command/door_lock", or "oauth/token".
+	Endpoint string
+	// Method is the HTTP method.
+	Method string
+	// URL is the path of the request, relative to the base URL.
+	URL string
+	// VehicleID is the id of the vehicle the request is for, or zero if it is not for a vehicle.
+	VehicleID int
+	// Retries is the number of times the request has already been attempted.
+	Retries int
+	// Start is the time the request was sent.
+	Start time.Time
+}
+
+// RequestResult describes the outcome of an API request, for use by hooks.
+type RequestResult struct {
+	// StatusCode is the HTTP status code, or zero if no response was received.
+	StatusCode int
+	// Duration is the time from sending the request to reading the full response.
+	Duration time.Duration
+	// Err is the error returned to the caller, if any.
+	Err error
+}
+
+// Hooks are functions called during the lifecycle of each API call and stream, for collecting
+// metrics or tracing. Any of the functions may be nil. Hooks are called synchronously, so they
+// should return quickly, and may be called concurrently if the Conn is shared.
+type Hooks struct {
+	// BeforeRequest is called before each request is sent. The same info is passed to the other
+	// request hooks, so it may be used as a key to associate them.
+	BeforeRequest func(info *RequestInfo)
+	// AfterRequest is called after each request completes, successfully or not.
+	AfterRequest func(info *RequestInfo, result RequestResult)
+	// CommandResult is called after a command response is received, before AfterRequest, with the
+	// result and reason reported by the vehicle.
+	CommandResult func(info *RequestInfo, result bool, reason string)
+
+	// StreamConnect is called after each attempt to connect or reconnect a stream.
+	StreamConnect func(vehicleID int, err error)
+	// StreamDisconnect is called when a stream connection ends. err is nil if the stream was
+	// closed by the caller.
+	StreamDisconnect func(vehicleID int, err error)
+	// StreamMessage is called for each message received on a stream.
+	StreamMessage func(vehicleID int, msg StreamingMessage)
+}
+
+// AddHooks adds hooks to the connection. Hooks are called in the order they were added. Add hooks
+// before making any requests.
+func (c *Conn) AddHooks(hooks Hooks) {
+	c.hooks = append(c.hooks, hooks)
+}
+
+func newRequestInfo(method, path string) *RequestInfo {
+	endpoint, id := endpointName(path)
+
+	return &RequestInfo{
+		Endpoint:  endpoint,
+		Method:    method,
+		URL:       path,
+		VehicleID: id,
+	}
+}
+
+// endpointName returns the endpoint name and vehicle id for a request path.
+func endpointName(path string) (string, int) {
+	if u, err := url.Parse(path); err == nil {
+		path = u.Path
+	}
+
+	path = strings.Trim(path, "/")
+
+	const vehicles = "api/1/vehicles"
+
+	if !strings.HasPrefix(path, vehicles) {
+		return strings.TrimPrefix(path, "api/1/"), 0
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(path[len(vehicles):], "/"), "/", 2)
+
+	if parts[0] == "" {
+		return "vehicles", 0
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return path, 0
+	}
+
+	if len(parts) == 1 {
+		return "vehicle", id
+	}
+
+	return strings.TrimPrefix(parts[1], "data_request/"), id
+}
+
+func (c *Conn) beforeRequest(info *RequestInfo) {
+	for _, h := range c.hooks {
+		if h.BeforeRequest != nil {
+			h.BeforeRequest(info)
+		}
+	}
+}
+
+func (c *Conn) afterRequest(info *RequestInfo, raw *RawResponse, err error) {
+	if len(c.hooks) == 0 {
+		return
+	}
+
+	result := RequestResult{
+		Duration: time.Since(info.Start),
+		Err:      err,
+	}
+
+	if raw != nil {
+		result.StatusCode = raw.StatusCode
+		result.Duration = raw.Duration
+	}
+
+	for _, h := range c.hooks {
+		if h.AfterRequest != nil {
+			h.AfterRequest(info, result)
+		}
+	}
+}
+
+func (c *Conn) commandResult(info *RequestInfo, result bool, reason string) {
+	for _, h := range c.hooks {
+		if h.CommandResult != nil {
+			h.CommandResult(info, result, reason)
+		}
+	}
+}
+
+func (c *Conn) streamConnect(id int, err error) {
+	for _, h := range c.hooks {
+		if h.StreamConnect != nil {
+			h.StreamConnect(id, err)
+		}
+	}
+}
+
+func (c *Conn) streamDisconnect(id int, err error) {
+	for _, h := range c.hooks {
+		if h.StreamDisconnect != nil {
+			h.StreamDisconnect(id, err)
+		}
+	}
+}
+
+func (c *Conn) streamMessage(id int, msg StreamingMessage) {
+	for _, h := range c.hooks {
+		if h.StreamMessage != nil {
+			h.StreamMessage(id, msg)
+		}
+	}
+}
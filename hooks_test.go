@@ -0,0 +1,31 @@
+package tesla
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointName(t *testing.T) {
+	tests := []struct {
+		path     string
+		endpoint string
+		id       int
+	}{
+		{path: "/oauth/token", endpoint: "oauth/token"},
+		{path: "/api/1/vehicles", endpoint: "vehicles"},
+		{path: "/api/1/vehicles/12", endpoint: "vehicle", id: 12},
+		{path: "/api/1/vehicles/12/wake_up", endpoint: "wake_up", id: 12},
+		{path: "/api/1/vehicles/12/data_request/charge_state", endpoint: "charge_state", id: 12},
+		{path: "/api/1/vehicles/12/command/door_lock", endpoint: "command/door_lock", id: 12},
+		{path: "/api/1/vehicles/12/nearby_charging_sites?foo=bar", endpoint: "nearby_charging_sites", id: 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			endpoint, id := endpointName(tt.path)
+			assert.Equal(t, tt.endpoint, endpoint)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
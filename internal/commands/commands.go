@@ -0,0 +1,223 @@
+// Package commands issues vehicle commands by their name in the owner's API, with parameters
+// decoded from JSON, for the gateway and the MQTT bridge.
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/rickbassham/tesla"
+	"golang.org/x/text/language"
+)
+
+// ErrUnknown is returned by Run for a command it does not know.
+var ErrUnknown = errors.New("unknown command")
+
+// ErrInvalidParams is returned by Run when the parameters cannot be decoded.
+var ErrInvalidParams = errors.New("invalid parameters")
+
+// command issues a command with the given parameters, which are named as in the owner's API.
+type command func(c *tesla.Conn, id int, params json.RawMessage) error
+
+// commands are the commands that can be issued, by their name in the owner's API.
+// remote_start_drive is left out, as it needs the account password, which callers of the
+// gateway and the MQTT bridge must not hold.
+var commands = map[string]command{
+	"honk_horn":               noParams((*tesla.Conn).HonkHorn),
+	"flash_lights":            noParams((*tesla.Conn).FlashLights),
+	"reset_valet_pin":         noParams((*tesla.Conn).ResetValetPin),
+	"door_unlock":             noParams((*tesla.Conn).UnlockDoors),
+	"door_lock":               noParams((*tesla.Conn).LockDoors),
+	"charge_port_door_open":   noParams((*tesla.Conn).OpenChargePortDoor),
+	"charge_port_door_close":  noParams((*tesla.Conn).CloseChargePortDoor),
+	"charge_start":            noParams((*tesla.Conn).StartCharging),
+	"charge_stop":             noParams((*tesla.Conn).StopCharging),
+	"charge_standard":         noParams((*tesla.Conn).SetChargeLimitStandard),
+	"charge_max_range":        noParams((*tesla.Conn).SetChargeLimitMaxRange),
+	"auto_conditioning_start": noParams((*tesla.Conn).AutoConditioningStart),
+	"auto_conditioning_stop":  noParams((*tesla.Conn).AutoConditioningStop),
+	"media_toggle_playback":   noParams((*tesla.Conn).MediaTogglePlayback),
+	"media_next_track":        noParams((*tesla.Conn).MediaNextTrack),
+	"media_prev_track":        noParams((*tesla.Conn).MediaPreviousTrack),
+	"media_next_fav":          noParams((*tesla.Conn).MediaNextFavorite),
+	"media_prev_fav":          noParams((*tesla.Conn).MediaPreviousFavorite),
+	"media_volume_up":         noParams((*tesla.Conn).MediaVolumeUp),
+	"media_volume_down":       noParams((*tesla.Conn).MediaVolumeDown),
+	"cancel_software_update":  noParams((*tesla.Conn).CancelSoftwareUpdate),
+
+	"trigger_homelink": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Latitude  float64 `json:"lat"`
+			Longitude float64 `json:"lon"`
+		}
+
+		return withParams(params, &p, func() error { return c.TriggerHomelink(id, p.Latitude, p.Longitude) })
+	},
+	"speed_limit_set_limit": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			LimitMPH int `json:"limit_mph"`
+		}
+
+		return withParams(params, &p, func() error { return c.SpeedLimitSetLimit(id, p.LimitMPH) })
+	},
+	"speed_limit_activate":   withPIN((*tesla.Conn).SpeedLimitActivate),
+	"speed_limit_deactivate": withPIN((*tesla.Conn).SpeedLimitDeactivate),
+	"speed_limit_clear_pin":  withPIN((*tesla.Conn).SpeedLimitClearPin),
+	"set_valet_mode": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			On  bool   `json:"on"`
+			Pin string `json:"password"`
+		}
+
+		return withParams(params, &p, func() error { return c.SetValetMode(id, p.On, p.Pin) })
+	},
+	"set_sentry_mode":                      withOn((*tesla.Conn).SetSentryMode),
+	"set_preconditioning_max":              withOn((*tesla.Conn).SetPreconditioningMax),
+	"remote_steering_wheel_heater_request": withOn((*tesla.Conn).SetHeatedSteeringWheel),
+	"actuate_trunk": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Trunk tesla.Trunk `json:"which_trunk"`
+		}
+
+		return withParams(params, &p, func() error { return c.OpenTrunk(id, p.Trunk) })
+	},
+	"window_control": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Command   tesla.WindowCommand `json:"command"`
+			Latitude  float64             `json:"lat"`
+			Longitude float64             `json:"lon"`
+		}
+
+		return withParams(params, &p, func() error { return c.ActuateWindows(id, p.Command, p.Latitude, p.Longitude) })
+	},
+	"sun_roof_control": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			State tesla.SunroofCommand `json:"state"`
+		}
+
+		return withParams(params, &p, func() error { return c.ActuateSunroof(id, p.State) })
+	},
+	"set_charge_limit": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Percent int `json:"percent"`
+		}
+
+		return withParams(params, &p, func() error { return c.SetChargeLimit(id, p.Percent) })
+	},
+	"set_temps": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Driver    float64 `json:"driver_temp"`
+			Passenger float64 `json:"passenger_temp"`
+		}
+
+		return withParams(params, &p, func() error { return c.SetTemperatures(id, p.Driver, p.Passenger) })
+	},
+	"remote_seat_heater_request": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Seat  tesla.Seat          `json:"heater"`
+			Level tesla.SeatHeatLevel `json:"level"`
+		}
+
+		return withParams(params, &p, func() error { return c.SetSeatHeater(id, p.Seat, p.Level) })
+	},
+	"share": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Value struct {
+				Text string `json:"android.intent.extra.TEXT"`
+			} `json:"value"`
+			Locale string `json:"locale"`
+		}
+
+		return withParams(params, &p, func() error {
+			tag, err := language.Parse(p.Locale)
+			if p.Locale == "" {
+				tag, err = language.AmericanEnglish, nil
+			}
+
+			if err != nil {
+				return fmt.Errorf("%w: invalid locale %q", ErrInvalidParams, p.Locale)
+			}
+
+			return c.Share(id, tag, p.Value.Text)
+		})
+	},
+	"schedule_software_update": func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Offset int `json:"offset_sec"`
+		}
+
+		return withParams(params, &p, func() error {
+			return c.ScheduleSoftwareUpdate(id, time.Duration(p.Offset)*time.Second)
+		})
+	},
+}
+
+// Known reports whether name is a command Run can issue.
+func Known(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Names returns the names of the commands Run can issue, sorted.
+func Names() []string {
+	names := make([]string, 0, len(commands))
+
+	for name := range commands {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Run issues the named command to the vehicle. Params is the JSON object of parameters, or empty
+// if the command takes none.
+func Run(c *tesla.Conn, id int, name string, params json.RawMessage) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("%w %q", ErrUnknown, name)
+	}
+
+	return cmd(c, id, params)
+}
+
+func noParams(fn func(c *tesla.Conn, id int) error) command {
+	return func(c *tesla.Conn, id int, params json.RawMessage) error {
+		return fn(c, id)
+	}
+}
+
+func withOn(fn func(c *tesla.Conn, id int, on bool) error) command {
+	return func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			On bool `json:"on"`
+		}
+
+		return withParams(params, &p, func() error { return fn(c, id, p.On) })
+	}
+}
+
+func withPIN(fn func(c *tesla.Conn, id int, pin string) error) command {
+	return func(c *tesla.Conn, id int, params json.RawMessage) error {
+		var p struct {
+			Pin string `json:"pin"`
+		}
+
+		return withParams(params, &p, func() error { return fn(c, id, p.Pin) })
+	}
+}
+
+// withParams decodes params into p, then calls fn.
+func withParams(params json.RawMessage, p interface{}, fn func() error) error {
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, p); err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidParams, err)
+		}
+	}
+
+	return fn()
+}
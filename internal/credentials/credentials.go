@@ -0,0 +1,95 @@
+// Package credentials loads and saves the tokens shared by the command-line tools.
+package credentials
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/rickbassham/tesla"
+)
+
+// Config is the saved credentials and settings.
+type Config struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	BaseURL      string `json:"base_url,omitempty"`
+	StreamingURL string `json:"streaming_url,omitempty"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Conn returns a new connection using the saved credentials.
+func (cfg *Config) Conn() *tesla.Conn {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = tesla.DefaultBaseURL
+	}
+
+	conn := tesla.NewConn(http.DefaultTransport, baseURL, cfg.ClientID, cfg.ClientSecret)
+	conn.SetAccessToken(cfg.AccessToken)
+	conn.SetRefreshToken(cfg.RefreshToken)
+
+	if cfg.StreamingURL != "" {
+		conn.SetStreamingURL(cfg.StreamingURL)
+	}
+
+	return conn
+}
+
+// DefaultPath returns the path of the credentials file in the user's config directory.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("error finding config directory: %w", err)
+	}
+
+	return filepath.Join(dir, "tesla", "credentials.json"), nil
+}
+
+// Load reads the credentials file. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	var cfg Config
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &cfg, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("error parsing config %s: %w", path, err)
+	}
+
+	return &cfg, nil
+}
+
+// Save writes the credentials file, readable only by the user, as it holds tokens.
+func Save(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("error creating config directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("error writing config: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package credentials
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rickbassham/tesla"
+)
+
+var (
+	// ErrVehicleNotFound is returned by Session.Vehicle when no vehicle matches.
+	ErrVehicleNotFound = errors.New("vehicle not found")
+	// ErrNoVehicleChosen is returned by Session.Vehicle when no vehicle is named and the account has
+	// more than one.
+	ErrNoVehicleChosen = errors.New("no vehicle chosen")
+)
+
+// Session is a connection using the credentials saved at Path. Tokens refreshed through the
+// session are saved back to the file. It is not safe for concurrent use.
+type Session struct {
+	Path   string
+	Config *Config
+	Conn   *tesla.Conn
+}
+
+// Open loads the credentials saved at path, or at DefaultPath if path is empty, and returns a
+// session using them. If no access token has been saved, the error wraps
+// tesla.ErrMissingAccessToken.
+func Open(path string) (*Session, error) {
+	if path == "" {
+		var err error
+
+		path, err = DefaultPath()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.AccessToken == "" {
+		return nil, fmt.Errorf("not logged in; run \"tesla login\": %w", tesla.ErrMissingAccessToken)
+	}
+
+	return NewSession(path, cfg), nil
+}
+
+// NewSession returns a session using cfg, which is saved to path whenever the tokens change.
+func NewSession(path string, cfg *Config) *Session {
+	return &Session{Path: path, Config: cfg, Conn: cfg.Conn()}
+}
+
+// SaveTokens saves the connection's current tokens.
+func (s *Session) SaveTokens() error {
+	s.Config.AccessToken = s.Conn.AccessToken()
+	s.Config.RefreshToken = s.Conn.RefreshToken()
+
+	return Save(s.Path, s.Config)
+}
+
+// Call calls fn. If the access token is rejected and a refresh token has been saved, the tokens
+// are refreshed and saved, and fn is called again. If the refresh fails, fn's error is returned.
+func (s *Session) Call(fn func() error) error {
+	err := fn()
+
+	var statusErr tesla.HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode() != http.StatusUnauthorized || s.Config.RefreshToken == "" {
+		return err
+	}
+
+	if refreshErr := s.Conn.UpdateRefreshToken(); refreshErr != nil {
+		return err
+	}
+
+	if err := s.SaveTokens(); err != nil {
+		return err
+	}
+
+	return fn()
+}
+
+// Vehicle returns the vehicle with the given id, VIN, or display name, or the only vehicle on the
+// account if name is empty.
+func (s *Session) Vehicle(name string) (*tesla.Vehicle, error) {
+	var vehicles []tesla.Vehicle
+
+	err := s.Call(func() (err error) {
+		vehicles, err = s.Conn.GetVehicles()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if name == "" {
+		if len(vehicles) == 1 {
+			return &vehicles[0], nil
+		}
+
+		return nil, fmt.Errorf("%w: the account has %d vehicles", ErrNoVehicleChosen, len(vehicles))
+	}
+
+	for i, v := range vehicles {
+		if name == strconv.Itoa(v.ID) || strings.EqualFold(name, v.VIN) || strings.EqualFold(name, v.DisplayName) {
+			return &vehicles[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("vehicle %q: %w", name, ErrVehicleNotFound)
+}
@@ -0,0 +1,90 @@
+package credentials_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func newSession(t *testing.T, accessToken string) (*credentials.Session, *teslatest.Server) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	srv, _ := teslatest.NewTestServer(t)
+
+	path := filepath.Join(dir, "credentials.json")
+	require.NoError(t, credentials.Save(path, &credentials.Config{
+		ClientID:     teslatest.ClientID,
+		ClientSecret: teslatest.ClientSecret,
+		BaseURL:      srv.URL,
+		AccessToken:  accessToken,
+		RefreshToken: teslatest.RefreshToken,
+	}))
+
+	s, err := credentials.Open(path)
+	require.NoError(t, err)
+
+	return s, srv
+}
+
+func TestOpenNotLoggedIn(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	_, err = credentials.Open(filepath.Join(dir, "credentials.json"))
+	assert.True(t, errors.Is(err, tesla.ErrMissingAccessToken))
+}
+
+func TestSessionCallRefreshesTokens(t *testing.T) {
+	s, _ := newSession(t, "expired")
+
+	calls := 0
+	err := s.Call(func() error {
+		calls++
+		_, err := s.Conn.GetVehicles()
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	cfg, err := credentials.Load(s.Path)
+	require.NoError(t, err)
+	assert.Equal(t, teslatest.AccessToken, cfg.AccessToken, "refreshed tokens are saved")
+}
+
+func TestSessionVehicle(t *testing.T) {
+	s, _ := newSession(t, teslatest.AccessToken)
+
+	for _, name := range []string{"", "1", teslatest.VIN, strings.ToLower(teslatest.VIN)} {
+		v, err := s.Vehicle(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, 1, v.ID, name)
+	}
+
+	_, err := s.Vehicle("nope")
+	assert.True(t, errors.Is(err, credentials.ErrVehicleNotFound))
+}
+
+func TestSessionVehicleNotChosen(t *testing.T) {
+	s, srv := newSession(t, teslatest.AccessToken)
+	srv.AddVehicle(2, "5YJSA1E2XLF000002")
+
+	_, err := s.Vehicle("")
+	assert.True(t, errors.Is(err, credentials.ErrNoVehicleChosen))
+
+	v, err := s.Vehicle("2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, v.ID)
+}
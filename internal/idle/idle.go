@@ -0,0 +1,106 @@
+// Package idle decides when to poll the state of a vehicle so that it can fall asleep. Every data
+// request keeps a vehicle awake, so a client polling an online vehicle at a fixed interval would
+// never let it sleep.
+//
+// The state of a vehicle that is online is polled while it is in use, and for IdleAfter once it is
+// not. After that, polling stops for SleepWindow so the vehicle can fall asleep; if it is still
+// awake when the window ends, its state is polled once more and the window starts again.
+package idle
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// Tracker tracks when each vehicle was last in use. It is safe for concurrent use.
+type Tracker struct {
+	idleAfter   time.Duration
+	sleepWindow time.Duration
+
+	mu       sync.Mutex
+	vehicles map[int]*vehicle
+}
+
+type vehicle struct {
+	online bool
+	// activeAt is when the vehicle was last seen in use, or woke up.
+	activeAt time.Time
+}
+
+// NewTracker returns a tracker that stops polling a vehicle once it has been idle for idleAfter,
+// for sleepWindow.
+func NewTracker(idleAfter, sleepWindow time.Duration) *Tracker {
+	return &Tracker{
+		idleAfter:   idleAfter,
+		sleepWindow: sleepWindow,
+		vehicles:    make(map[int]*vehicle),
+	}
+}
+
+// ShouldPoll records the vehicle as listed at now, and reports whether its state should be
+// polled. Listing vehicles never wakes them, so it can be done at any interval.
+func (t *Tracker) ShouldPoll(v tesla.Vehicle, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d := t.vehicle(v.ID)
+
+	online := v.State == "online"
+	if online && !d.online {
+		d.activeAt = now
+	}
+
+	d.online = online
+
+	idle := now.Sub(d.activeAt)
+
+	return online && (idle < t.idleAfter || idle >= t.idleAfter+t.sleepWindow)
+}
+
+// Polled records that the state of the vehicle was polled at now, and whether it showed the
+// vehicle in use.
+func (t *Tracker) Polled(id int, now time.Time, active bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d := t.vehicle(id)
+
+	switch {
+	case active:
+		d.activeAt = now
+	case now.Sub(d.activeAt) >= t.idleAfter:
+		// The vehicle stayed awake through the sleep window without being used. Wait another
+		// window before polling again.
+		d.activeAt = now.Add(-t.idleAfter)
+	}
+}
+
+func (t *Tracker) vehicle(id int) *vehicle {
+	d, ok := t.vehicles[id]
+	if !ok {
+		d = &vehicle{}
+		t.vehicles[id] = d
+	}
+
+	return d
+}
+
+// Active reports whether the states show the vehicle in use: driving, charging, conditioning, or
+// occupied. Any state may be nil.
+func Active(charge *tesla.ChargeState, climate *tesla.ClimateState, state *tesla.VehicleState, drive *tesla.DriveState) bool {
+	if charge != nil && (charge.ChargingState == tesla.ChargingStateCharging || charge.ChargingState == tesla.ChargingStateStarting) {
+		return true
+	}
+
+	if drive != nil && drive.ShiftState != nil && drive.ShiftState != "P" {
+		return true
+	}
+
+	if climate != nil && climate.IsClimateOn {
+		return true
+	}
+
+	return state != nil && state.IsUserPresent
+}
@@ -0,0 +1,52 @@
+package idle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/rickbassham/tesla"
+)
+
+func TestTracker(t *testing.T) {
+	tr := NewTracker(15*time.Minute, 30*time.Minute)
+	now := time.Unix(1580000000, 0)
+
+	online := tesla.Vehicle{ID: 1, State: "online"}
+
+	poll := func(d time.Duration, v tesla.Vehicle, active bool) bool {
+		now = now.Add(d)
+
+		if !tr.ShouldPoll(v, now) {
+			return false
+		}
+
+		tr.Polled(v.ID, now, active)
+
+		return true
+	}
+
+	assert.True(t, poll(0, online, false), "a vehicle that comes online is polled")
+	assert.True(t, poll(14*time.Minute, online, false))
+	assert.False(t, poll(time.Minute, online, false), "an idle vehicle is left alone to sleep")
+	assert.False(t, poll(29*time.Minute, online, false))
+	assert.True(t, poll(time.Minute, online, false), "a vehicle awake after the window is polled again")
+	assert.False(t, poll(time.Minute, online, false), "and left alone for another window")
+
+	assert.False(t, poll(30*time.Minute, tesla.Vehicle{ID: 1, State: "asleep"}, false), "an asleep vehicle is never polled")
+	assert.True(t, poll(time.Minute, online, true), "a vehicle that woke up is polled")
+	assert.True(t, poll(10*time.Minute, online, true), "an active vehicle keeps being polled")
+	assert.True(t, poll(10*time.Minute, online, true))
+	assert.True(t, poll(14*time.Minute, online, false))
+	assert.False(t, poll(time.Minute, online, false))
+}
+
+func TestActive(t *testing.T) {
+	assert.False(t, Active(nil, nil, nil, nil))
+	assert.False(t, Active(&tesla.ChargeState{ChargingState: tesla.ChargingStateComplete}, &tesla.ClimateState{}, &tesla.VehicleState{}, &tesla.DriveState{ShiftState: "P"}))
+	assert.True(t, Active(&tesla.ChargeState{ChargingState: tesla.ChargingStateCharging}, nil, nil, nil))
+	assert.True(t, Active(nil, &tesla.ClimateState{IsClimateOn: true}, nil, nil))
+	assert.True(t, Active(nil, nil, &tesla.VehicleState{IsUserPresent: true}, nil))
+	assert.True(t, Active(nil, nil, nil, &tesla.DriveState{ShiftState: "D"}))
+}
@@ -0,0 +1,105 @@
+package tesla
+
+import (
+	"fmt"
+	"time"
+)
+
+// DefaultMaxLocationAge is how old a GPS fix may be before the location-aware commands refuse to
+// use it, unless changed with SetMaxLocationAge.
+const DefaultMaxLocationAge = 5 * time.Minute
+
+// Location is the position of a vehicle as of a GPS fix.
+type Location struct {
+	Latitude  float64
+	Longitude float64
+	// Time is when the vehicle received the GPS fix.
+	Time time.Time
+}
+
+// SetMaxLocationAge sets how old a GPS fix may be for CurrentLocation. Zero restores
+// DefaultMaxLocationAge.
+func (c *Conn) SetMaxLocationAge(d time.Duration) {
+	c.locationsMu.Lock()
+	defer c.locationsMu.Unlock()
+
+	c.maxLocationAge = d
+}
+
+func (c *Conn) learnLocation(id int, loc Location) {
+	if loc.Latitude == 0 && loc.Longitude == 0 {
+		return
+	}
+
+	c.locationsMu.Lock()
+	defer c.locationsMu.Unlock()
+
+	if c.locations == nil {
+		c.locations = make(map[int]Location)
+	}
+
+	if loc.Time.After(c.locations[id].Time) {
+		c.locations[id] = loc
+	}
+}
+
+// CurrentLocation returns the current location of the vehicle. The latest position received from
+// a Stream is used if it is recent enough; otherwise the drive state is retrieved. If the GPS fix
+// is older than the maximum age, or there is none, an error wrapping ErrStaleLocation is returned.
+func (c *Conn) CurrentLocation(id int) (*Location, error) {
+	c.locationsMu.RLock()
+	loc, streamed := c.locations[id]
+	maxAge := c.maxLocationAge
+	c.locationsMu.RUnlock()
+
+	if maxAge == 0 {
+		maxAge = DefaultMaxLocationAge
+	}
+
+	if streamed && time.Since(loc.Time) <= maxAge {
+		return &loc, nil
+	}
+
+	state, err := c.GetDriveState(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Latitude == 0 && state.Longitude == 0 {
+		return nil, fmt.Errorf("%w: no GPS fix", ErrStaleLocation)
+	}
+
+	loc = Location{Latitude: state.Latitude, Longitude: state.Longitude, Time: state.GpsTime()}
+
+	if loc.Time.IsZero() {
+		return nil, fmt.Errorf("%w: no GPS fix time", ErrStaleLocation)
+	}
+
+	if age := time.Since(loc.Time); age > maxAge {
+		return nil, fmt.Errorf("%w: last GPS fix was %s ago", ErrStaleLocation, age.Round(time.Second))
+	}
+
+	return &loc, nil
+}
+
+// CloseWindowsAtVehicle closes all windows, using the vehicle's current location for the
+// proximity check. See CurrentLocation for how the location is found.
+func (c *Conn) CloseWindowsAtVehicle(id int) error {
+	loc, err := c.CurrentLocation(id)
+	if err != nil {
+		return err
+	}
+
+	return c.ActuateWindows(id, WindowCommandClose, loc.Latitude, loc.Longitude)
+}
+
+// TriggerHomelinkAtVehicle opens or closes the primary Homelink device, using the vehicle's
+// current location for the proximity check. See CurrentLocation for how the location is found.
+func (c *Conn) TriggerHomelinkAtVehicle(id int) error {
+	loc, err := c.CurrentLocation(id)
+	if err != nil {
+		return err
+	}
+
+	return c.TriggerHomelink(id, loc.Latitude, loc.Longitude)
+}
@@ -0,0 +1,208 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Logger receives structured log messages from a Conn. The arguments are alternating keys and
+// values. It is satisfied by *slog.Logger, so a Conn can log with any slog handler.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// redacted replaces sensitive values in logged headers and bodies.
+const redacted = "[REDACTED]"
+
+// redactedKeys are JSON keys whose values are never logged.
+var redactedKeys = map[string]bool{
+	"access_token":   true,
+	"backseat_token": true,
+	"client_secret":  true,
+	"password":       true,
+	"pin":            true,
+	"refresh_token":  true,
+	"token":          true,
+	"tokens":         true,
+}
+
+// SetLogger sets the logger used for requests and responses. Requests and successful responses
+// are logged at debug level, error responses at warn level, and failed requests at error level.
+// Credentials, tokens, passwords, and PINs are always redacted. Pass nil to turn logging off.
+func (c *Conn) SetLogger(logger Logger) {
+	c.logger = logger
+}
+
+// SetLogBodies turns logging of request and response bodies on or off. Bodies are off by default,
+// as responses can be large.
+func (c *Conn) SetLogBodies(on bool) {
+	c.logBodies = on
+}
+
+// SetDebugMode turns debug mode on or off. If on, all requests and responses, including bodies,
+// are logged to stdout. Sensitive values are redacted.
+//
+// Deprecated: Use SetLogger and SetLogBodies, which allow the output to be directed elsewhere.
+func (c *Conn) SetDebugMode(debug bool) {
+	if debug {
+		c.SetLogger(NewTextLogger(os.Stdout))
+		c.SetLogBodies(true)
+	} else {
+		c.SetLogger(nil)
+		c.SetLogBodies(false)
+	}
+}
+
+func (c *Conn) logRequest(req *http.Request, reqBody interface{}) {
+	if c.logger == nil {
+		return
+	}
+
+	args := []interface{}{
+		"method", req.Method,
+		"url", req.URL.String(),
+		"header", redactHeader(req.Header),
+	}
+
+	if c.logBodies && reqBody != nil {
+		body, err := json.Marshal(reqBody)
+		if err == nil {
+			args = append(args, "body", redactBody(body))
+		}
+	}
+
+	c.logger.Debug("tesla request", args...)
+}
+
+func (c *Conn) logResponse(req *http.Request, raw *RawResponse) {
+	if c.logger == nil {
+		return
+	}
+
+	args := []interface{}{
+		"method", req.Method,
+		"url", req.URL.String(),
+		"status", raw.StatusCode,
+		"duration", raw.Duration,
+	}
+
+	if c.logBodies {
+		args = append(args, "body", redactBody(raw.Body))
+	}
+
+	if raw.StatusCode != http.StatusOK {
+		c.logger.Warn("tesla response", args...)
+	} else {
+		c.logger.Debug("tesla response", args...)
+	}
+}
+
+func (c *Conn) logError(req *http.Request, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.Error("tesla request failed",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"error", err,
+	)
+}
+
+func redactHeader(header http.Header) http.Header {
+	clean := make(http.Header, len(header))
+
+	for key, values := range header {
+		if strings.EqualFold(key, "Authorization") || strings.EqualFold(key, "Cookie") {
+			clean[key] = []string{redacted}
+			continue
+		}
+
+		clean[key] = values
+	}
+
+	return clean
+}
+
+// redactBody returns the body with sensitive values replaced. Bodies that are not JSON are
+// returned unchanged, as the API only sends credentials in JSON.
+func redactBody(body []byte) string {
+	var val interface{}
+
+	if json.Unmarshal(body, &val) != nil {
+		return string(body)
+	}
+
+	clean, err := json.Marshal(redactValue(val))
+	if err != nil {
+		return redacted
+	}
+
+	return string(clean)
+}
+
+func redactValue(val interface{}) interface{} {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if redactedKeys[strings.ToLower(key)] && item != nil {
+				v[key] = redacted
+			} else {
+				v[key] = redactValue(item)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+
+	return val
+}
+
+// textLogger is a minimal Logger that writes one line per message.
+type textLogger struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewTextLogger returns a Logger that writes each message to w as a line of text, in the form
+// "time level msg key=value ...". It is intended for debugging; use slog for production logging.
+func NewTextLogger(w io.Writer) Logger {
+	return &textLogger{w: w}
+}
+
+func (l *textLogger) Debug(msg string, args ...interface{}) { l.log("DEBUG", msg, args) }
+func (l *textLogger) Info(msg string, args ...interface{})  { l.log("INFO", msg, args) }
+func (l *textLogger) Warn(msg string, args ...interface{})  { l.log("WARN", msg, args) }
+func (l *textLogger) Error(msg string, args ...interface{}) { l.log("ERROR", msg, args) }
+
+func (l *textLogger) log(level, msg string, args []interface{}) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s %q", time.Now().Format(time.RFC3339), level, msg)
+
+	for i := 0; i < len(args); i += 2 {
+		if i+1 < len(args) {
+			fmt.Fprintf(&b, " %v=%q", args[i], fmt.Sprint(args[i+1]))
+		} else {
+			fmt.Fprintf(&b, " !BADKEY=%q", fmt.Sprint(args[i]))
+		}
+	}
+
+	b.WriteByte('\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	_, _ = io.WriteString(l.w, b.String())
+}
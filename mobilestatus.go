@@ -7,8 +7,14 @@ import (
 
 // GetMobileEnabled returns whether or not the Mobile Access setting is enabled in the vehicle.
 func (c *Conn) GetMobileEnabled(id int) (bool, error) {
+	val, _, err := c.GetMobileEnabledRaw(id)
+	return val, err
+}
+
+// GetMobileEnabledRaw is like GetMobileEnabled, but also returns the raw HTTP response.
+func (c *Conn) GetMobileEnabledRaw(id int) (bool, *RawResponse, error) {
 	if c.accessToken == "" {
-		return false, fmt.Errorf("%w", ErrMissingAccessToken)
+		return false, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -17,10 +23,10 @@ func (c *Conn) GetMobileEnabled(id int) (bool, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/mobile_enabled", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/mobile_enabled", id), nil, &respBody)
 	if err != nil {
-		return false, err
+		return false, raw, err
 	}
 
-	return respBody.Response, nil
+	return respBody.Response, raw, nil
 }
@@ -0,0 +1,480 @@
+// Package mqttbridge publishes vehicle state to an MQTT broker and issues the commands it
+// receives from the broker, for home automation.
+//
+// With the default prefix, the topics are:
+//
+//	tesla/<vin>/state                       online, asleep, or offline
+//	tesla/<vin>/display_name
+//	tesla/<vin>/charge_state/battery_level  one topic for each field of each state, by JSON name
+//	tesla/<vin>/stream/speed                one topic for each field of streaming messages
+//	tesla/<vin>/command/<name>              subscribed; the payload is the JSON parameters, if any
+//	tesla/<vin>/command/<name>/result       {"result": true, "reason": ""}
+//
+// The states are charge_state, climate_state, drive_state, gui_settings, vehicle_config, and
+// vehicle_state. Fields of nested objects are published below the object's topic. Values are
+// published retained, and only when they change, so a new subscriber sees the latest values at
+// once. Commands use their names in the owner's API, such as door_lock or set_charge_limit.
+// Retained command messages are ignored, as they would be run again on every reconnect.
+//
+// Each data request keeps a vehicle awake, so the state of an idle vehicle is not polled for a
+// while to let it fall asleep, as configured by Config.IdleAfter and Config.SleepWindow. Listing
+// the vehicles, which publishes the state topic, never wakes them.
+//
+// Home Assistant discovery configs can also be published, so the main sensors and command buttons
+// appear in Home Assistant without configuration.
+package mqttbridge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/commands"
+	"github.com/rickbassham/tesla/internal/idle"
+)
+
+// DefaultPrefix is the topic prefix used if none is configured.
+const DefaultPrefix = "tesla"
+
+// Config configures a Bridge.
+type Config struct {
+	// Prefix is prepended to every topic. If empty, DefaultPrefix is used.
+	Prefix string
+
+	// DiscoveryPrefix is the Home Assistant discovery prefix, usually "homeassistant". If empty,
+	// discovery configs are not published.
+	DiscoveryPrefix string
+
+	// Commands are the names of the commands that may be issued over MQTT, or "*" for all
+	// commands. "wake_up" allows waking the vehicle. If empty, commands are ignored.
+	Commands []string
+
+	// Interval is how often vehicles are polled. If zero, they are polled every minute.
+	Interval time.Duration
+
+	// IdleAfter is how long the state of a vehicle keeps being polled after it stops being used.
+	// Each request keeps a vehicle awake, so polling then stops for SleepWindow to let it fall
+	// asleep. If zero, they are 15 and 30 minutes. Vehicles that are not online are never polled.
+	IdleAfter   time.Duration
+	SleepWindow time.Duration
+
+	// QoS is the MQTT quality of service for published and subscribed topics.
+	QoS byte
+
+	// Logger, if set, receives errors from Run and command results.
+	Logger tesla.Logger
+}
+
+// Bridge publishes vehicle state and issues commands for a Conn.
+type Bridge struct {
+	conn    *tesla.Conn
+	client  mqtt.Client
+	cfg     Config
+	tracker *idle.Tracker
+	now     func() time.Time
+
+	mu        sync.Mutex
+	vehicles  map[string]tesla.Vehicle
+	published map[string]string
+	streaming map[int]bool
+}
+
+// New returns a bridge between the vehicles on conn and the broker client is connected to.
+func New(conn *tesla.Conn, client mqtt.Client, cfg Config) *Bridge {
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Minute
+	}
+
+	if cfg.IdleAfter <= 0 {
+		cfg.IdleAfter = 15 * time.Minute
+	}
+
+	if cfg.SleepWindow <= 0 {
+		cfg.SleepWindow = 30 * time.Minute
+	}
+
+	return &Bridge{
+		conn:      conn,
+		client:    client,
+		cfg:       cfg,
+		tracker:   idle.NewTracker(cfg.IdleAfter, cfg.SleepWindow),
+		now:       time.Now,
+		vehicles:  make(map[string]tesla.Vehicle),
+		published: make(map[string]string),
+		streaming: make(map[int]bool),
+	}
+}
+
+// Run subscribes to the command topics, then polls state and streams data from vehicles that are
+// online until ctx is done.
+func (b *Bridge) Run(ctx context.Context) error {
+	if err := b.Subscribe(); err != nil {
+		return err
+	}
+
+	tick := time.NewTicker(b.cfg.Interval)
+	defer tick.Stop()
+
+	for {
+		if err := b.Poll(); err != nil {
+			b.logError("error polling vehicles", err)
+		}
+
+		b.mu.Lock()
+		for _, v := range b.vehicles {
+			if v.State == "online" && !b.streaming[v.ID] {
+				b.streaming[v.ID] = true
+				go b.stream(ctx, v)
+			}
+		}
+		b.mu.Unlock()
+
+		select {
+		case <-tick.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// stateReaders fetch the states published for each vehicle that is online.
+var stateReaders = []struct {
+	name string
+	read func(c *tesla.Conn, id int) (interface{}, error)
+}{
+	{"charge_state", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetChargeState(id) }},
+	{"climate_state", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetClimateState(id) }},
+	{"drive_state", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetDriveState(id) }},
+	{"gui_settings", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetGUISettings(id) }},
+	{"vehicle_config", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetVehicleConfig(id) }},
+	{"vehicle_state", func(c *tesla.Conn, id int) (interface{}, error) { return c.GetVehicleState(id) }},
+}
+
+// Poll publishes the state of every vehicle on the account. The full state is only requested
+// from vehicles that are online, and not while an idle vehicle is being left to fall asleep; see
+// Config.IdleAfter. The first error is returned after every vehicle is polled.
+func (b *Bridge) Poll() error {
+	vehicles, err := b.conn.GetVehicles()
+	if err != nil {
+		return err
+	}
+
+	var first error
+
+	keep := func(err error) {
+		if first == nil {
+			first = err
+		}
+	}
+
+	for _, v := range vehicles {
+		b.mu.Lock()
+		b.vehicles[v.VIN] = v
+		b.mu.Unlock()
+
+		if b.cfg.DiscoveryPrefix != "" {
+			if err := b.PublishDiscovery(v); err != nil {
+				keep(err)
+			}
+		}
+
+		if err := b.publish(b.topic(v.VIN, "state"), v.State); err != nil {
+			keep(err)
+		}
+
+		if err := b.publish(b.topic(v.VIN, "display_name"), v.DisplayName); err != nil {
+			keep(err)
+		}
+
+		now := b.now()
+
+		if !b.tracker.ShouldPoll(v, now) {
+			continue
+		}
+
+		states := make(map[string]interface{})
+
+		for _, reader := range stateReaders {
+			state, err := reader.read(b.conn, v.ID)
+			if err == nil {
+				states[reader.name] = state
+				err = b.PublishState(v.VIN, reader.name, state)
+			}
+
+			if err != nil {
+				keep(fmt.Errorf("error publishing %s for %s: %w", reader.name, v.VIN, err))
+			}
+		}
+
+		charge, _ := states["charge_state"].(*tesla.ChargeState)
+		climate, _ := states["climate_state"].(*tesla.ClimateState)
+		state, _ := states["vehicle_state"].(*tesla.VehicleState)
+		drive, _ := states["drive_state"].(*tesla.DriveState)
+
+		b.tracker.Polled(v.ID, now, idle.Active(charge, climate, state, drive))
+	}
+
+	return first
+}
+
+// PublishState publishes each field of state, such as a *tesla.ChargeState, to a topic named for
+// its JSON name below <prefix>/<vin>/<section>.
+func (b *Bridge) PublishState(vin, section string, state interface{}) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", section, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var val interface{}
+
+	if err := dec.Decode(&val); err != nil {
+		return fmt.Errorf("error encoding %s: %w", section, err)
+	}
+
+	values := make(map[string]string)
+	flatten(b.topic(vin, section), val, values)
+
+	for topic, payload := range values {
+		if err := b.publish(topic, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flatten adds a payload for each scalar in val to values, by topic. Arrays are published as
+// JSON, and nulls as empty payloads, which clear the retained value.
+func flatten(topic string, val interface{}, values map[string]string) {
+	switch val := val.(type) {
+	case map[string]interface{}:
+		for key, field := range val {
+			flatten(topic+"/"+key, field, values)
+		}
+	case nil:
+		values[topic] = ""
+	case string:
+		values[topic] = val
+	case json.Number:
+		values[topic] = val.String()
+	case bool:
+		values[topic] = strconv.FormatBool(val)
+	default:
+		data, _ := json.Marshal(val)
+		values[topic] = string(data)
+	}
+}
+
+// streamFields are the fields of a streaming message, by the names used by the streaming API.
+var streamFields = []struct {
+	name  string
+	value func(msg *tesla.StreamingMessage) string
+}{
+	{"timestamp", func(msg *tesla.StreamingMessage) string { return msg.Timestamp.UTC().Format(time.RFC3339Nano) }},
+	{"speed", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.Speed) }},
+	{"odometer", func(msg *tesla.StreamingMessage) string { return formatFloat(msg.Odometer) }},
+	{"soc", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.SOC) }},
+	{"elevation", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.Elevation) }},
+	{"est_heading", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.EstHeading) }},
+	{"est_lat", func(msg *tesla.StreamingMessage) string { return formatFloat(msg.EstLatitude) }},
+	{"est_lng", func(msg *tesla.StreamingMessage) string { return formatFloat(msg.EstLongitude) }},
+	{"power", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.Power) }},
+	{"shift_state", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.ShiftState) }},
+	{"range", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.Range) }},
+	{"est_range", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.EstRange) }},
+	{"heading", func(msg *tesla.StreamingMessage) string { return strconv.Itoa(msg.Heading) }},
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// PublishStream publishes each field of a streaming message below <prefix>/<vin>/stream.
+func (b *Bridge) PublishStream(vin string, msg tesla.StreamingMessage) error {
+	for _, field := range streamFields {
+		if err := b.publish(b.topic(vin, "stream", field.name), field.value(&msg)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// stream publishes streaming messages from the vehicle until the stream ends or ctx is done. Run
+// starts it again at the next poll if the vehicle is still online.
+func (b *Bridge) stream(ctx context.Context, v tesla.Vehicle) {
+	defer func() {
+		b.mu.Lock()
+		delete(b.streaming, v.ID)
+		b.mu.Unlock()
+	}()
+
+	s, err := b.conn.Stream(v.ID, "")
+	if err != nil {
+		b.logError("error starting stream", err, "vin", v.VIN)
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-done:
+		}
+	}()
+
+	for msg := range s.Data() {
+		if err := b.PublishStream(v.VIN, msg); err != nil {
+			b.logError("error publishing stream", err, "vin", v.VIN)
+		}
+	}
+}
+
+// Subscribe subscribes to the command topics. Run calls it; it is only needed when publishing
+// state without Run.
+func (b *Bridge) Subscribe() error {
+	token := b.client.Subscribe(b.topic("+", "command", "+"), b.cfg.QoS, func(_ mqtt.Client, msg mqtt.Message) {
+		// A retained command is delivered again each time the bridge subscribes, which would
+		// repeat a door_unlock on every reconnect.
+		if msg.Retained() {
+			if b.cfg.Logger != nil {
+				b.cfg.Logger.Warn("ignoring retained command", "topic", msg.Topic())
+			}
+
+			return
+		}
+
+		// Commands are slow, and the client cannot publish the result from within the handler.
+		go b.handleCommand(msg.Topic(), msg.Payload())
+	})
+
+	token.Wait()
+
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("error subscribing to commands: %w", err)
+	}
+
+	return nil
+}
+
+// commandResult is published after each command.
+type commandResult struct {
+	Result bool   `json:"result"`
+	Reason string `json:"reason"`
+}
+
+func (b *Bridge) handleCommand(topic string, payload []byte) {
+	parts := strings.Split(strings.TrimPrefix(topic, b.cfg.Prefix+"/"), "/")
+	if len(parts) != 3 || parts[1] != "command" {
+		return
+	}
+
+	vin, name := parts[0], parts[2]
+
+	err := b.runCommand(vin, name, payload)
+
+	result := commandResult{Result: err == nil}
+
+	if err != nil {
+		result.Reason = strings.TrimSuffix(err.Error(), ": "+tesla.ErrCommandError.Error())
+		b.logError("command failed", err, "vin", vin, "command", name)
+	} else if b.cfg.Logger != nil {
+		b.cfg.Logger.Info("command succeeded", "vin", vin, "command", name)
+	}
+
+	data, _ := json.Marshal(result)
+
+	token := b.client.Publish(topic+"/result", b.cfg.QoS, false, data)
+	token.Wait()
+
+	if err := token.Error(); err != nil {
+		b.logError("error publishing command result", err, "vin", vin, "command", name)
+	}
+}
+
+// errNotAllowed is returned for commands that are not in Config.Commands.
+var errNotAllowed = errors.New("command not allowed")
+
+func (b *Bridge) runCommand(vin, name string, payload []byte) error {
+	if !b.allows(name) {
+		return errNotAllowed
+	}
+
+	b.mu.Lock()
+	v, ok := b.vehicles[vin]
+	b.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("unknown vehicle %s", vin)
+	}
+
+	if name == "wake_up" {
+		_, err := b.conn.WakeUp(v.ID)
+		return err
+	}
+
+	return commands.Run(b.conn, v.ID, name, bytes.TrimSpace(payload))
+}
+
+func (b *Bridge) allows(name string) bool {
+	for _, allowed := range b.cfg.Commands {
+		if allowed == "*" || allowed == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (b *Bridge) topic(parts ...string) string {
+	return b.cfg.Prefix + "/" + strings.Join(parts, "/")
+}
+
+// publish publishes a retained payload, unless it is unchanged since it was last published.
+func (b *Bridge) publish(topic, payload string) error {
+	b.mu.Lock()
+	last, ok := b.published[topic]
+	b.mu.Unlock()
+
+	if ok && last == payload {
+		return nil
+	}
+
+	token := b.client.Publish(topic, b.cfg.QoS, true, payload)
+	token.Wait()
+
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("error publishing %s: %w", topic, err)
+	}
+
+	b.mu.Lock()
+	b.published[topic] = payload
+	b.mu.Unlock()
+
+	return nil
+}
+
+func (b *Bridge) logError(msg string, err error, args ...interface{}) {
+	if b.cfg.Logger != nil {
+		b.cfg.Logger.Warn(msg, append([]interface{}{"error", err}, args...)...)
+	}
+}
@@ -0,0 +1,361 @@
+package mqttbridge_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	mochi "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/mochi-mqtt/server/v2/packets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/mqttbridge"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+const vin = teslatest.VIN
+
+type fixture struct {
+	t      *testing.T
+	srv    *teslatest.Server
+	broker *mochi.Server
+	client mqtt.Client
+	bridge *mqttbridge.Bridge
+
+	mu       sync.Mutex
+	messages map[string][]string
+}
+
+func newFixture(t *testing.T, cfg mqttbridge.Config) *fixture {
+	srv, conn := teslatest.NewTestServer(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	broker := mochi.New(&mochi.Options{
+		InlineClient: true,
+		Logger:       slog.New(slog.NewTextHandler(ioutil.Discard, nil)),
+	})
+	require.NoError(t, broker.AddHook(new(auth.AllowHook), nil))
+	require.NoError(t, broker.AddListener(listeners.NewTCP(listeners.Config{ID: "tcp", Address: addr})))
+
+	go func() { _ = broker.Serve() }()
+	t.Cleanup(func() { _ = broker.Close() })
+
+	client := mqtt.NewClient(mqtt.NewClientOptions().AddBroker("tcp://" + addr).SetClientID("bridge"))
+	token := client.Connect()
+	require.True(t, token.WaitTimeout(5*time.Second))
+	require.NoError(t, token.Error())
+	t.Cleanup(func() { client.Disconnect(0) })
+
+	f := &fixture{
+		t:        t,
+		srv:      srv,
+		broker:   broker,
+		client:   client,
+		bridge:   mqttbridge.New(conn, client, cfg),
+		messages: make(map[string][]string),
+	}
+
+	require.NoError(t, broker.Subscribe("#", 1, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.messages[pk.TopicName] = append(f.messages[pk.TopicName], string(pk.Payload))
+	}))
+
+	return f
+}
+
+// wait waits for a message on the topic and returns the latest payload.
+func (f *fixture) wait(topic string) string {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		payloads := f.messages[topic]
+		f.mu.Unlock()
+
+		if len(payloads) > 0 {
+			return payloads[len(payloads)-1]
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	f.t.Fatalf("timed out waiting for %s", topic)
+
+	return ""
+}
+
+// waitFor waits for the latest payload on the topic to be want.
+func (f *fixture) waitFor(topic, want string) {
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		if f.wait(topic) == want {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.Equal(f.t, want, f.wait(topic))
+}
+
+func (f *fixture) count(topic string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.messages[topic])
+}
+
+// command publishes a command and returns its result.
+func (f *fixture) command(name, payload string) map[string]interface{} {
+	topic := "tesla/" + vin + "/command/" + name
+
+	f.mu.Lock()
+	delete(f.messages, topic+"/result")
+	f.mu.Unlock()
+
+	require.NoError(f.t, f.broker.Publish(topic, []byte(payload), false, 0))
+
+	var result map[string]interface{}
+	require.NoError(f.t, json.Unmarshal([]byte(f.wait(topic+"/result")), &result))
+
+	return result
+}
+
+func TestPoll(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{})
+
+	require.NoError(t, f.bridge.Poll())
+
+	assert.Equal(t, "online", f.wait("tesla/"+vin+"/state"))
+	assert.Equal(t, "Test Vehicle", f.wait("tesla/"+vin+"/display_name"))
+	assert.Equal(t, "60", f.wait("tesla/"+vin+"/charge_state/battery_level"))
+	assert.Equal(t, "Disconnected", f.wait("tesla/"+vin+"/charge_state/charging_state"))
+	assert.Equal(t, "true", f.wait("tesla/"+vin+"/vehicle_state/locked"))
+	assert.Equal(t, "65", f.wait("tesla/"+vin+"/vehicle_state/speed_limit_mode/current_limit_mph"))
+	assert.Equal(t, "", f.wait("tesla/"+vin+"/drive_state/speed"), "null clears the retained value")
+
+	require.NoError(t, f.bridge.Poll())
+	assert.Equal(t, 1, f.count("tesla/"+vin+"/charge_state/battery_level"), "unchanged values are not published again")
+
+	f.srv.Vehicle(1).Sleep()
+
+	require.NoError(t, f.bridge.Poll())
+	f.waitFor("tesla/"+vin+"/state", "asleep")
+
+	for _, req := range f.srv.Requests()[len(f.srv.Requests())-1:] {
+		assert.Equal(t, "/api/1/vehicles", req.Path, "polling does not wake the vehicle")
+	}
+}
+
+func TestPollLetsIdleVehiclesSleep(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{})
+
+	now := time.Unix(1580000000, 0)
+	f.bridge.SetClock(func() time.Time { return now })
+
+	poll := func(d time.Duration) int {
+		now = now.Add(d)
+		before := f.srv.Requests()
+		require.NoError(t, f.bridge.Poll())
+
+		n := 0
+		for _, req := range f.srv.Requests()[len(before):] {
+			if strings.Contains(req.Path, "/data_request/") {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	assert.Equal(t, 6, poll(0), "a vehicle that is online is polled")
+	assert.Equal(t, 6, poll(14*time.Minute), "and keeps being polled until idle")
+	assert.Equal(t, 0, poll(time.Minute), "an idle vehicle is left alone to sleep")
+	assert.Equal(t, 0, poll(29*time.Minute))
+	assert.Equal(t, 6, poll(time.Minute), "a vehicle awake after the sleep window is polled again")
+
+	f.srv.Vehicle(1).PlugIn(true)
+
+	assert.Equal(t, 0, poll(time.Minute), "the vehicle is not polled within the next window")
+	assert.Equal(t, 6, poll(30*time.Minute))
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, 6, poll(10*time.Minute), "a charging vehicle is always polled")
+	}
+}
+
+func TestRetained(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{})
+
+	require.NoError(t, f.bridge.Poll())
+	f.wait("tesla/" + vin + "/climate_state/inside_temp")
+
+	late := make(chan string, 1)
+
+	require.NoError(t, f.broker.Subscribe("tesla/"+vin+"/climate_state/inside_temp", 2, func(_ *mochi.Client, _ packets.Subscription, pk packets.Packet) {
+		late <- string(pk.Payload)
+	}))
+
+	select {
+	case payload := <-late:
+		assert.Equal(t, "20", payload)
+	case <-time.After(5 * time.Second):
+		t.Fatal("a new subscriber should receive the retained value")
+	}
+}
+
+func TestDiscovery(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{
+		DiscoveryPrefix: "homeassistant",
+		Commands:        []string{"door_lock", "set_charge_limit"},
+	})
+
+	require.NoError(t, f.bridge.Poll())
+
+	var sensor map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(f.wait("homeassistant/sensor/"+vin+"/charge_state_battery_level/config")), &sensor))
+	assert.Equal(t, "tesla/"+vin+"/charge_state/battery_level", sensor["state_topic"])
+	assert.Equal(t, "%", sensor["unit_of_measurement"])
+	assert.Equal(t, vin+"_charge_state_battery_level", sensor["unique_id"])
+	assert.Equal(t, map[string]interface{}{
+		"identifiers":  []interface{}{vin},
+		"name":         "Test Vehicle",
+		"manufacturer": "Tesla",
+	}, sensor["device"])
+
+	var lock map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(f.wait("homeassistant/binary_sensor/"+vin+"/vehicle_state_locked/config")), &lock))
+	assert.Equal(t, "false", lock["payload_on"])
+
+	var button map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(f.wait("homeassistant/button/"+vin+"/door_lock/config")), &button))
+	assert.Equal(t, "tesla/"+vin+"/command/door_lock", button["command_topic"])
+	assert.Equal(t, "", button["payload_press"])
+
+	assert.Equal(t, 0, f.count("homeassistant/button/"+vin+"/honk_horn/config"), "only allowed commands get buttons")
+}
+
+func TestCommands(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{
+		Commands: []string{"door_unlock", "set_charge_limit", "charge_start", "wake_up"},
+	})
+
+	require.NoError(t, f.bridge.Subscribe())
+
+	result := f.command("door_unlock", "")
+	assert.Equal(t, false, result["result"])
+	assert.Contains(t, result["reason"], "unknown vehicle", "vehicles are known after polling")
+
+	require.NoError(t, f.bridge.Poll())
+
+	result = f.command("door_unlock", "")
+	assert.Equal(t, true, result["result"])
+	assert.False(t, f.srv.Vehicle(1).VehicleState.Locked)
+
+	result = f.command("set_charge_limit", `{"percent": 85}`)
+	assert.Equal(t, true, result["result"])
+	assert.Equal(t, 85, f.srv.Vehicle(1).ChargeState.ChargeLimitSoc)
+
+	result = f.command("set_charge_limit", `{"percent": "high"}`)
+	assert.Equal(t, false, result["result"])
+	assert.Contains(t, result["reason"], "invalid parameters")
+
+	result = f.command("charge_start", "")
+	assert.Equal(t, map[string]interface{}{"result": false, "reason": "disconnected"}, result)
+
+	result = f.command("honk_horn", "")
+	assert.Equal(t, map[string]interface{}{"result": false, "reason": "command not allowed"}, result)
+
+	f.srv.Vehicle(1).Sleep()
+
+	result = f.command("wake_up", "")
+	assert.Equal(t, true, result["result"])
+
+	var names []string
+	for _, cmd := range f.srv.Vehicle(1).Commands() {
+		names = append(names, cmd.Name)
+	}
+
+	assert.Equal(t, []string{"door_unlock", "set_charge_limit", "charge_start"}, names)
+}
+
+func TestRetainedCommandsIgnored(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{Commands: []string{"door_unlock", "door_lock"}})
+
+	require.NoError(t, f.bridge.Poll())
+
+	// A retained command is left on the broker, as a misconfigured client might, and delivered
+	// when the bridge subscribes. An empty retained payload would clear it instead.
+	require.NoError(t, f.broker.Publish("tesla/"+vin+"/command/door_unlock", []byte("{}"), true, 0))
+	require.NoError(t, f.bridge.Subscribe())
+
+	result := f.command("door_lock", "")
+	assert.Equal(t, true, result["result"])
+
+	var names []string
+	for _, cmd := range f.srv.Vehicle(1).Commands() {
+		names = append(names, cmd.Name)
+	}
+
+	assert.Equal(t, []string{"door_lock"}, names, "the retained command is not run")
+	assert.Equal(t, 0, f.count("tesla/"+vin+"/command/door_unlock/result"))
+}
+
+func TestRunStreams(t *testing.T) {
+	f := newFixture(t, mqttbridge.Config{Interval: time.Hour})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- f.bridge.Run(ctx) }()
+
+	f.wait("tesla/" + vin + "/charge_state/battery_level")
+
+	msg := tesla.StreamingMessage{Timestamp: time.Unix(1580000000, 0), Speed: 55, SOC: 70, EstLatitude: 37.5, Power: 30}
+
+	// The stream may not be connected yet, so keep sending until a message arrives.
+	stop := make(chan struct{})
+	go func() {
+		for {
+			f.srv.Vehicle(1).Stream(msg)
+
+			select {
+			case <-time.After(50 * time.Millisecond):
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	assert.Equal(t, "55", f.wait("tesla/"+vin+"/stream/speed"))
+	assert.Equal(t, "37.5", f.wait("tesla/"+vin+"/stream/est_lat"))
+	assert.Equal(t, "2020-01-26T00:53:20Z", f.wait("tesla/"+vin+"/stream/timestamp"))
+
+	close(stop)
+	cancel()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return")
+	}
+}
@@ -0,0 +1,101 @@
+// Command tesla-mqtt runs an MQTT bridge for the vehicles on an account. See package mqttbridge for
+// the topics.
+//
+// Usage:
+//
+//	tesla-mqtt [-broker tcp://localhost:1883] [-commands door_lock,door_unlock] [-discovery-prefix homeassistant]
+//
+// It uses the credentials saved by "tesla login". The MQTT username and password may be given
+// with the MQTT_USERNAME and MQTT_PASSWORD environment variables. No commands are accepted unless
+// listed with -commands; "*" accepts them all. State is polled so vehicles can sleep: see
+// -idle-after and -sleep-window.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/internal/credentials"
+	"github.com/rickbassham/tesla/mqttbridge"
+)
+
+func main() {
+	if err := run(); err != nil && err != context.Canceled {
+		fmt.Fprintln(os.Stderr, "tesla-mqtt:", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	configPath := flag.String("config", os.Getenv("TESLA_CONFIG"), "path of the credentials file saved by tesla login")
+	broker := flag.String("broker", "tcp://localhost:1883", "URL of the MQTT broker")
+	clientID := flag.String("client-id", "tesla-mqtt", "MQTT client id")
+	prefix := flag.String("prefix", mqttbridge.DefaultPrefix, "topic prefix")
+	discovery := flag.String("discovery-prefix", "homeassistant", "Home Assistant discovery prefix, or empty to disable discovery")
+	commands := flag.String("commands", "", "comma-separated commands to accept, or * for all")
+	interval := flag.Duration("interval", time.Minute, "how often to poll")
+	idleAfter := flag.Duration("idle-after", 15*time.Minute, "how long to keep polling a vehicle after it stops being used")
+	sleepWindow := flag.Duration("sleep-window", 30*time.Minute, "how long to stop polling an idle vehicle so it can sleep")
+	flag.Parse()
+
+	session, err := credentials.Open(*configPath)
+	if err != nil {
+		return err
+	}
+
+	conn := session.Conn
+
+	// Refresh the access token now if it has expired, as the bridge cannot save it.
+	err = session.Call(func() error {
+		_, err := conn.GetVehicles()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(*broker).
+		SetClientID(*clientID).
+		SetUsername(os.Getenv("MQTT_USERNAME")).
+		SetPassword(os.Getenv("MQTT_PASSWORD")).
+		SetAutoReconnect(true)
+
+	client := mqtt.NewClient(opts)
+
+	token := client.Connect()
+	token.Wait()
+
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("error connecting to %s: %w", *broker, err)
+	}
+	defer client.Disconnect(250)
+
+	var allowed []string
+	if *commands != "" {
+		allowed = strings.Split(*commands, ",")
+	}
+
+	bridge := mqttbridge.New(conn, client, mqttbridge.Config{
+		Prefix:          *prefix,
+		DiscoveryPrefix: *discovery,
+		Commands:        allowed,
+		Interval:        *interval,
+		IdleAfter:       *idleAfter,
+		SleepWindow:     *sleepWindow,
+		Logger:          tesla.NewTextLogger(os.Stderr),
+	})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	return bridge.Run(ctx)
+}
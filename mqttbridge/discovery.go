@@ -0,0 +1,142 @@
+package mqttbridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rickbassham/tesla"
+)
+
+// entity is a Home Assistant entity for a published topic or a command.
+type entity struct {
+	component string
+	topic     string
+	name      string
+
+	unit        string
+	deviceClass string
+	stateClass  string
+
+	// payloadOn and payloadOff are the states of a binary sensor.
+	payloadOn  string
+	payloadOff string
+}
+
+// entities are the Home Assistant sensors. Values are in the units of the owner's API.
+var entities = []entity{
+	{component: "sensor", topic: "state", name: "State"},
+	{component: "sensor", topic: "charge_state/battery_level", name: "Battery", unit: "%", deviceClass: "battery", stateClass: "measurement"},
+	{component: "sensor", topic: "charge_state/battery_range", name: "Range", unit: "mi", deviceClass: "distance", stateClass: "measurement"},
+	{component: "sensor", topic: "charge_state/charge_limit_soc", name: "Charge limit", unit: "%"},
+	{component: "sensor", topic: "charge_state/charging_state", name: "Charging state"},
+	{component: "sensor", topic: "charge_state/charger_power", name: "Charger power", unit: "kW", deviceClass: "power", stateClass: "measurement"},
+	{component: "sensor", topic: "charge_state/charge_energy_added", name: "Energy added", unit: "kWh", deviceClass: "energy", stateClass: "total_increasing"},
+	{component: "sensor", topic: "climate_state/inside_temp", name: "Inside temperature", unit: "°C", deviceClass: "temperature", stateClass: "measurement"},
+	{component: "sensor", topic: "climate_state/outside_temp", name: "Outside temperature", unit: "°C", deviceClass: "temperature", stateClass: "measurement"},
+	{component: "sensor", topic: "vehicle_state/odometer", name: "Odometer", unit: "mi", deviceClass: "distance", stateClass: "total_increasing"},
+	{component: "sensor", topic: "stream/speed", name: "Speed", unit: "mph", deviceClass: "speed", stateClass: "measurement"},
+	{component: "sensor", topic: "stream/power", name: "Power", unit: "kW", deviceClass: "power", stateClass: "measurement"},
+	// Home Assistant's lock class is on when unlocked.
+	{component: "binary_sensor", topic: "vehicle_state/locked", name: "Doors", deviceClass: "lock", payloadOn: "false", payloadOff: "true"},
+	{component: "binary_sensor", topic: "vehicle_state/sentry_mode", name: "Sentry mode", payloadOn: "true", payloadOff: "false"},
+	{component: "binary_sensor", topic: "climate_state/is_climate_on", name: "Climate", payloadOn: "true", payloadOff: "false"},
+	{component: "binary_sensor", topic: "charge_state/charge_port_door_open", name: "Charge port", deviceClass: "door", payloadOn: "true", payloadOff: "false"},
+}
+
+// buttons are the commands shown as Home Assistant buttons, if they are allowed.
+var buttons = []struct {
+	command string
+	name    string
+}{
+	{"wake_up", "Wake up"},
+	{"door_lock", "Lock"},
+	{"door_unlock", "Unlock"},
+	{"honk_horn", "Honk horn"},
+	{"flash_lights", "Flash lights"},
+	{"auto_conditioning_start", "Start climate"},
+	{"auto_conditioning_stop", "Stop climate"},
+	{"charge_start", "Start charging"},
+	{"charge_stop", "Stop charging"},
+	{"charge_port_door_open", "Open charge port"},
+	{"charge_port_door_close", "Close charge port"},
+}
+
+type discoveryDevice struct {
+	Identifiers  []string `json:"identifiers"`
+	Name         string   `json:"name"`
+	Manufacturer string   `json:"manufacturer"`
+}
+
+type discoveryConfig struct {
+	Name         string          `json:"name"`
+	UniqueID     string          `json:"unique_id"`
+	StateTopic   string          `json:"state_topic,omitempty"`
+	CommandTopic string          `json:"command_topic,omitempty"`
+	PayloadPress *string         `json:"payload_press,omitempty"`
+	PayloadOn    string          `json:"payload_on,omitempty"`
+	PayloadOff   string          `json:"payload_off,omitempty"`
+	Unit         string          `json:"unit_of_measurement,omitempty"`
+	DeviceClass  string          `json:"device_class,omitempty"`
+	StateClass   string          `json:"state_class,omitempty"`
+	Device       discoveryDevice `json:"device"`
+}
+
+// PublishDiscovery publishes the Home Assistant discovery configs for the vehicle's sensors, and
+// for buttons issuing the allowed commands. Poll calls it when Config.DiscoveryPrefix is set.
+func (b *Bridge) PublishDiscovery(v tesla.Vehicle) error {
+	device := discoveryDevice{
+		Identifiers:  []string{v.VIN},
+		Name:         v.DisplayName,
+		Manufacturer: "Tesla",
+	}
+
+	for _, e := range entities {
+		objectID := strings.Replace(e.topic, "/", "_", -1)
+
+		err := b.publishDiscovery(e.component, v.VIN, objectID, discoveryConfig{
+			Name:        e.name,
+			UniqueID:    v.VIN + "_" + objectID,
+			StateTopic:  b.topic(v.VIN, e.topic),
+			PayloadOn:   e.payloadOn,
+			PayloadOff:  e.payloadOff,
+			Unit:        e.unit,
+			DeviceClass: e.deviceClass,
+			StateClass:  e.stateClass,
+			Device:      device,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, button := range buttons {
+		if !b.allows(button.command) {
+			continue
+		}
+
+		press := ""
+
+		err := b.publishDiscovery("button", v.VIN, button.command, discoveryConfig{
+			Name:         button.name,
+			UniqueID:     v.VIN + "_" + button.command,
+			CommandTopic: b.topic(v.VIN, "command", button.command),
+			PayloadPress: &press,
+			Device:       device,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *Bridge) publishDiscovery(component, vin, objectID string, cfg discoveryConfig) error {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return fmt.Errorf("error encoding discovery config: %w", err)
+	}
+
+	return b.publish(fmt.Sprintf("%s/%s/%s/%s/config", b.cfg.DiscoveryPrefix, component, vin, objectID), string(data))
+}
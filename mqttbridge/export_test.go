@@ -0,0 +1,8 @@
+package mqttbridge
+
+import "time"
+
+// SetClock replaces the clock used to decide when vehicles are idle.
+func (b *Bridge) SetClock(now func() time.Time) {
+	b.now = now
+}
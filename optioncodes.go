@@ -0,0 +1,153 @@
+package tesla
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// OptionCategory groups option codes by the part of the vehicle they describe.
+type OptionCategory string
+
+var (
+	// OptionCategoryUnknown is used for codes not found in the option code table.
+	OptionCategoryUnknown = OptionCategory("")
+	// OptionCategoryModel identifies the vehicle model.
+	OptionCategoryModel = OptionCategory("model")
+	// OptionCategoryBattery identifies the battery pack or its software limit.
+	OptionCategoryBattery = OptionCategory("battery")
+	// OptionCategoryDrive identifies the drivetrain.
+	OptionCategoryDrive = OptionCategory("drive")
+	// OptionCategoryPaint identifies the exterior paint.
+	OptionCategoryPaint = OptionCategory("paint")
+	// OptionCategoryWheels identifies the wheels.
+	OptionCategoryWheels = OptionCategory("wheels")
+	// OptionCategoryInterior identifies the seats and interior trim.
+	OptionCategoryInterior = OptionCategory("interior")
+	// OptionCategoryAutopilotHardware identifies the Autopilot computer and sensor suite.
+	OptionCategoryAutopilotHardware = OptionCategory("autopilot_hardware")
+	// OptionCategoryAutopilot identifies the purchased Autopilot software package.
+	OptionCategoryAutopilot = OptionCategory("autopilot")
+	// OptionCategoryRegion identifies the market the vehicle was built for.
+	OptionCategoryRegion = OptionCategory("region")
+	// OptionCategoryOther is used for known codes that do not fit another category.
+	OptionCategoryOther = OptionCategory("other")
+)
+
+// OptionCode is a single decoded option code.
+type OptionCode struct {
+	Code        string         `json:"code"`
+	Category    OptionCategory `json:"category"`
+	Description string         `json:"description"`
+}
+
+// Options is the decoded form of Vehicle.OptionCodes. Each single-valued field is the zero
+// OptionCode if no matching code was present.
+type Options struct {
+	Model             OptionCode
+	Battery           OptionCode
+	Drive             OptionCode
+	Paint             OptionCode
+	Wheels            OptionCode
+	Interior          OptionCode
+	AutopilotHardware OptionCode
+	Region            OptionCode
+
+	// All holds every code in the order received, including unknown codes.
+	All []OptionCode
+	// Unknown holds the codes that were not found in the option code table.
+	Unknown []string
+}
+
+// optionCodesMu guards optionCodes, which may be updated by LoadOptionCodes.
+var optionCodesMu sync.RWMutex
+
+// LoadOptionCodes merges option code definitions into the table used by DecodeOptionCodes. This
+// allows new codes to be added without updating the package. The input is a JSON object keyed by
+// code, for example:
+//
+//	{"PPSB": {"category": "paint", "description": "Deep Blue Metallic"}}
+func LoadOptionCodes(r io.Reader) error {
+	var table map[string]OptionCode
+
+	err := json.NewDecoder(r).Decode(&table)
+	if err != nil {
+		return fmt.Errorf("error decoding option code table: %w", err)
+	}
+
+	optionCodesMu.Lock()
+	defer optionCodesMu.Unlock()
+
+	for code, opt := range table {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		opt.Code = code
+		optionCodes[code] = opt
+	}
+
+	return nil
+}
+
+// LookupOptionCode returns the definition of a single option code. The code is matched without
+// regard to case or surrounding whitespace.
+func LookupOptionCode(code string) (OptionCode, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+
+	optionCodesMu.RLock()
+	defer optionCodesMu.RUnlock()
+
+	opt, ok := optionCodes[code]
+	if ok {
+		opt.Code = code
+	}
+
+	return opt, ok
+}
+
+// DecodeOptionCodes parses a comma separated list of option codes, such as
+// "MDLS,RENA,AD15,PBSB".
+func DecodeOptionCodes(codes string) Options {
+	var opts Options
+
+	for _, code := range strings.Split(codes, ",") {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+
+		opt, ok := LookupOptionCode(code)
+		if !ok {
+			opt = OptionCode{Code: code, Category: OptionCategoryUnknown}
+			opts.Unknown = append(opts.Unknown, code)
+		}
+
+		opts.All = append(opts.All, opt)
+
+		switch opt.Category {
+		case OptionCategoryModel:
+			opts.Model = opt
+		case OptionCategoryBattery:
+			opts.Battery = opt
+		case OptionCategoryDrive:
+			opts.Drive = opt
+		case OptionCategoryPaint:
+			opts.Paint = opt
+		case OptionCategoryWheels:
+			opts.Wheels = opt
+		case OptionCategoryInterior:
+			opts.Interior = opt
+		case OptionCategoryAutopilotHardware:
+			opts.AutopilotHardware = opt
+		case OptionCategoryRegion:
+			opts.Region = opt
+		}
+	}
+
+	return opts
+}
+
+// Options decodes the vehicle's option codes.
+func (v *Vehicle) Options() Options {
+	return DecodeOptionCodes(v.OptionCodes)
+}
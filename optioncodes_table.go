@@ -0,0 +1,104 @@
+package tesla
+
+// optionCodes is the built in option code table, based on the codes documented at
+// https://tesla-api.timdorr.com/vehicle/optioncodes. Use LoadOptionCodes to add to it.
+var optionCodes = map[string]OptionCode{
+	// Model
+	"MDLS": {Category: OptionCategoryModel, Description: "Model S"},
+	"MS03": {Category: OptionCategoryModel, Description: "Model S"},
+	"MS04": {Category: OptionCategoryModel, Description: "Model S"},
+	"MDLX": {Category: OptionCategoryModel, Description: "Model X"},
+	"MDL3": {Category: OptionCategoryModel, Description: "Model 3"},
+	"MDLY": {Category: OptionCategoryModel, Description: "Model Y"},
+
+	// Region
+	"RENA": {Category: OptionCategoryRegion, Description: "North America"},
+	"RENC": {Category: OptionCategoryRegion, Description: "Canada"},
+	"REEU": {Category: OptionCategoryRegion, Description: "Europe"},
+	"RECN": {Category: OptionCategoryRegion, Description: "China"},
+	"REAP": {Category: OptionCategoryRegion, Description: "Asia Pacific"},
+	"REHK": {Category: OptionCategoryRegion, Description: "Hong Kong"},
+	"REJP": {Category: OptionCategoryRegion, Description: "Japan"},
+
+	// Battery
+	"BT37": {Category: OptionCategoryBattery, Description: "75 kWh (Model 3)"},
+	"BT40": {Category: OptionCategoryBattery, Description: "40 kWh"},
+	"BT60": {Category: OptionCategoryBattery, Description: "60 kWh"},
+	"BT70": {Category: OptionCategoryBattery, Description: "70 kWh"},
+	"BT85": {Category: OptionCategoryBattery, Description: "85 kWh"},
+	"BTX4": {Category: OptionCategoryBattery, Description: "90 kWh"},
+	"BTX5": {Category: OptionCategoryBattery, Description: "75 kWh"},
+	"BTX6": {Category: OptionCategoryBattery, Description: "100 kWh"},
+	"BTX7": {Category: OptionCategoryBattery, Description: "75 kWh"},
+	"BTX8": {Category: OptionCategoryBattery, Description: "85 kWh"},
+	"BR03": {Category: OptionCategoryBattery, Description: "60 kWh (software limited)"},
+	"BR05": {Category: OptionCategoryBattery, Description: "75 kWh (software limited)"},
+
+	// Drive
+	"DV2W": {Category: OptionCategoryDrive, Description: "Rear-Wheel Drive"},
+	"DV4W": {Category: OptionCategoryDrive, Description: "All-Wheel Drive"},
+
+	// Paint
+	"PBCW": {Category: OptionCategoryPaint, Description: "Catalina White"},
+	"PBSB": {Category: OptionCategoryPaint, Description: "Solid Black"},
+	"PMAB": {Category: OptionCategoryPaint, Description: "Anza Brown Metallic"},
+	"PMBL": {Category: OptionCategoryPaint, Description: "Obsidian Black Multi-Coat"},
+	"PMMB": {Category: OptionCategoryPaint, Description: "Monterey Blue Metallic"},
+	"PMMR": {Category: OptionCategoryPaint, Description: "Multi-Coat Red"},
+	"PMNG": {Category: OptionCategoryPaint, Description: "Midnight Silver Metallic"},
+	"PMSG": {Category: OptionCategoryPaint, Description: "Sequoia Green Metallic"},
+	"PMSS": {Category: OptionCategoryPaint, Description: "San Simeon Silver Metallic"},
+	"PMTG": {Category: OptionCategoryPaint, Description: "Dolphin Grey Metallic"},
+	"PPMR": {Category: OptionCategoryPaint, Description: "Red Multi-Coat"},
+	"PPSB": {Category: OptionCategoryPaint, Description: "Deep Blue Metallic"},
+	"PPSR": {Category: OptionCategoryPaint, Description: "Signature Red"},
+	"PPSW": {Category: OptionCategoryPaint, Description: "Pearl White Multi-Coat"},
+	"PPTI": {Category: OptionCategoryPaint, Description: "Titanium Metallic"},
+
+	// Wheels
+	"W38B": {Category: OptionCategoryWheels, Description: "18\" Aero Wheels"},
+	"W39B": {Category: OptionCategoryWheels, Description: "19\" Sport Wheels"},
+	"W32P": {Category: OptionCategoryWheels, Description: "20\" Performance Wheels"},
+	"WT19": {Category: OptionCategoryWheels, Description: "19\" Wheels"},
+	"WT20": {Category: OptionCategoryWheels, Description: "20\" Silver Slipstream Wheels"},
+	"WT21": {Category: OptionCategoryWheels, Description: "21\" Wheels"},
+	"WTAS": {Category: OptionCategoryWheels, Description: "19\" Silver Slipstream Wheels"},
+	"WTDS": {Category: OptionCategoryWheels, Description: "19\" Grey Slipstream Wheels"},
+	"WTSG": {Category: OptionCategoryWheels, Description: "21\" Turbine Grey Wheels"},
+	"WTTB": {Category: OptionCategoryWheels, Description: "19\" Cyclone Wheels"},
+	"WTX1": {Category: OptionCategoryWheels, Description: "19\" Michelin Primacy All-Season Tires"},
+
+	// Interior
+	"IBE00": {Category: OptionCategoryInterior, Description: "Black Textile Seats"},
+	"IN3BB": {Category: OptionCategoryInterior, Description: "All Black Partial Premium Interior"},
+	"IN3PB": {Category: OptionCategoryInterior, Description: "All Black Premium Interior"},
+	"IN3PW": {Category: OptionCategoryInterior, Description: "Black and White Premium Interior"},
+	"IPB0":  {Category: OptionCategoryInterior, Description: "All Black Interior"},
+	"IPW0":  {Category: OptionCategoryInterior, Description: "White Interior"},
+
+	// Autopilot hardware
+	"APH0": {Category: OptionCategoryAutopilotHardware, Description: "Autopilot 2.0 Hardware"},
+	"APH1": {Category: OptionCategoryAutopilotHardware, Description: "Autopilot 1.0 Hardware"},
+	"APH2": {Category: OptionCategoryAutopilotHardware, Description: "Autopilot 2.0 Hardware"},
+	"APH3": {Category: OptionCategoryAutopilotHardware, Description: "Autopilot 2.5 Hardware"},
+	"APH4": {Category: OptionCategoryAutopilotHardware, Description: "Autopilot 3.0 Hardware (Full Self-Driving Computer)"},
+
+	// Autopilot software
+	"APBS": {Category: OptionCategoryAutopilot, Description: "Autopilot"},
+	"APF0": {Category: OptionCategoryAutopilot, Description: "Autopilot Firmware 2.0 Base"},
+	"APF1": {Category: OptionCategoryAutopilot, Description: "Enhanced Autopilot"},
+	"APF2": {Category: OptionCategoryAutopilot, Description: "Full Self-Driving Capability"},
+	"APPB": {Category: OptionCategoryAutopilot, Description: "Enhanced Autopilot"},
+
+	// Other
+	"AD15": {Category: OptionCategoryOther, Description: "Charging Adapter"},
+	"DRLH": {Category: OptionCategoryOther, Description: "Left-Hand Drive"},
+	"DRRH": {Category: OptionCategoryOther, Description: "Right-Hand Drive"},
+	"PF00": {Category: OptionCategoryOther, Description: "No Performance Package"},
+	"PF01": {Category: OptionCategoryOther, Description: "Performance Package"},
+	"SC04": {Category: OptionCategoryOther, Description: "Pay Per Use Supercharging"},
+	"SC05": {Category: OptionCategoryOther, Description: "Free Supercharging"},
+	"SU01": {Category: OptionCategoryOther, Description: "Standard Suspension"},
+	"SU03": {Category: OptionCategoryOther, Description: "Smart Air Suspension"},
+	"TM00": {Category: OptionCategoryOther, Description: "Standard Trim"},
+}
@@ -0,0 +1,108 @@
+package tesla
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupOptionCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		expected OptionCode
+		ok       bool
+	}{
+		{
+			name:     "known",
+			code:     "MDLS",
+			expected: OptionCode{Code: "MDLS", Category: OptionCategoryModel, Description: "Model S"},
+			ok:       true,
+		},
+		{
+			name:     "lower case and spaces",
+			code:     " pbsb ",
+			expected: OptionCode{Code: "PBSB", Category: OptionCategoryPaint, Description: "Solid Black"},
+			ok:       true,
+		},
+		{
+			name: "unknown",
+			code: "ZZZZ",
+		},
+		{
+			name: "empty",
+			code: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt, ok := LookupOptionCode(tt.code)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, opt)
+		})
+	}
+}
+
+func TestDecodeOptionCodes(t *testing.T) {
+	opts := DecodeOptionCodes("MDLS, rena,BT85,DV4W,PBSB,W39B,AD15,ZZZZ,,")
+
+	assert.Equal(t, "Model S", opts.Model.Description)
+	assert.Equal(t, "North America", opts.Region.Description)
+	assert.Equal(t, "RENA", opts.Region.Code)
+	assert.Equal(t, "85 kWh", opts.Battery.Description)
+	assert.Equal(t, "All-Wheel Drive", opts.Drive.Description)
+	assert.Equal(t, "Solid Black", opts.Paint.Description)
+	assert.Equal(t, "19\" Sport Wheels", opts.Wheels.Description)
+	assert.Equal(t, OptionCode{}, opts.Interior)
+	assert.Equal(t, OptionCode{}, opts.AutopilotHardware)
+
+	require.Len(t, opts.All, 8)
+	assert.Equal(t, OptionCode{Code: "AD15", Category: OptionCategoryOther, Description: "Charging Adapter"}, opts.All[6])
+	assert.Equal(t, OptionCode{Code: "ZZZZ", Category: OptionCategoryUnknown}, opts.All[7])
+	assert.Equal(t, []string{"ZZZZ"}, opts.Unknown)
+
+	assert.Equal(t, Options{}, DecodeOptionCodes(""))
+}
+
+func TestLoadOptionCodes(t *testing.T) {
+	optionCodesMu.Lock()
+	saved := make(map[string]OptionCode, len(optionCodes))
+	for code, opt := range optionCodes {
+		saved[code] = opt
+	}
+	optionCodesMu.Unlock()
+
+	defer func() {
+		optionCodesMu.Lock()
+		optionCodes = saved
+		optionCodesMu.Unlock()
+	}()
+
+	err := LoadOptionCodes(strings.NewReader(`{
+		"PBSB": {"category": "paint", "description": "Obsidian Black"},
+		" ppzz ": {"category": "paint", "description": "Test Paint"}
+	}`))
+	require.NoError(t, err)
+
+	opt, ok := LookupOptionCode("PBSB")
+	assert.True(t, ok)
+	assert.Equal(t, OptionCode{Code: "PBSB", Category: OptionCategoryPaint, Description: "Obsidian Black"}, opt)
+
+	opt, ok = LookupOptionCode("ppzz")
+	assert.True(t, ok)
+	assert.Equal(t, OptionCode{Code: "PPZZ", Category: OptionCategoryPaint, Description: "Test Paint"}, opt)
+
+	opts := DecodeOptionCodes("MDLS,PPZZ")
+	assert.Equal(t, "Test Paint", opts.Paint.Description)
+	assert.Empty(t, opts.Unknown)
+
+	err = LoadOptionCodes(strings.NewReader(`not json`))
+	assert.Error(t, err)
+
+	opt, ok = LookupOptionCode("MDLS")
+	assert.True(t, ok)
+	assert.Equal(t, "Model S", opt.Description)
+}
@@ -0,0 +1,169 @@
+// Package otelhooks records OpenTelemetry spans for the requests and streams made by a
+// tesla.Conn.
+//
+//	conn.AddHooks(otelhooks.New(otel.GetTracerProvider()).Hooks())
+//
+// The tesla package does not accept a context, so each request is recorded as a root span. It is
+// a separate module so that the tesla package does not depend on OpenTelemetry.
+package otelhooks
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rickbassham/tesla"
+)
+
+// InstrumentationName is the name of the tracer used to create spans.
+const InstrumentationName = "github.com/rickbassham/tesla/otelhooks"
+
+// Tracer records spans for tesla API calls.
+type Tracer struct {
+	tracer trace.Tracer
+
+	requests sync.Map // *tesla.RequestInfo → trace.Span
+
+	mu      sync.Mutex
+	streams map[int]*streamSpan
+}
+
+type streamSpan struct {
+	span     trace.Span
+	messages int
+}
+
+// New creates a tracer that creates spans with the given provider.
+func New(provider trace.TracerProvider) *Tracer {
+	return &Tracer{
+		tracer:  provider.Tracer(InstrumentationName),
+		streams: make(map[int]*streamSpan),
+	}
+}
+
+// Hooks returns hooks that record spans with the tracer.
+//
+// Each request is recorded as a span named "tesla <endpoint>", with the vehicle id, HTTP method
+// and status code, and retry count as attributes. Commands also record the result and reason
+// reported by the vehicle. Each stream connection is recorded as a span named "tesla stream" that
+// lasts until the connection ends, with the number of messages received as an attribute.
+func (t *Tracer) Hooks() tesla.Hooks {
+	return tesla.Hooks{
+		BeforeRequest:    t.beforeRequest,
+		AfterRequest:     t.afterRequest,
+		CommandResult:    t.commandResult,
+		StreamConnect:    t.streamConnect,
+		StreamDisconnect: t.streamDisconnect,
+		StreamMessage:    t.streamMessage,
+	}
+}
+
+func (t *Tracer) beforeRequest(info *tesla.RequestInfo) {
+	attrs := []attribute.KeyValue{
+		attribute.String("tesla.endpoint", info.Endpoint),
+		attribute.String("http.request.method", info.Method),
+		attribute.Int("tesla.retries", info.Retries),
+	}
+
+	if info.VehicleID != 0 {
+		attrs = append(attrs, attribute.Int("tesla.vehicle_id", info.VehicleID))
+	}
+
+	_, span := t.tracer.Start(context.Background(), "tesla "+info.Endpoint,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithTimestamp(info.Start),
+		trace.WithAttributes(attrs...),
+	)
+
+	t.requests.Store(info, span)
+}
+
+func (t *Tracer) afterRequest(info *tesla.RequestInfo, result tesla.RequestResult) {
+	val, ok := t.requests.Load(info)
+	if !ok {
+		return
+	}
+
+	t.requests.Delete(info)
+
+	span := val.(trace.Span)
+
+	if result.StatusCode != 0 {
+		span.SetAttributes(attribute.Int("http.response.status_code", result.StatusCode))
+	}
+
+	if result.Err != nil {
+		span.RecordError(result.Err)
+		span.SetStatus(codes.Error, result.Err.Error())
+	}
+
+	span.End(trace.WithTimestamp(info.Start.Add(result.Duration)))
+}
+
+func (t *Tracer) commandResult(info *tesla.RequestInfo, result bool, reason string) {
+	val, ok := t.requests.Load(info)
+	if !ok {
+		return
+	}
+
+	val.(trace.Span).SetAttributes(
+		attribute.Bool("tesla.command.result", result),
+		attribute.String("tesla.command.reason", reason),
+	)
+}
+
+func (t *Tracer) streamConnect(vehicleID int, err error) {
+	_, span := t.tracer.Start(context.Background(), "tesla stream",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("tesla.vehicle_id", vehicleID)),
+	)
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.streams[vehicleID]; ok {
+		prev.span.End()
+	}
+
+	t.streams[vehicleID] = &streamSpan{span: span}
+}
+
+func (t *Tracer) streamDisconnect(vehicleID int, err error) {
+	t.mu.Lock()
+	s, ok := t.streams[vehicleID]
+	delete(t.streams, vehicleID)
+	t.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	s.span.SetAttributes(attribute.Int("tesla.stream.messages", s.messages))
+
+	if err != nil {
+		s.span.RecordError(err)
+		s.span.SetStatus(codes.Error, err.Error())
+	}
+
+	s.span.End()
+}
+
+func (t *Tracer) streamMessage(vehicleID int, msg tesla.StreamingMessage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s, ok := t.streams[vehicleID]; ok {
+		s.messages++
+	}
+}
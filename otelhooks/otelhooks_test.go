@@ -0,0 +1,59 @@
+package otelhooks_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/rickbassham/tesla/otelhooks"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func TestTracer(t *testing.T) {
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	v := srv.AddVehicle(1, "5YJSA1E27HF000001")
+	v.Lock()
+	v.CommandResults = map[string]teslatest.CommandResult{
+		"door_lock": {Result: false, Reason: "user_present"},
+	}
+	v.Unlock()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	conn := srv.Conn()
+	conn.AddHooks(otelhooks.New(provider).Hooks())
+
+	require.NoError(t, conn.Authenticate(teslatest.Email, teslatest.Password))
+
+	srv.Fail("/charge_state", http.StatusTooManyRequests)
+
+	_, err := conn.GetChargeState(1)
+	require.Error(t, err)
+
+	require.Error(t, conn.LockDoors(1))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	assert.Equal(t, "tesla oauth/token", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+	assert.Equal(t, "tesla charge_state", spans[1].Name())
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), attribute.Int("tesla.vehicle_id", 1))
+	assert.Contains(t, spans[1].Attributes(), attribute.Int("http.response.status_code", http.StatusTooManyRequests))
+
+	assert.Equal(t, "tesla command/door_lock", spans[2].Name())
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+	assert.Contains(t, spans[2].Attributes(), attribute.Bool("tesla.command.result", false))
+	assert.Contains(t, spans[2].Attributes(), attribute.String("tesla.command.reason", "user_present"))
+}
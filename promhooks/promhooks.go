@@ -0,0 +1,144 @@
+// Package promhooks collects Prometheus metrics for the requests and streams made by a tesla.Conn.
+//
+//	collector := promhooks.NewCollector()
+//	prometheus.MustRegister(collector)
+//	conn.AddHooks(collector.Hooks())
+//
+// It is a separate module so that the tesla package does not depend on the Prometheus client.
+package promhooks
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/rickbassham/tesla"
+)
+
+// Collector is a prometheus.Collector for tesla API metrics.
+type Collector struct {
+	requests          *prometheus.CounterVec
+	duration          *prometheus.HistogramVec
+	retries           *prometheus.CounterVec
+	commands          *prometheus.CounterVec
+	streamConnects    *prometheus.CounterVec
+	streamDisconnects *prometheus.CounterVec
+	streamMessages    *prometheus.CounterVec
+	streamsActive     prometheus.Gauge
+}
+
+// NewCollector creates a new collector. Register it with a prometheus.Registerer and add its
+// Hooks to one or more connections.
+func NewCollector() *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_requests_total",
+			Help: "Requests made to the Tesla API, by endpoint and HTTP status code.",
+		}, []string{"endpoint", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tesla_request_duration_seconds",
+			Help:    "Latency of requests made to the Tesla API, by endpoint.",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}, []string{"endpoint"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_request_retries_total",
+			Help: "Requests to the Tesla API that were retries of an earlier attempt, by endpoint.",
+		}, []string{"endpoint"}),
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_commands_total",
+			Help: "Commands sent to vehicles, by command and whether the vehicle reported success.",
+		}, []string{"command", "result"}),
+		streamConnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_stream_connects_total",
+			Help: "Attempts to connect to the streaming API, by outcome.",
+		}, []string{"result"}),
+		streamDisconnects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_stream_disconnects_total",
+			Help: "Streaming API connections that ended, by whether they ended in an error.",
+		}, []string{"result"}),
+		streamMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tesla_stream_messages_total",
+			Help: "Messages received from the streaming API, by vehicle.",
+		}, []string{"vehicle_id"}),
+		streamsActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "tesla_streams_active",
+			Help: "Streaming API connections currently open.",
+		}),
+	}
+}
+
+func (c *Collector) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.requests,
+		c.duration,
+		c.retries,
+		c.commands,
+		c.streamConnects,
+		c.streamDisconnects,
+		c.streamMessages,
+		c.streamsActive,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c.collectors() {
+		collector.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c.collectors() {
+		collector.Collect(ch)
+	}
+}
+
+// Hooks returns hooks that record metrics in the collector.
+func (c *Collector) Hooks() tesla.Hooks {
+	return tesla.Hooks{
+		BeforeRequest: func(info *tesla.RequestInfo) {
+			if info.Retries > 0 {
+				c.retries.WithLabelValues(info.Endpoint).Inc()
+			}
+		},
+		AfterRequest: func(info *tesla.RequestInfo, result tesla.RequestResult) {
+			c.requests.WithLabelValues(info.Endpoint, statusLabel(result.StatusCode)).Inc()
+			c.duration.WithLabelValues(info.Endpoint).Observe(result.Duration.Seconds())
+		},
+		CommandResult: func(info *tesla.RequestInfo, result bool, reason string) {
+			c.commands.WithLabelValues(info.Endpoint, strconv.FormatBool(result)).Inc()
+		},
+		StreamConnect: func(vehicleID int, err error) {
+			c.streamConnects.WithLabelValues(errorLabel(err)).Inc()
+
+			if err == nil {
+				c.streamsActive.Inc()
+			}
+		},
+		StreamDisconnect: func(vehicleID int, err error) {
+			c.streamDisconnects.WithLabelValues(errorLabel(err)).Inc()
+			c.streamsActive.Dec()
+		},
+		StreamMessage: func(vehicleID int, msg tesla.StreamingMessage) {
+			c.streamMessages.WithLabelValues(strconv.Itoa(vehicleID)).Inc()
+		},
+	}
+}
+
+// statusLabel returns the status code as a label, or "error" if no response was received.
+func statusLabel(statusCode int) string {
+	if statusCode == 0 {
+		return "error"
+	}
+
+	return strconv.Itoa(statusCode)
+}
+
+func errorLabel(err error) string {
+	if err != nil {
+		return "error"
+	}
+
+	return "ok"
+}
@@ -0,0 +1,59 @@
+package promhooks_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla/promhooks"
+	"github.com/rickbassham/tesla/teslatest"
+)
+
+func TestCollector(t *testing.T) {
+	srv := teslatest.NewServer()
+	defer srv.Close()
+
+	srv.AddVehicle(1, "5YJSA1E27HF000001")
+
+	collector := promhooks.NewCollector()
+
+	registry := prometheus.NewPedanticRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	conn := srv.Conn()
+	conn.AddHooks(collector.Hooks())
+
+	require.NoError(t, conn.Authenticate(teslatest.Email, teslatest.Password))
+
+	_, err := conn.GetChargeState(1)
+	require.NoError(t, err)
+
+	srv.Fail("/charge_state", http.StatusTooManyRequests)
+
+	_, err = conn.GetChargeState(1)
+	require.Error(t, err)
+
+	require.NoError(t, conn.LockDoors(1))
+
+	expected := `
+# HELP tesla_commands_total Commands sent to vehicles, by command and whether the vehicle reported success.
+# TYPE tesla_commands_total counter
+tesla_commands_total{command="command/door_lock",result="true"} 1
+# HELP tesla_requests_total Requests made to the Tesla API, by endpoint and HTTP status code.
+# TYPE tesla_requests_total counter
+tesla_requests_total{endpoint="charge_state",status="200"} 1
+tesla_requests_total{endpoint="charge_state",status="429"} 1
+tesla_requests_total{endpoint="command/door_lock",status="200"} 1
+tesla_requests_total{endpoint="oauth/token",status="200"} 1
+`
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "tesla_requests_total", "tesla_commands_total")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 3, testutil.CollectAndCount(collector, "tesla_request_duration_seconds"))
+}
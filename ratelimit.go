@@ -0,0 +1,257 @@
+package tesla
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket budget. Requests are allowed at Rate per second on average, with
+// bursts of up to Burst requests. A zero Rate means no limit.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// Every returns a RateLimit allowing one request per interval, with the given burst.
+func Every(interval time.Duration, burst int) RateLimit {
+	if interval <= 0 {
+		return RateLimit{}
+	}
+
+	return RateLimit{Rate: float64(time.Second) / float64(interval), Burst: burst}
+}
+
+// RateLimits are the budgets for each kind of request.
+type RateLimits struct {
+	// Data limits requests that read data, including vehicle lists and state.
+	Data RateLimit
+	// Commands limits commands sent to vehicles.
+	Commands RateLimit
+	// WakeUps limits requests to wake vehicles.
+	WakeUps RateLimit
+}
+
+// requestKind is the budget a request is charged to.
+type requestKind int
+
+const (
+	kindData requestKind = iota
+	kindCommand
+	kindWakeUp
+)
+
+func kindOf(endpoint string) requestKind {
+	switch {
+	case endpoint == "wake_up":
+		return kindWakeUp
+	case strings.HasPrefix(endpoint, "command/"):
+		return kindCommand
+	default:
+		return kindData
+	}
+}
+
+func (limits RateLimits) forKind(kind requestKind) RateLimit {
+	switch kind {
+	case kindCommand:
+		return limits.Commands
+	case kindWakeUp:
+		return limits.WakeUps
+	default:
+		return limits.Data
+	}
+}
+
+// SetRateLimits sets the budgets shared by every request made with the connection. Requests that
+// would exceed a budget wait until it allows them.
+//
+// Once rate limits are set, a 429 response also pauses every request until the time given by its
+// Retry-After header, or for a short backoff if it has none, and the request is retried up to
+// maxRetries times. Tesla throttles by account, so share one Conn between everything using the
+// same account.
+func (c *Conn) SetRateLimits(limits RateLimits, maxRetries int) {
+	l := c.rateLimiter()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.account = limits
+	l.maxRetries = maxRetries
+	l.resetBuckets(0)
+}
+
+// SetVehicleRateLimits sets budgets for requests for a single vehicle. They apply in addition to
+// the budgets set with SetRateLimits.
+func (c *Conn) SetVehicleRateLimits(id int, limits RateLimits) {
+	l := c.rateLimiter()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.vehicles[id] = limits
+	l.resetBuckets(id)
+}
+
+func (c *Conn) rateLimiter() *rateLimiter {
+	c.limiterOnce.Do(func() {
+		c.limiter = newRateLimiter()
+	})
+
+	return c.limiter
+}
+
+type bucketKey struct {
+	vehicleID int
+	kind      requestKind
+}
+
+// bucket is a token bucket. Tokens may go negative, in which case the holder of the last token
+// must wait until the bucket refills to zero before using it.
+type bucket struct {
+	limit  RateLimit
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes a token and returns how long the caller must wait before using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	if b.limit.Rate <= 0 {
+		return 0
+	}
+
+	burst := math.Max(1, float64(b.limit.Burst))
+
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if now.After(b.last) {
+		b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*b.limit.Rate)
+	}
+
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.limit.Rate * float64(time.Second))
+}
+
+// defaultRetryAfter is how long to pause after a 429 response without a Retry-After header. It
+// doubles with each retry.
+const defaultRetryAfter = time.Second
+
+type rateLimiter struct {
+	mu sync.Mutex
+
+	account    RateLimits
+	vehicles   map[int]RateLimits
+	maxRetries int
+
+	buckets     map[bucketKey]*bucket
+	pausedUntil time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		vehicles: make(map[int]RateLimits),
+		buckets:  make(map[bucketKey]*bucket),
+		now:      time.Now,
+		sleep:    time.Sleep,
+	}
+}
+
+func (l *rateLimiter) resetBuckets(vehicleID int) {
+	for key := range l.buckets {
+		if key.vehicleID == vehicleID {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func (l *rateLimiter) bucket(key bucketKey, limit RateLimit) *bucket {
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limit: limit}
+		l.buckets[key] = b
+	}
+
+	return b
+}
+
+// delay reserves the tokens for a request and returns how long to wait before sending it.
+func (l *rateLimiter) delay(info *RequestInfo) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	kind := kindOf(info.Endpoint)
+
+	wait := l.pausedUntil.Sub(now)
+
+	if d := l.bucket(bucketKey{0, kind}, l.account.forKind(kind)).reserve(now); d > wait {
+		wait = d
+	}
+
+	if limits, ok := l.vehicles[info.VehicleID]; ok && info.VehicleID != 0 {
+		if d := l.bucket(bucketKey{info.VehicleID, kind}, limits.forKind(kind)).reserve(now); d > wait {
+			wait = d
+		}
+	}
+
+	if wait < 0 {
+		return 0
+	}
+
+	return wait
+}
+
+func (l *rateLimiter) wait(info *RequestInfo) {
+	if d := l.delay(info); d > 0 {
+		l.sleep(d)
+	}
+}
+
+// throttled records a 429 response. It returns true if the request should be retried.
+func (l *rateLimiter) throttled(info *RequestInfo, raw *RawResponse) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	until, ok := retryAfter(raw.Header, now)
+	if !ok {
+		until = now.Add(defaultRetryAfter << uint(info.Retries))
+	}
+
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+
+	return info.Retries < l.maxRetries
+}
+
+// retryAfter parses the Retry-After header, which is either a number of seconds or an HTTP date.
+func retryAfter(header http.Header, now time.Time) (time.Time, bool) {
+	val := strings.TrimSpace(header.Get("Retry-After"))
+	if val == "" {
+		return time.Time{}, false
+	}
+
+	if secs, err := strconv.Atoi(val); err == nil {
+		return now.Add(time.Duration(secs) * time.Second), true
+	}
+
+	if t, err := http.ParseTime(val); err == nil {
+		return t, true
+	}
+
+	return time.Time{}, false
+}
@@ -0,0 +1,93 @@
+package tesla
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now   time.Time
+	slept []time.Duration
+}
+
+func (c *fakeClock) sleep(d time.Duration) {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+}
+
+func newTestLimiter(clock *fakeClock) *rateLimiter {
+	l := newRateLimiter()
+	l.now = func() time.Time { return clock.now }
+	l.sleep = clock.sleep
+
+	return l
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1580000000, 0)}
+
+	l := newTestLimiter(clock)
+	l.account = RateLimits{
+		Data:     Every(time.Second, 2),
+		Commands: Every(10*time.Second, 1),
+	}
+	l.vehicles[1] = RateLimits{WakeUps: Every(time.Minute, 1)}
+
+	data := &RequestInfo{Endpoint: "charge_state", VehicleID: 1}
+	command := &RequestInfo{Endpoint: "command/door_lock", VehicleID: 1}
+	wake := &RequestInfo{Endpoint: "wake_up", VehicleID: 1}
+	otherWake := &RequestInfo{Endpoint: "wake_up", VehicleID: 2}
+
+	// The burst is allowed immediately, then one request per interval.
+	assert.Equal(t, time.Duration(0), l.delay(data))
+	assert.Equal(t, time.Duration(0), l.delay(data))
+	assert.Equal(t, time.Second, l.delay(data))
+	assert.Equal(t, 2*time.Second, l.delay(data))
+
+	// Each kind has its own budget.
+	assert.Equal(t, time.Duration(0), l.delay(command))
+	assert.Equal(t, 10*time.Second, l.delay(command))
+
+	// Vehicle budgets only apply to their vehicle.
+	assert.Equal(t, time.Duration(0), l.delay(wake))
+	assert.Equal(t, time.Minute, l.delay(wake))
+	assert.Equal(t, time.Duration(0), l.delay(otherWake))
+	assert.Equal(t, time.Duration(0), l.delay(otherWake))
+
+	// Tokens are refilled over time.
+	clock.now = clock.now.Add(time.Hour)
+	assert.Equal(t, time.Duration(0), l.delay(data))
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1580000000, 0)}
+
+	l := newTestLimiter(clock)
+	l.maxRetries = 1
+
+	info := &RequestInfo{Endpoint: "charge_state", VehicleID: 1}
+
+	raw := &RawResponse{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+	}
+
+	assert.True(t, l.throttled(info, raw))
+
+	l.wait(&RequestInfo{Endpoint: "command/door_lock", VehicleID: 2})
+	assert.Equal(t, []time.Duration{30 * time.Second}, clock.slept)
+
+	raw.Header.Set("Retry-After", clock.now.Add(time.Minute).UTC().Format(http.TimeFormat))
+
+	assert.False(t, l.throttled(&RequestInfo{Endpoint: "charge_state", Retries: 1}, raw))
+	assert.Equal(t, time.Minute, l.delay(info))
+
+	// Without a Retry-After header, the pause doubles with each retry.
+	clock.now = clock.now.Add(time.Hour)
+
+	assert.True(t, l.throttled(&RequestInfo{Endpoint: "vehicles"}, &RawResponse{Header: http.Header{}}))
+	assert.Equal(t, defaultRetryAfter, l.delay(info))
+}
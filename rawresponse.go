@@ -0,0 +1,21 @@
+package tesla
+
+import (
+	"net/http"
+	"time"
+)
+
+// RawResponse is the HTTP response a typed value was decoded from. It is returned by the Raw
+// variants of the getters, such as GetChargeStateRaw, for debugging and auditing.
+type RawResponse struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Header holds the response headers.
+	Header http.Header
+	// Body is the exact response body, before decoding.
+	Body []byte
+	// Start is when the request was sent.
+	Start time.Time
+	// Duration is how long the request took, including reading the response body.
+	Duration time.Duration
+}
@@ -0,0 +1,113 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// migrations are applied in order to bring a database up to date. The schema version is the
+// number applied, kept in SQLite's user_version. Never edit a released migration; append a new
+// one.
+var migrations = []string{
+	`
+	CREATE TABLE samples (
+		vehicle_id  INTEGER NOT NULL,
+		time        INTEGER NOT NULL,
+		speed       INTEGER NOT NULL,
+		odometer    REAL    NOT NULL,
+		soc         INTEGER NOT NULL,
+		elevation   INTEGER NOT NULL,
+		est_heading INTEGER NOT NULL,
+		est_lat     REAL    NOT NULL,
+		est_lng     REAL    NOT NULL,
+		power       INTEGER NOT NULL,
+		shift_state INTEGER NOT NULL,
+		range       INTEGER NOT NULL,
+		est_range   INTEGER NOT NULL,
+		heading     INTEGER NOT NULL,
+		PRIMARY KEY (vehicle_id, time)
+	) WITHOUT ROWID;
+
+	CREATE INDEX samples_time ON samples (time);
+
+	CREATE TABLE snapshots (
+		vehicle_id INTEGER NOT NULL,
+		kind       TEXT    NOT NULL,
+		time       INTEGER NOT NULL,
+		data       TEXT    NOT NULL,
+		PRIMARY KEY (vehicle_id, kind, time)
+	) WITHOUT ROWID;
+
+	CREATE INDEX snapshots_time ON snapshots (time);
+
+	CREATE TABLE trips (
+		id             INTEGER PRIMARY KEY AUTOINCREMENT,
+		vehicle_id     INTEGER NOT NULL,
+		start_time     INTEGER NOT NULL,
+		end_time       INTEGER NOT NULL,
+		start_odometer REAL    NOT NULL,
+		end_odometer   REAL    NOT NULL,
+		start_soc      INTEGER NOT NULL,
+		end_soc        INTEGER NOT NULL,
+		start_lat      REAL    NOT NULL,
+		start_lng      REAL    NOT NULL,
+		end_lat        REAL    NOT NULL,
+		end_lng        REAL    NOT NULL,
+		max_speed      INTEGER NOT NULL
+	);
+
+	CREATE INDEX trips_vehicle_time ON trips (vehicle_id, start_time);
+
+	CREATE TABLE charging_sessions (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		vehicle_id   INTEGER NOT NULL,
+		start_time   INTEGER NOT NULL,
+		end_time     INTEGER NOT NULL,
+		start_soc    INTEGER NOT NULL,
+		end_soc      INTEGER NOT NULL,
+		energy_added REAL    NOT NULL,
+		max_power    INTEGER NOT NULL,
+		latitude     REAL    NOT NULL,
+		longitude    REAL    NOT NULL
+	);
+
+	CREATE INDEX charging_sessions_vehicle_time ON charging_sessions (vehicle_id, start_time);
+	`,
+}
+
+// migrate applies the migrations the database has not yet had, each in its own transaction.
+func migrate(db *sql.DB) error {
+	var version int
+
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("error reading schema version: %w", err)
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return fmt.Errorf("error migrating to version %d: %w", i+1, err)
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error migrating to version %d: %w", i+1, err)
+		}
+
+		// PRAGMA does not accept bound parameters.
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error migrating to version %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error migrating to version %d: %w", i+1, err)
+		}
+	}
+
+	return nil
+}
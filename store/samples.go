@@ -0,0 +1,141 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+const sampleColumns = `time, speed, odometer, soc, elevation, est_heading, est_lat, est_lng, power, shift_state, range, est_range, heading`
+
+// AddSamples stores streaming messages received for a vehicle. A message with the same timestamp
+// as one already stored replaces it.
+func (s *Store) AddSamples(vehicleID int, msgs ...tesla.StreamingMessage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error adding samples: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO samples (vehicle_id, ` + sampleColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("error adding samples: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, msg := range msgs {
+		_, err := stmt.Exec(vehicleID, millis(msg.Timestamp), msg.Speed, msg.Odometer, msg.SOC, msg.Elevation,
+			msg.EstHeading, msg.EstLatitude, msg.EstLongitude, msg.Power, msg.ShiftState, msg.Range, msg.EstRange,
+			msg.Heading)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error adding samples: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error adding samples: %w", err)
+	}
+
+	return nil
+}
+
+// Samples returns the vehicle's samples from from up to, but not including, to, oldest first.
+func (s *Store) Samples(vehicleID int, from, to time.Time) ([]tesla.StreamingMessage, error) {
+	rows, err := s.db.Query(`SELECT `+sampleColumns+` FROM samples
+		WHERE vehicle_id = ? AND time >= ? AND time < ? ORDER BY time`,
+		vehicleID, millis(from), millis(to))
+	if err != nil {
+		return nil, fmt.Errorf("error querying samples: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []tesla.StreamingMessage
+
+	for rows.Next() {
+		msg, err := scanSample(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		msgs = append(msgs, msg)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying samples: %w", err)
+	}
+
+	return msgs, nil
+}
+
+// LatestSample returns the vehicle's most recent sample, or ErrNotFound if it has none.
+func (s *Store) LatestSample(vehicleID int) (*tesla.StreamingMessage, error) {
+	row := s.db.QueryRow(`SELECT `+sampleColumns+` FROM samples
+		WHERE vehicle_id = ? ORDER BY time DESC LIMIT 1`, vehicleID)
+
+	msg, err := scanSample(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &msg, nil
+}
+
+// LatestSamples returns the most recent sample of every vehicle, by vehicle id.
+func (s *Store) LatestSamples() (map[int]tesla.StreamingMessage, error) {
+	rows, err := s.db.Query(`SELECT vehicle_id, ` + sampleColumns + ` FROM samples
+		WHERE (vehicle_id, time) IN (SELECT vehicle_id, MAX(time) FROM samples GROUP BY vehicle_id)`)
+	if err != nil {
+		return nil, fmt.Errorf("error querying samples: %w", err)
+	}
+	defer rows.Close()
+
+	latest := make(map[int]tesla.StreamingMessage)
+
+	for rows.Next() {
+		var id int
+
+		msg, err := scanSample(rows, &id)
+		if err != nil {
+			return nil, err
+		}
+
+		latest[id] = msg
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying samples: %w", err)
+	}
+
+	return latest, nil
+}
+
+// scanner is a *sql.Row or *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSample scans the sample columns, after any columns scanned into dest.
+func scanSample(row scanner, dest ...interface{}) (tesla.StreamingMessage, error) {
+	var (
+		ms  int64
+		msg tesla.StreamingMessage
+	)
+
+	err := row.Scan(append(dest, &ms, &msg.Speed, &msg.Odometer, &msg.SOC, &msg.Elevation, &msg.EstHeading,
+		&msg.EstLatitude, &msg.EstLongitude, &msg.Power, &msg.ShiftState, &msg.Range, &msg.EstRange, &msg.Heading)...)
+	if err == sql.ErrNoRows {
+		return msg, fmt.Errorf("%w", ErrNotFound)
+	}
+
+	if err != nil {
+		return msg, fmt.Errorf("error reading sample: %w", err)
+	}
+
+	msg.Timestamp = fromMillis(ms)
+
+	return msg, nil
+}
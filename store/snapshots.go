@@ -0,0 +1,144 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// The kinds of state snapshot, named as in the owner's API.
+const (
+	KindChargeState   = "charge_state"
+	KindClimateState  = "climate_state"
+	KindDriveState    = "drive_state"
+	KindGUISettings   = "gui_settings"
+	KindVehicleConfig = "vehicle_config"
+	KindVehicleState  = "vehicle_state"
+)
+
+// Snapshot is a stored vehicle state.
+type Snapshot struct {
+	VehicleID int
+	Kind      string
+	Time      time.Time
+	// Data is the state as JSON, including any fields the tesla package does not decode.
+	Data json.RawMessage
+}
+
+// Decode decodes the state into v, which should be a pointer to the state type for the kind.
+func (s *Snapshot) Decode(v interface{}) error {
+	if err := json.Unmarshal(s.Data, v); err != nil {
+		return fmt.Errorf("error decoding %s snapshot: %w", s.Kind, err)
+	}
+
+	return nil
+}
+
+// kindOf returns the kind of snapshot for a state.
+func kindOf(state interface{}) (string, error) {
+	switch state.(type) {
+	case *tesla.ChargeState, tesla.ChargeState:
+		return KindChargeState, nil
+	case *tesla.ClimateState, tesla.ClimateState:
+		return KindClimateState, nil
+	case *tesla.DriveState, tesla.DriveState:
+		return KindDriveState, nil
+	case *tesla.GUISettings, tesla.GUISettings:
+		return KindGUISettings, nil
+	case *tesla.VehicleConfig, tesla.VehicleConfig:
+		return KindVehicleConfig, nil
+	case *tesla.VehicleState, tesla.VehicleState:
+		return KindVehicleState, nil
+	}
+
+	return "", fmt.Errorf("unsupported snapshot type %T", state)
+}
+
+// AddSnapshot stores a state of the vehicle, such as a *tesla.ChargeState, as of t.
+func (s *Store) AddSnapshot(vehicleID int, t time.Time, state interface{}) error {
+	kind, err := kindOf(state)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("error encoding %s snapshot: %w", kind, err)
+	}
+
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO snapshots (vehicle_id, kind, time, data) VALUES (?, ?, ?, ?)`,
+		vehicleID, kind, millis(t), string(data))
+	if err != nil {
+		return fmt.Errorf("error adding %s snapshot: %w", kind, err)
+	}
+
+	return nil
+}
+
+// Snapshots returns the vehicle's snapshots of a kind from from up to, but not including, to,
+// oldest first.
+func (s *Store) Snapshots(vehicleID int, kind string, from, to time.Time) ([]Snapshot, error) {
+	rows, err := s.db.Query(`SELECT vehicle_id, kind, time, data FROM snapshots
+		WHERE vehicle_id = ? AND kind = ? AND time >= ? AND time < ? ORDER BY time`,
+		vehicleID, kind, millis(from), millis(to))
+	if err != nil {
+		return nil, fmt.Errorf("error querying snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snapshots []Snapshot
+
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// LatestSnapshot returns the vehicle's most recent snapshot of a kind, or ErrNotFound if it has
+// none.
+func (s *Store) LatestSnapshot(vehicleID int, kind string) (*Snapshot, error) {
+	row := s.db.QueryRow(`SELECT vehicle_id, kind, time, data FROM snapshots
+		WHERE vehicle_id = ? AND kind = ? ORDER BY time DESC LIMIT 1`, vehicleID, kind)
+
+	snapshot, err := scanSnapshot(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &snapshot, nil
+}
+
+func scanSnapshot(row scanner) (Snapshot, error) {
+	var (
+		snapshot Snapshot
+		ms       int64
+		data     string
+	)
+
+	err := row.Scan(&snapshot.VehicleID, &snapshot.Kind, &ms, &data)
+	if err == sql.ErrNoRows {
+		return snapshot, fmt.Errorf("%w", ErrNotFound)
+	}
+
+	if err != nil {
+		return snapshot, fmt.Errorf("error reading snapshot: %w", err)
+	}
+
+	snapshot.Time = fromMillis(ms)
+	snapshot.Data = json.RawMessage(data)
+
+	return snapshot, nil
+}
@@ -0,0 +1,115 @@
+// Package store persists vehicle telemetry in an embedded SQLite database: streaming samples,
+// state snapshots, trips, and charging sessions.
+//
+//	s, err := store.Open("tesla.db")
+//	...
+//	err = s.AddSamples(id, msg)
+//	samples, err := s.Samples(id, time.Now().Add(-time.Hour), time.Now())
+//
+// The database is migrated to the current schema when opened. Old data is removed with Prune.
+// It uses a pure-Go SQLite driver, so it needs no C toolchain, and is a separate module so that
+// the tesla package does not depend on it.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	// Registers the "sqlite" driver.
+	_ "modernc.org/sqlite"
+)
+
+// ErrNotFound is returned by the Latest methods when there is no matching record.
+var ErrNotFound = errors.New("not found")
+
+// Store is a telemetry database. It is safe for concurrent use.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// Open opens the database at path, creating it if needed, and migrates it to the current schema.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %w", err)
+	}
+
+	// SQLite allows a single writer; serializing on one connection avoids "database is locked"
+	// errors, and keeps in-memory databases from being opened once per connection.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA journal_mode = WAL; PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error configuring database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{db: db, now: time.Now}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Retention is how long each kind of record is kept. Zero keeps records forever.
+type Retention struct {
+	Samples          time.Duration
+	Snapshots        time.Duration
+	Trips            time.Duration
+	ChargingSessions time.Duration
+}
+
+// Prune deletes the records older than the retention allows, and returns how many were deleted.
+// Trips and charging sessions are aged by when they ended.
+func (s *Store) Prune(r Retention) (int64, error) {
+	now := s.now()
+
+	tables := []struct {
+		keep   time.Duration
+		delete string
+	}{
+		{r.Samples, "DELETE FROM samples WHERE time < ?"},
+		{r.Snapshots, "DELETE FROM snapshots WHERE time < ?"},
+		{r.Trips, "DELETE FROM trips WHERE end_time < ?"},
+		{r.ChargingSessions, "DELETE FROM charging_sessions WHERE end_time < ?"},
+	}
+
+	var deleted int64
+
+	for _, table := range tables {
+		if table.keep <= 0 {
+			continue
+		}
+
+		res, err := s.db.Exec(table.delete, millis(now.Add(-table.keep)))
+		if err != nil {
+			return deleted, fmt.Errorf("error pruning: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return deleted, fmt.Errorf("error pruning: %w", err)
+		}
+
+		deleted += n
+	}
+
+	return deleted, nil
+}
+
+// millis converts a time to the Unix milliseconds stored in the database.
+func millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func fromMillis(ms int64) time.Time {
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+)
+
+var t0 = time.Unix(1580000000, 0)
+
+func openTemp(t *testing.T) (*Store, string, func()) {
+	dir, err := ioutil.TempDir("", "tesla-store")
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "tesla.db")
+
+	s, err := Open(path)
+	require.NoError(t, err)
+
+	return s, path, func() {
+		s.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	s, path, cleanup := openTemp(t)
+	defer cleanup()
+
+	require.NoError(t, s.AddSamples(1, tesla.StreamingMessage{Timestamp: t0, Speed: 30}))
+	require.NoError(t, s.Close())
+
+	s, err := Open(path)
+	require.NoError(t, err, "reopening a migrated database")
+
+	var version int
+	require.NoError(t, s.db.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, len(migrations), version)
+
+	msg, err := s.LatestSample(1)
+	require.NoError(t, err)
+	assert.Equal(t, 30, msg.Speed, "data survives reopening")
+
+	_, err = s.db.Exec("PRAGMA user_version = 999")
+	require.NoError(t, err)
+	require.NoError(t, s.Close())
+
+	_, err = Open(path)
+	assert.EqualError(t, err, "database schema version 999 is newer than supported version 1")
+}
+
+func TestSamples(t *testing.T) {
+	s, _, cleanup := openTemp(t)
+	defer cleanup()
+
+	_, err := s.LatestSample(1)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	var msgs []tesla.StreamingMessage
+
+	for i := 0; i < 5; i++ {
+		msgs = append(msgs, tesla.StreamingMessage{
+			Timestamp:    t0.Add(time.Duration(i) * time.Second),
+			Speed:        10 * i,
+			Odometer:     12345.6 + float64(i)/10,
+			SOC:          70,
+			Elevation:    120,
+			EstLatitude:  37.4,
+			EstLongitude: -122.1,
+			Power:        20,
+			ShiftState:   4,
+		})
+	}
+
+	require.NoError(t, s.AddSamples(1, msgs...))
+	require.NoError(t, s.AddSamples(2, tesla.StreamingMessage{Timestamp: t0, Speed: 99}))
+
+	got, err := s.Samples(1, t0.Add(time.Second), t0.Add(4*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, msgs[1:4], got)
+
+	latest, err := s.LatestSample(1)
+	require.NoError(t, err)
+	assert.Equal(t, msgs[4], *latest)
+
+	all, err := s.LatestSamples()
+	require.NoError(t, err)
+	assert.Equal(t, map[int]tesla.StreamingMessage{
+		1: msgs[4],
+		2: {Timestamp: t0, Speed: 99},
+	}, all)
+
+	msgs[4].Speed = 45
+	require.NoError(t, s.AddSamples(1, msgs[4]))
+
+	latest, err = s.LatestSample(1)
+	require.NoError(t, err)
+	assert.Equal(t, 45, latest.Speed, "a sample with the same time replaces the old one")
+}
+
+func TestSnapshots(t *testing.T) {
+	s, _, cleanup := openTemp(t)
+	defer cleanup()
+
+	require.NoError(t, s.AddSnapshot(1, t0, &tesla.ChargeState{BatteryLevel: 60, ChargingState: tesla.ChargingStateCharging}))
+	require.NoError(t, s.AddSnapshot(1, t0.Add(time.Minute), &tesla.ChargeState{BatteryLevel: 61}))
+	require.NoError(t, s.AddSnapshot(1, t0, tesla.ClimateState{InsideTemp: 20}))
+
+	assert.EqualError(t, s.AddSnapshot(1, t0, "charge"), "unsupported snapshot type string")
+
+	snapshots, err := s.Snapshots(1, KindChargeState, t0, t0.Add(time.Hour))
+	require.NoError(t, err)
+	require.Len(t, snapshots, 2)
+
+	var state tesla.ChargeState
+	require.NoError(t, snapshots[0].Decode(&state))
+	assert.Equal(t, 60, state.BatteryLevel)
+	assert.Equal(t, tesla.ChargingStateCharging, state.ChargingState)
+	assert.Equal(t, t0, snapshots[0].Time)
+
+	latest, err := s.LatestSnapshot(1, KindClimateState)
+	require.NoError(t, err)
+
+	var climate tesla.ClimateState
+	require.NoError(t, latest.Decode(&climate))
+	assert.Equal(t, 20.0, climate.InsideTemp)
+
+	_, err = s.LatestSnapshot(1, KindDriveState)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestTrips(t *testing.T) {
+	s, _, cleanup := openTemp(t)
+	defer cleanup()
+
+	trip := NewTrip(1, []tesla.StreamingMessage{
+		{Timestamp: t0, Odometer: 100, SOC: 80, EstLatitude: 37.4, EstLongitude: -122.1},
+		{Timestamp: t0.Add(10 * time.Minute), Odometer: 105, SOC: 78, Speed: 65},
+		{Timestamp: t0.Add(20 * time.Minute), Odometer: 110, SOC: 76, Speed: 20, EstLatitude: 37.5, EstLongitude: -122.2},
+	})
+
+	assert.Equal(t, 65, trip.MaxSpeed)
+	assert.Equal(t, 20*time.Minute, trip.Duration())
+	assert.InDelta(t, 10, trip.Distance().Miles(), 0.001)
+
+	require.NoError(t, s.AddTrip(&trip))
+	assert.NotZero(t, trip.ID)
+
+	later := Trip{VehicleID: 1, Start: t0.Add(time.Hour), End: t0.Add(2 * time.Hour)}
+	require.NoError(t, s.AddTrip(&later))
+
+	trips, err := s.Trips(1, t0, t0.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []Trip{trip}, trips)
+
+	latest, err := s.LatestTrip(1)
+	require.NoError(t, err)
+	assert.Equal(t, later, *latest)
+
+	_, err = s.LatestTrip(2)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestChargingSessions(t *testing.T) {
+	s, _, cleanup := openTemp(t)
+	defer cleanup()
+
+	for i, state := range []tesla.ChargeState{
+		{BatteryLevel: 50, ChargerPower: 7, ChargeEnergyAdded: 0},
+		{BatteryLevel: 60, ChargerPower: 11, ChargeEnergyAdded: 8},
+		{BatteryLevel: 70, ChargerPower: 9, ChargeEnergyAdded: 16},
+	} {
+		require.NoError(t, s.AddSnapshot(1, t0.Add(time.Duration(i)*time.Hour), &state))
+	}
+
+	snapshots, err := s.Snapshots(1, KindChargeState, t0, t0.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	session, err := NewChargingSession(1, snapshots)
+	require.NoError(t, err)
+
+	assert.Equal(t, ChargingSession{
+		VehicleID:   1,
+		Start:       t0,
+		End:         t0.Add(2 * time.Hour),
+		StartSOC:    50,
+		EndSOC:      70,
+		EnergyAdded: 16,
+		MaxPower:    11,
+	}, session)
+	assert.InDelta(t, 16, session.Energy().KilowattHours(), 0.001)
+
+	session.Latitude, session.Longitude = 37.4, -122.1
+	require.NoError(t, s.AddChargingSession(&session))
+
+	sessions, err := s.ChargingSessions(1, t0, t0.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, []ChargingSession{session}, sessions)
+
+	latest, err := s.LatestChargingSession(1)
+	require.NoError(t, err)
+	assert.Equal(t, session, *latest)
+}
+
+func TestPrune(t *testing.T) {
+	s, _, cleanup := openTemp(t)
+	defer cleanup()
+
+	s.now = func() time.Time { return t0.Add(48 * time.Hour) }
+
+	require.NoError(t, s.AddSamples(1,
+		tesla.StreamingMessage{Timestamp: t0},
+		tesla.StreamingMessage{Timestamp: t0.Add(47 * time.Hour)},
+	))
+	require.NoError(t, s.AddSnapshot(1, t0, &tesla.DriveState{}))
+	require.NoError(t, s.AddTrip(&Trip{VehicleID: 1, Start: t0, End: t0.Add(time.Hour)}))
+
+	n, err := s.Prune(Retention{Samples: 24 * time.Hour, Trips: 24 * time.Hour})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	samples, err := s.Samples(1, t0, t0.Add(48*time.Hour))
+	require.NoError(t, err)
+	assert.Len(t, samples, 1)
+
+	_, err = s.LatestSnapshot(1, KindDriveState)
+	assert.NoError(t, err, "snapshots without a retention are kept")
+
+	_, err = s.LatestTrip(1)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
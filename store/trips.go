@@ -0,0 +1,293 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// Trip is a drive.
+type Trip struct {
+	ID        int64
+	VehicleID int
+
+	Start time.Time
+	End   time.Time
+
+	StartOdometer float64
+	EndOdometer   float64
+	StartSOC      int
+	EndSOC        int
+
+	StartLatitude  float64
+	StartLongitude float64
+	EndLatitude    float64
+	EndLongitude   float64
+
+	// MaxSpeed is in miles per hour, as reported by the API.
+	MaxSpeed int
+}
+
+// NewTrip summarizes the streaming samples of a drive, which must be in time order.
+func NewTrip(vehicleID int, samples []tesla.StreamingMessage) Trip {
+	trip := Trip{VehicleID: vehicleID}
+
+	if len(samples) == 0 {
+		return trip
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+
+	trip.Start, trip.End = first.Timestamp, last.Timestamp
+	trip.StartOdometer, trip.EndOdometer = first.Odometer, last.Odometer
+	trip.StartSOC, trip.EndSOC = first.SOC, last.SOC
+	trip.StartLatitude, trip.StartLongitude = first.EstLatitude, first.EstLongitude
+	trip.EndLatitude, trip.EndLongitude = last.EstLatitude, last.EstLongitude
+
+	for _, msg := range samples {
+		if msg.Speed > trip.MaxSpeed {
+			trip.MaxSpeed = msg.Speed
+		}
+	}
+
+	return trip
+}
+
+// Distance returns the distance driven.
+func (t *Trip) Distance() tesla.Distance {
+	return tesla.Miles(t.EndOdometer - t.StartOdometer)
+}
+
+// Duration returns how long the trip took.
+func (t *Trip) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+const tripColumns = `id, vehicle_id, start_time, end_time, start_odometer, end_odometer, start_soc, end_soc,
+	start_lat, start_lng, end_lat, end_lng, max_speed`
+
+// AddTrip stores a trip and sets its ID.
+func (s *Store) AddTrip(trip *Trip) error {
+	res, err := s.db.Exec(`INSERT INTO trips (vehicle_id, start_time, end_time, start_odometer, end_odometer,
+		start_soc, end_soc, start_lat, start_lng, end_lat, end_lng, max_speed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		trip.VehicleID, millis(trip.Start), millis(trip.End), trip.StartOdometer, trip.EndOdometer,
+		trip.StartSOC, trip.EndSOC, trip.StartLatitude, trip.StartLongitude, trip.EndLatitude, trip.EndLongitude,
+		trip.MaxSpeed)
+	if err != nil {
+		return fmt.Errorf("error adding trip: %w", err)
+	}
+
+	trip.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error adding trip: %w", err)
+	}
+
+	return nil
+}
+
+// Trips returns the vehicle's trips that started from from up to, but not including, to, oldest
+// first.
+func (s *Store) Trips(vehicleID int, from, to time.Time) ([]Trip, error) {
+	rows, err := s.db.Query(`SELECT `+tripColumns+` FROM trips
+		WHERE vehicle_id = ? AND start_time >= ? AND start_time < ? ORDER BY start_time`,
+		vehicleID, millis(from), millis(to))
+	if err != nil {
+		return nil, fmt.Errorf("error querying trips: %w", err)
+	}
+	defer rows.Close()
+
+	var trips []Trip
+
+	for rows.Next() {
+		trip, err := scanTrip(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		trips = append(trips, trip)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying trips: %w", err)
+	}
+
+	return trips, nil
+}
+
+// LatestTrip returns the vehicle's most recent trip, or ErrNotFound if it has none.
+func (s *Store) LatestTrip(vehicleID int) (*Trip, error) {
+	row := s.db.QueryRow(`SELECT `+tripColumns+` FROM trips
+		WHERE vehicle_id = ? ORDER BY start_time DESC LIMIT 1`, vehicleID)
+
+	trip, err := scanTrip(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &trip, nil
+}
+
+func scanTrip(row scanner) (Trip, error) {
+	var (
+		trip       Trip
+		start, end int64
+	)
+
+	err := row.Scan(&trip.ID, &trip.VehicleID, &start, &end, &trip.StartOdometer, &trip.EndOdometer,
+		&trip.StartSOC, &trip.EndSOC, &trip.StartLatitude, &trip.StartLongitude, &trip.EndLatitude,
+		&trip.EndLongitude, &trip.MaxSpeed)
+	if err == sql.ErrNoRows {
+		return trip, fmt.Errorf("%w", ErrNotFound)
+	}
+
+	if err != nil {
+		return trip, fmt.Errorf("error reading trip: %w", err)
+	}
+
+	trip.Start, trip.End = fromMillis(start), fromMillis(end)
+
+	return trip, nil
+}
+
+// ChargingSession is a period of charging.
+type ChargingSession struct {
+	ID        int64
+	VehicleID int
+
+	Start time.Time
+	End   time.Time
+
+	StartSOC int
+	EndSOC   int
+
+	// EnergyAdded is in kilowatt hours.
+	EnergyAdded float64
+	// MaxPower is in kilowatts.
+	MaxPower int
+
+	Latitude  float64
+	Longitude float64
+}
+
+// NewChargingSession summarizes the charge state snapshots of a charging session, which must be
+// in time order. The location is not known from charge state; set it from the drive state.
+func NewChargingSession(vehicleID int, snapshots []Snapshot) (ChargingSession, error) {
+	session := ChargingSession{VehicleID: vehicleID}
+
+	for i, snapshot := range snapshots {
+		var state tesla.ChargeState
+
+		if err := snapshot.Decode(&state); err != nil {
+			return session, err
+		}
+
+		if i == 0 {
+			session.Start = snapshot.Time
+			session.StartSOC = state.BatteryLevel
+		}
+
+		session.End = snapshot.Time
+		session.EndSOC = state.BatteryLevel
+		session.EnergyAdded = state.ChargeEnergyAdded
+
+		if state.ChargerPower > session.MaxPower {
+			session.MaxPower = state.ChargerPower
+		}
+	}
+
+	return session, nil
+}
+
+// Energy returns the energy added.
+func (c *ChargingSession) Energy() tesla.Energy {
+	return tesla.KilowattHours(c.EnergyAdded)
+}
+
+const chargingSessionColumns = `id, vehicle_id, start_time, end_time, start_soc, end_soc, energy_added, max_power,
+	latitude, longitude`
+
+// AddChargingSession stores a charging session and sets its ID.
+func (s *Store) AddChargingSession(session *ChargingSession) error {
+	res, err := s.db.Exec(`INSERT INTO charging_sessions (vehicle_id, start_time, end_time, start_soc, end_soc,
+		energy_added, max_power, latitude, longitude)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.VehicleID, millis(session.Start), millis(session.End), session.StartSOC, session.EndSOC,
+		session.EnergyAdded, session.MaxPower, session.Latitude, session.Longitude)
+	if err != nil {
+		return fmt.Errorf("error adding charging session: %w", err)
+	}
+
+	session.ID, err = res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error adding charging session: %w", err)
+	}
+
+	return nil
+}
+
+// ChargingSessions returns the vehicle's charging sessions that started from from up to, but not
+// including, to, oldest first.
+func (s *Store) ChargingSessions(vehicleID int, from, to time.Time) ([]ChargingSession, error) {
+	rows, err := s.db.Query(`SELECT `+chargingSessionColumns+` FROM charging_sessions
+		WHERE vehicle_id = ? AND start_time >= ? AND start_time < ? ORDER BY start_time`,
+		vehicleID, millis(from), millis(to))
+	if err != nil {
+		return nil, fmt.Errorf("error querying charging sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []ChargingSession
+
+	for rows.Next() {
+		session, err := scanChargingSession(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error querying charging sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// LatestChargingSession returns the vehicle's most recent charging session, or ErrNotFound if it
+// has none.
+func (s *Store) LatestChargingSession(vehicleID int) (*ChargingSession, error) {
+	row := s.db.QueryRow(`SELECT `+chargingSessionColumns+` FROM charging_sessions
+		WHERE vehicle_id = ? ORDER BY start_time DESC LIMIT 1`, vehicleID)
+
+	session, err := scanChargingSession(row)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+func scanChargingSession(row scanner) (ChargingSession, error) {
+	var (
+		session    ChargingSession
+		start, end int64
+	)
+
+	err := row.Scan(&session.ID, &session.VehicleID, &start, &end, &session.StartSOC, &session.EndSOC,
+		&session.EnergyAdded, &session.MaxPower, &session.Latitude, &session.Longitude)
+	if err == sql.ErrNoRows {
+		return session, fmt.Errorf("%w", ErrNotFound)
+	}
+
+	if err != nil {
+		return session, fmt.Errorf("error reading charging session: %w", err)
+	}
+
+	session.Start, session.End = fromMillis(start), fromMillis(end)
+
+	return session, nil
+}
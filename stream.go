@@ -10,32 +10,29 @@ import (
 	"github.com/gorilla/websocket"
 )
 
-// StreamingMessage represents the current state of the car.
+// StreamingMessage represents the current state of the car. The JSON names are the field names
+// used by the streaming API.
 type StreamingMessage struct {
-	Timestamp    time.Time
-	Speed        int
-	Odometer     float64
-	SOC          int
-	Elevation    int
-	EstHeading   int
-	EstLatitude  float64
-	EstLongitude float64
-	Power        int
-	ShiftState   int
-	Range        int
-	EstRange     int
-	Heading      int
+	Timestamp    time.Time `json:"timestamp"`
+	Speed        int       `json:"speed"`
+	Odometer     float64   `json:"odometer"`
+	SOC          int       `json:"soc"`
+	Elevation    int       `json:"elevation"`
+	EstHeading   int       `json:"est_heading"`
+	EstLatitude  float64   `json:"est_lat"`
+	EstLongitude float64   `json:"est_lng"`
+	Power        int       `json:"power"`
+	ShiftState   int       `json:"shift_state"`
+	Range        int       `json:"range"`
+	EstRange     int       `json:"est_range"`
+	Heading      int       `json:"heading"`
 }
 
 func (msg *StreamingMessage) fromCSV(data string) {
 	values := strings.Split(data, ",")
 
-	if ts, err := strconv.Atoi(values[0]); err == nil {
-		sec := ts / 1000
-		ms := time.Duration(ts%1000) * time.Millisecond
-
-		msg.Timestamp = time.Unix(int64(sec), int64(ms))
-
+	if ts, err := strconv.ParseInt(values[0], 10, 64); err == nil {
+		msg.Timestamp = timeFromMillis(ts)
 	}
 
 	if val, err := strconv.Atoi(values[1]); err == nil {
@@ -87,6 +84,31 @@ func (msg *StreamingMessage) fromCSV(data string) {
 	}
 }
 
+// CurrentSpeed returns the speed of the vehicle.
+func (msg *StreamingMessage) CurrentSpeed() Speed {
+	return MilesPerHour(float64(msg.Speed))
+}
+
+// CurrentPower returns the power being drawn from the battery.
+func (msg *StreamingMessage) CurrentPower() Power {
+	return Kilowatts(float64(msg.Power))
+}
+
+// OdometerDistance returns the total distance the vehicle has driven.
+func (msg *StreamingMessage) OdometerDistance() Distance {
+	return Miles(msg.Odometer)
+}
+
+// RangeDistance returns the rated range remaining.
+func (msg *StreamingMessage) RangeDistance() Distance {
+	return Miles(float64(msg.Range))
+}
+
+// EstRangeDistance returns the range remaining, estimated from recent driving.
+func (msg *StreamingMessage) EstRangeDistance() Distance {
+	return Miles(float64(msg.EstRange))
+}
+
 type Stream struct {
 	data  chan StreamingMessage
 	close bool
@@ -104,9 +126,26 @@ func (s *Stream) Close() {
 }
 
 func (s *Stream) Err() error {
+	s.sem.RLock()
+	defer s.sem.RUnlock()
+
 	return s.err
 }
 
+func (s *Stream) closed() bool {
+	s.sem.RLock()
+	defer s.sem.RUnlock()
+
+	return s.close
+}
+
+func (s *Stream) setErr(err error) {
+	s.sem.Lock()
+	defer s.sem.Unlock()
+
+	s.err = err
+}
+
 func (s *Stream) Data() <-chan StreamingMessage {
 	return s.data
 }
@@ -130,12 +169,16 @@ func (c *Conn) Stream(id int, token string) (*Stream, error) {
 			Tag:         fmt.Sprintf("%d", id),
 		}
 
-		url := "wss://streaming.vn.teslamotors.com/streaming/"
-		ws, _, err := websocket.DefaultDialer.Dial(url, nil)
-		if err != nil {
-			return nil, err
+		ws, _, err := websocket.DefaultDialer.Dial(c.streamingURL, nil)
+		if err == nil {
+			if err = ws.WriteJSON(connectMsg); err != nil {
+				ws.Close()
+			}
 		}
-		if err = ws.WriteJSON(connectMsg); err != nil {
+
+		c.streamConnect(id, err)
+
+		if err != nil {
 			return nil, err
 		}
 
@@ -155,23 +198,34 @@ func (c *Conn) Stream(id int, token string) (*Stream, error) {
 		defer close(s.data)
 
 		for {
-			if s.close {
+			if s.closed() {
+				ws.Close()
+				c.streamDisconnect(id, nil)
 				return
 			}
 
 			var msg message
 			err := ws.ReadJSON(&msg)
+			if err != nil {
+				ws.Close()
+				s.setErr(err)
+				c.streamDisconnect(id, err)
+				return
+			}
 
 			if msg.MessageType == "data:update" {
 				var sm StreamingMessage
 				sm.fromCSV(msg.Value)
+				c.learnLocation(id, Location{Latitude: sm.EstLatitude, Longitude: sm.EstLongitude, Time: sm.Timestamp})
+				c.streamMessage(id, sm)
 				s.data <- sm
 			} else if msg.MessageType == "data:error" {
 				ws.Close()
+				c.streamDisconnect(id, fmt.Errorf("stream error: %s", msg.Value))
 
 				ws, err = connect()
 				if err != nil {
-					s.err = err
+					s.setErr(err)
 					return
 				}
 			}
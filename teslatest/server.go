@@ -0,0 +1,378 @@
+// Package teslatest provides a fake Tesla Owner's API for testing code that uses the tesla
+// package without network access or a real vehicle.
+//
+// The server simulates vehicles that fall asleep and wake up, charge over time, respond to
+// commands, and stream data over a websocket. Errors such as 408 (vehicle unavailable) and 429
+// (too many requests) can be injected to exercise retry logic.
+//
+//	srv := teslatest.NewServer()
+//	defer srv.Close()
+//
+//	v := srv.AddVehicle(1, "5YJSA1E27HF000001")
+//	v.ChargeState.BatteryLevel = 50
+//
+//	conn := srv.Conn()
+//	err := conn.Authenticate(teslatest.Email, teslatest.Password)
+//
+// In tests, NewTestServer does the same with a vehicle of id 1 and closes the server when the
+// test finishes.
+package teslatest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+const (
+	// Email is the account email accepted by the server.
+	Email = "test@example.com"
+	// Password is the account password accepted by the server.
+	Password = "correct-horse-battery-staple"
+	// ClientID is the OAuth client ID accepted by the server.
+	ClientID = "client-id"
+	// ClientSecret is the OAuth client secret accepted by the server.
+	ClientSecret = "client-secret"
+	// AccessToken is the access token issued by the server.
+	AccessToken = "test-access-token"
+	// RefreshToken is the refresh token issued by the server.
+	RefreshToken = "test-refresh-token"
+	// VIN is the VIN of the vehicle added by NewTestServer.
+	VIN = "5YJSA1E2XLF000001"
+)
+
+// Server is a fake Tesla Owner's API and streaming API.
+type Server struct {
+	// URL is the base URL of the owner's API, suitable for tesla.NewConn.
+	URL string
+	// StreamingURL is the websocket URL of the streaming API, suitable for Conn.SetStreamingURL.
+	StreamingURL string
+
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	offset   time.Duration
+	vehicles map[int]*Vehicle
+	order    []int
+	failures []failure
+	requests []Request
+}
+
+type failure struct {
+	path       string
+	statusCode int
+	retryAfter time.Duration
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Time   time.Time
+}
+
+// NewServer starts a new fake API server. Call Close when done.
+func NewServer() *Server {
+	s := &Server{
+		vehicles: make(map[int]*Vehicle),
+	}
+
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.srv.URL
+	s.StreamingURL = "ws" + strings.TrimPrefix(s.srv.URL, "http") + "/streaming/"
+
+	return s
+}
+
+// NewTestServer starts a new fake API server with one vehicle, with id 1 and VIN, and returns it
+// with a connection that has already authenticated. The server is closed when the test finishes.
+func NewTestServer(t testing.TB) (*Server, *tesla.Conn) {
+	t.Helper()
+
+	s := NewServer()
+	t.Cleanup(s.Close)
+
+	s.AddVehicle(1, VIN)
+
+	conn := s.Conn()
+	if err := conn.Authenticate(Email, Password); err != nil {
+		t.Fatalf("error authenticating: %v", err)
+	}
+
+	return s, conn
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// Conn returns a new connection configured to use the server. It is not yet authenticated.
+func (s *Server) Conn() *tesla.Conn {
+	conn := tesla.NewConn(http.DefaultTransport, s.URL, ClientID, ClientSecret)
+	conn.SetStreamingURL(s.StreamingURL)
+
+	return conn
+}
+
+// Now returns the server's simulated current time.
+func (s *Server) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.now()
+}
+
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// Advance moves the server's simulated clock forward. Charging vehicles gain charge and waking
+// vehicles come online as if the time had passed.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// Fail causes the next request to a path containing the given substring to fail with the given
+// HTTP status code. An empty path matches any request. Failures are consumed in the order they
+// were added.
+func (s *Server) Fail(path string, statusCode int) {
+	s.FailWithRetryAfter(path, statusCode, 0)
+}
+
+// FailWithRetryAfter is like Fail, but also sends a Retry-After header, as the API does with 429
+// responses.
+func (s *Server) FailWithRetryAfter(path string, statusCode int, retryAfter time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, failure{
+		path:       path,
+		statusCode: statusCode,
+		retryAfter: retryAfter,
+	})
+}
+
+// Requests returns every request the server has received, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// AddVehicle adds a new online vehicle to the account, with plausible default state.
+func (s *Server) AddVehicle(id int, vin string) *Vehicle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := newVehicle(id, vin, s.now())
+
+	s.vehicles[id] = v
+	s.order = append(s.order, id)
+
+	return v
+}
+
+// Vehicle returns the simulated vehicle with the given id. Lock the vehicle before modifying it
+// while requests may be in flight.
+func (s *Server) Vehicle(id int) *Vehicle {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.vehicles[id]
+}
+
+func (s *Server) takeFailure(path string) (failure, bool) {
+	for i, f := range s.failures {
+		if f.path == "" || strings.Contains(path, f.path) {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			return f, true
+		}
+	}
+
+	return failure{}, false
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Time: s.now()})
+	f, failed := s.takeFailure(r.URL.Path)
+	s.mu.Unlock()
+
+	if failed {
+		if f.retryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(f.retryAfter/time.Second)))
+		}
+
+		writeError(w, f.statusCode, http.StatusText(f.statusCode))
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/oauth/token":
+		s.serveToken(w, r)
+	case r.URL.Path == "/streaming/":
+		s.serveStream(w, r)
+	case strings.HasPrefix(r.URL.Path, "/api/1/vehicles"):
+		if r.Header.Get("Authorization") != "Bearer "+AccessToken {
+			writeError(w, http.StatusUnauthorized, "invalid bearer token")
+			return
+		}
+
+		s.serveVehicles(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req struct {
+		GrantType    string `json:"grant_type"`
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Email        string `json:"email"`
+		Password     string `json:"password"`
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	valid := req.ClientID == ClientID && req.ClientSecret == ClientSecret
+
+	switch req.GrantType {
+	case "password":
+		valid = valid && req.Email == Email && req.Password == Password
+	case "refresh_token":
+		valid = valid && req.RefreshToken == RefreshToken
+	default:
+		valid = false
+	}
+
+	if !valid {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"access_token":  AccessToken,
+		"token_type":    "bearer",
+		"expires_in":    3888000,
+		"refresh_token": RefreshToken,
+		"created_at":    s.Now().Unix(),
+	})
+}
+
+func (s *Server) serveVehicles(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/1/vehicles"), "/"), "/")
+
+	if parts[0] == "" {
+		s.mu.Lock()
+		now := s.now()
+		list := make([]tesla.Vehicle, 0, len(s.order))
+		for _, id := range s.order {
+			list = append(list, s.vehicles[id].summary(now))
+		}
+		s.mu.Unlock()
+
+		writeJSON(w, map[string]interface{}{"response": list, "count": len(list)})
+		return
+	}
+
+	id, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	s.mu.Lock()
+	v, ok := s.vehicles[id]
+	now := s.now()
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, "not_found")
+		return
+	}
+
+	if len(parts) == 1 {
+		writeJSON(w, map[string]interface{}{"response": v.summary(now)})
+		return
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "wake_up" && r.Method == http.MethodPost:
+		writeJSON(w, map[string]interface{}{"response": v.wakeUp(now)})
+	case len(parts) == 3 && parts[1] == "data_request" && r.Method == http.MethodGet:
+		resp, err := v.dataRequest(parts[2], now)
+		writeVehicleResponse(w, resp, err)
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		resp, err := v.dataRequest(parts[1], now)
+		writeVehicleResponse(w, resp, err)
+	case len(parts) == 3 && parts[1] == "command" && r.Method == http.MethodPost:
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		resp, err := v.command(parts[2], body, now)
+		writeVehicleResponse(w, resp, err)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func writeVehicleResponse(w http.ResponseWriter, resp interface{}, err error) {
+	if err != nil {
+		if statusErr, ok := err.(statusError); ok {
+			writeError(w, statusErr.statusCode, statusErr.message)
+			return
+		}
+
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"response": resp})
+}
+
+type statusError struct {
+	statusCode int
+	message    string
+}
+
+func (err statusError) Error() string {
+	return fmt.Sprintf("%d: %s", err.statusCode, err.message)
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"response": nil,
+		"error":    message,
+	})
+}
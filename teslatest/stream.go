@@ -0,0 +1,152 @@
+package teslatest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rickbassham/tesla"
+)
+
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// Stream sends a message to every client currently streaming from the vehicle. The vehicle's
+// drive state and odometer are updated to match, so later data requests agree with the stream.
+// A zero Timestamp is replaced with the current time.
+func (v *Vehicle) Stream(msg tesla.StreamingMessage) {
+	v.Lock()
+	defer v.Unlock()
+
+	if msg.Timestamp.IsZero() {
+		msg.Timestamp = time.Now()
+	}
+
+	v.DriveState.Latitude = msg.EstLatitude
+	v.DriveState.Longitude = msg.EstLongitude
+	v.DriveState.Heading = msg.Heading
+	v.DriveState.Power = msg.Power
+	v.DriveState.GpsAsOf = int(msg.Timestamp.Unix())
+	v.DriveState.Speed = float64(msg.Speed)
+	v.VehicleState.Odometer = msg.Odometer
+
+	for sub := range v.subscribers {
+		select {
+		case sub <- msg:
+		default:
+		}
+	}
+}
+
+func (v *Vehicle) subscribe() chan tesla.StreamingMessage {
+	v.Lock()
+	defer v.Unlock()
+
+	sub := make(chan tesla.StreamingMessage, 100)
+	v.subscribers[sub] = struct{}{}
+
+	return sub
+}
+
+func (v *Vehicle) unsubscribe(sub chan tesla.StreamingMessage) {
+	v.Lock()
+	defer v.Unlock()
+
+	delete(v.subscribers, sub)
+}
+
+type streamMessage struct {
+	MessageType string `json:"msg_type"`
+	Token       string `json:"token,omitempty"`
+	Value       string `json:"value"`
+	Tag         string `json:"tag"`
+}
+
+// serveStream implements the streaming API. Subscriptions for unknown vehicles, vehicles that are
+// not online, or with an invalid token are closed immediately.
+func (s *Server) serveStream(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer ws.Close()
+
+	var sub streamMessage
+
+	err = ws.ReadJSON(&sub)
+	if err != nil || sub.MessageType != "data:subscribe_oauth" || sub.Token != AccessToken {
+		return
+	}
+
+	id, err := strconv.Atoi(sub.Tag)
+	if err != nil {
+		return
+	}
+
+	v := s.Vehicle(id)
+	if v == nil {
+		return
+	}
+
+	v.Lock()
+	online := v.State == StateOnline
+	v.Unlock()
+
+	if !online {
+		return
+	}
+
+	msgs := v.subscribe()
+	defer v.unsubscribe(msgs)
+
+	closed := make(chan struct{})
+
+	go func() {
+		defer close(closed)
+
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case msg := <-msgs:
+			err := ws.WriteJSON(streamMessage{
+				MessageType: "data:update",
+				Tag:         sub.Tag,
+				Value:       streamingCSV(msg),
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// streamingCSV formats a message the way the streaming API does, in the order requested by
+// Conn.Stream.
+func streamingCSV(msg tesla.StreamingMessage) string {
+	return fmt.Sprintf("%d,%d,%.1f,%d,%d,%d,%f,%f,%d,%d,%d,%d,%d",
+		msg.Timestamp.UnixNano()/int64(time.Millisecond),
+		msg.Speed,
+		msg.Odometer,
+		msg.SOC,
+		msg.Elevation,
+		msg.EstHeading,
+		msg.EstLatitude,
+		msg.EstLongitude,
+		msg.Power,
+		msg.ShiftState,
+		msg.Range,
+		msg.EstRange,
+		msg.Heading,
+	)
+}
@@ -0,0 +1,448 @@
+package teslatest
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+const (
+	// StateOnline is the state of a vehicle that is awake and accepting requests.
+	StateOnline = "online"
+	// StateAsleep is the state of a vehicle that must be woken before accepting requests.
+	StateAsleep = "asleep"
+	// StateOffline is the state of a vehicle that cannot be reached, even to wake it.
+	StateOffline = "offline"
+)
+
+// Command is a command received by a simulated vehicle.
+type Command struct {
+	Name string
+	Body map[string]interface{}
+	Time time.Time
+}
+
+// CommandResult overrides the result of a command sent to a simulated vehicle.
+type CommandResult struct {
+	Result bool
+	Reason string
+}
+
+// Vehicle is a simulated vehicle. The embedded mutex must be held while modifying a vehicle that
+// may be in use by the server.
+type Vehicle struct {
+	sync.Mutex
+
+	ID          int
+	VehicleID   int
+	VIN         string
+	DisplayName string
+	OptionCodes string
+	State       string
+
+	// WakeDelay is how long the vehicle takes to come online after a wake_up request.
+	WakeDelay time.Duration
+	// ChargePercentPerHour is how quickly the battery level rises while charging.
+	ChargePercentPerHour float64
+	// BatteryCapacity is the usable capacity of the battery in kWh, used to compute energy added.
+	BatteryCapacity float64
+	// RangePerPercent is the rated range in miles for each percent of battery.
+	RangePerPercent float64
+	// CommandResults overrides the result of the named commands, such as "door_lock". The
+	// command is still recorded but has no effect on the vehicle state.
+	CommandResults map[string]CommandResult
+
+	MobileEnabled bool
+	ChargeState   tesla.ChargeState
+	ClimateState  tesla.ClimateState
+	DriveState    tesla.DriveState
+	GUISettings   tesla.GUISettings
+	VehicleConfig tesla.VehicleConfig
+	VehicleState  tesla.VehicleState
+	ChargingSites tesla.ChargingSites
+
+	commands    []Command
+	wakeAt      time.Time
+	lastUpdate  time.Time
+	level       float64
+	subscribers map[chan tesla.StreamingMessage]struct{}
+}
+
+func newVehicle(id int, vin string, now time.Time) *Vehicle {
+	v := &Vehicle{
+		ID:                   id,
+		VehicleID:            id + 1000,
+		VIN:                  vin,
+		DisplayName:          "Test Vehicle",
+		OptionCodes:          "MDLS,RENA,AD15,PBSB,DV4W,BTX6,APH4",
+		State:                StateOnline,
+		WakeDelay:            10 * time.Second,
+		ChargePercentPerHour: 10,
+		BatteryCapacity:      75,
+		RangePerPercent:      2.6,
+		MobileEnabled:        true,
+		lastUpdate:           now,
+		level:                60,
+		subscribers:          make(map[chan tesla.StreamingMessage]struct{}),
+	}
+
+	v.ChargeState = tesla.ChargeState{
+		BatteryLevel:            60,
+		UsableBatteryLevel:      60,
+		BatteryRange:            60 * v.RangePerPercent,
+		EstBatteryRange:         60 * v.RangePerPercent * 0.9,
+		IdealBatteryRange:       60 * v.RangePerPercent * 1.1,
+		ChargeCurrentRequest:    48,
+		ChargeCurrentRequestMax: 48,
+		ChargeLimitSoc:          80,
+		ChargeLimitSocMin:       50,
+		ChargeLimitSocMax:       100,
+		ChargeLimitSocStd:       90,
+		ChargePortLatch:         tesla.ChargePortLatchEngaged,
+		ChargingState:           tesla.ChargingStateDisconnected,
+		ConnChargeCable:         tesla.ChargeCableSAE,
+	}
+
+	v.ClimateState = tesla.ClimateState{
+		DriverTempSetting:    21,
+		PassengerTempSetting: 21,
+		InsideTemp:           20,
+		OutsideTemp:          15,
+		MinAvailTemp:         15,
+		MaxAvailTemp:         28,
+	}
+
+	v.DriveState = tesla.DriveState{
+		GpsAsOf:   int(now.Unix()),
+		Heading:   90,
+		Latitude:  37.4919,
+		Longitude: -121.9447,
+	}
+
+	v.GUISettings = tesla.GUISettings{
+		GUIChargeRateUnits:  "mi/hr",
+		GUIDistanceUnits:    "mi/hr",
+		GUIRangeDisplay:     "Rated",
+		GUITemperatureUnits: "F",
+		ShowRangeUnits:      true,
+	}
+
+	v.VehicleConfig = tesla.VehicleConfig{
+		CanAcceptNavigationRequests: true,
+		CanActuateTrunks:            true,
+		CarType:                     "models",
+		ChargePortType:              "US",
+		MotorizedChargePort:         true,
+		RearSeatHeaters:             1,
+		SunRoofInstalled:            0,
+	}
+
+	v.VehicleState = tesla.VehicleState{
+		APIVersion:           7,
+		CarVersion:           "2020.4.1 4a4ad401858f",
+		Locked:               true,
+		Odometer:             12345.6,
+		RemoteStartSupported: true,
+		SentryModeAvailable:  true,
+		VehicleName:          "Test Vehicle",
+	}
+	v.VehicleState.SpeedLimitMode.CurrentLimitMph = 65
+	v.VehicleState.SpeedLimitMode.MinLimitMph = 50
+	v.VehicleState.SpeedLimitMode.MaxLimitMph = 90
+
+	return v
+}
+
+// Sleep puts the vehicle to sleep. Data requests and commands fail with 408 until it is woken.
+func (v *Vehicle) Sleep() {
+	v.Lock()
+	defer v.Unlock()
+
+	v.State = StateAsleep
+	v.wakeAt = time.Time{}
+}
+
+// PlugIn connects a charge cable. If startCharging is true, the vehicle begins charging
+// immediately, as if charging was not scheduled.
+func (v *Vehicle) PlugIn(startCharging bool) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.ChargeState.ChargingState = tesla.ChargingStateStopped
+	if startCharging {
+		v.startCharging()
+	}
+}
+
+// Unplug disconnects the charge cable.
+func (v *Vehicle) Unplug() {
+	v.Lock()
+	defer v.Unlock()
+
+	v.ChargeState.ChargingState = tesla.ChargingStateDisconnected
+	v.ChargeState.ChargerPower = 0
+	v.ChargeState.ChargerVoltage = 0
+	v.ChargeState.ChargerActualCurrent = 0
+}
+
+// Commands returns every command the vehicle has received, in order.
+func (v *Vehicle) Commands() []Command {
+	v.Lock()
+	defer v.Unlock()
+
+	return append([]Command(nil), v.commands...)
+}
+
+func (v *Vehicle) startCharging() {
+	v.ChargeState.ChargingState = tesla.ChargingStateCharging
+	v.ChargeState.ChargerPower = int(v.BatteryCapacity * v.ChargePercentPerHour / 100)
+	v.ChargeState.ChargerVoltage = 240
+	v.ChargeState.ChargerActualCurrent = v.ChargeState.ChargeCurrentRequest
+	v.ChargeState.ChargerPhases = 1
+	v.ChargeState.ChargeEnergyAdded = 0
+	v.ChargeState.ChargeMilesAddedRated = 0
+}
+
+func (v *Vehicle) stopCharging(state tesla.ChargingState) {
+	v.ChargeState.ChargingState = state
+	v.ChargeState.ChargerPower = 0
+	v.ChargeState.ChargerActualCurrent = 0
+	v.ChargeState.ChargeRate = 0
+	v.ChargeState.TimeToFullCharge = 0
+}
+
+// update advances the simulation to now. The vehicle must be locked.
+func (v *Vehicle) update(now time.Time) {
+	if v.State == StateAsleep && !v.wakeAt.IsZero() && !now.Before(v.wakeAt) {
+		v.State = StateOnline
+		v.wakeAt = time.Time{}
+	}
+
+	elapsed := now.Sub(v.lastUpdate)
+	v.lastUpdate = now
+
+	if v.ChargeState.ChargingState != tesla.ChargingStateCharging || elapsed <= 0 {
+		return
+	}
+
+	if int(v.level) != v.ChargeState.BatteryLevel {
+		v.level = float64(v.ChargeState.BatteryLevel)
+	}
+
+	target := float64(v.ChargeState.ChargeLimitSoc)
+	gained := elapsed.Hours() * v.ChargePercentPerHour
+
+	if v.level+gained >= target {
+		gained = target - v.level
+		if gained < 0 {
+			gained = 0
+		}
+
+		v.stopCharging(tesla.ChargingStateComplete)
+	} else {
+		v.ChargeState.ChargeRate = v.ChargePercentPerHour * v.RangePerPercent
+		v.ChargeState.TimeToFullCharge = (target - v.level - gained) / v.ChargePercentPerHour
+		v.ChargeState.MinutesToFullCharge = int(v.ChargeState.TimeToFullCharge * 60)
+	}
+
+	v.level += gained
+	v.ChargeState.ChargeEnergyAdded += gained * v.BatteryCapacity / 100
+	v.ChargeState.ChargeMilesAddedRated += gained * v.RangePerPercent
+	v.ChargeState.ChargeMilesAddedIdeal += gained * v.RangePerPercent * 1.1
+	v.ChargeState.BatteryLevel = int(v.level)
+	v.ChargeState.UsableBatteryLevel = int(v.level)
+	v.ChargeState.BatteryRange = v.level * v.RangePerPercent
+	v.ChargeState.EstBatteryRange = v.level * v.RangePerPercent * 0.9
+	v.ChargeState.IdealBatteryRange = v.level * v.RangePerPercent * 1.1
+}
+
+func (v *Vehicle) summary(now time.Time) tesla.Vehicle {
+	v.Lock()
+	defer v.Unlock()
+
+	v.update(now)
+
+	return v.summaryLocked()
+}
+
+func (v *Vehicle) summaryLocked() tesla.Vehicle {
+	return tesla.Vehicle{
+		ID:          v.ID,
+		VehicleID:   v.VehicleID,
+		VIN:         v.VIN,
+		DisplayName: v.DisplayName,
+		OptionCodes: v.OptionCodes,
+		State:       v.State,
+		APIVersion:  v.VehicleState.APIVersion,
+		Tokens:      []string{"token1", "token2"},
+	}
+}
+
+func (v *Vehicle) wakeUp(now time.Time) tesla.Vehicle {
+	v.Lock()
+	defer v.Unlock()
+
+	v.update(now)
+
+	if v.State == StateAsleep && v.wakeAt.IsZero() {
+		v.wakeAt = now.Add(v.WakeDelay)
+		v.update(now)
+	}
+
+	return v.summaryLocked()
+}
+
+func errVehicleUnavailable() error {
+	return statusError{
+		statusCode: http.StatusRequestTimeout,
+		message:    "vehicle unavailable: {:error=>\"vehicle unavailable:\"}",
+	}
+}
+
+func (v *Vehicle) dataRequest(name string, now time.Time) (interface{}, error) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.update(now)
+
+	if v.State != StateOnline {
+		return nil, errVehicleUnavailable()
+	}
+
+	ts := now.UnixNano() / int64(time.Millisecond)
+
+	switch name {
+	case "charge_state":
+		state := v.ChargeState
+		state.Timestamp = ts
+		return state, nil
+	case "climate_state":
+		state := v.ClimateState
+		state.Timestamp = ts
+		return state, nil
+	case "drive_state":
+		state := v.DriveState
+		state.Timestamp = ts
+		return state, nil
+	case "gui_settings":
+		state := v.GUISettings
+		state.Timestamp = ts
+		return state, nil
+	case "vehicle_config":
+		state := v.VehicleConfig
+		state.Timestamp = ts
+		return state, nil
+	case "vehicle_state":
+		state := v.VehicleState
+		state.Timestamp = ts
+		return state, nil
+	case "nearby_charging_sites":
+		sites := v.ChargingSites
+		sites.Timestamp = ts
+		return sites, nil
+	case "mobile_enabled":
+		return v.MobileEnabled, nil
+	}
+
+	return nil, statusError{statusCode: http.StatusNotFound, message: "not found"}
+}
+
+func (v *Vehicle) command(name string, body map[string]interface{}, now time.Time) (interface{}, error) {
+	v.Lock()
+	defer v.Unlock()
+
+	v.update(now)
+
+	if v.State != StateOnline {
+		return nil, errVehicleUnavailable()
+	}
+
+	v.commands = append(v.commands, Command{Name: name, Body: body, Time: now})
+
+	if result, ok := v.CommandResults[name]; ok {
+		return map[string]interface{}{"result": result.Result, "reason": result.Reason}, nil
+	}
+
+	reason := v.applyCommand(name, body)
+
+	return map[string]interface{}{"result": reason == "", "reason": reason}, nil
+}
+
+// applyCommand updates the vehicle state for the command, returning the failure reason if the
+// command could not be performed.
+func (v *Vehicle) applyCommand(name string, body map[string]interface{}) string {
+	switch name {
+	case "charge_start":
+		switch v.ChargeState.ChargingState {
+		case tesla.ChargingStateDisconnected:
+			return "disconnected"
+		case tesla.ChargingStateCharging:
+			return "is_charging"
+		case tesla.ChargingStateComplete:
+			return "complete"
+		}
+
+		v.startCharging()
+	case "charge_stop":
+		if v.ChargeState.ChargingState != tesla.ChargingStateCharging {
+			return "not_charging"
+		}
+
+		v.stopCharging(tesla.ChargingStateStopped)
+	case "set_charge_limit":
+		v.ChargeState.ChargeLimitSoc = intValue(body["percent"])
+	case "charge_standard":
+		v.ChargeState.ChargeLimitSoc = v.ChargeState.ChargeLimitSocStd
+	case "charge_max_range":
+		v.ChargeState.ChargeLimitSoc = v.ChargeState.ChargeLimitSocMax
+	case "charge_port_door_open":
+		v.ChargeState.ChargePortDoorOpen = true
+	case "charge_port_door_close":
+		v.ChargeState.ChargePortDoorOpen = false
+	case "door_lock":
+		v.VehicleState.Locked = true
+	case "door_unlock":
+		v.VehicleState.Locked = false
+	case "set_sentry_mode":
+		v.VehicleState.SentryMode = boolValue(body["on"])
+	case "set_valet_mode":
+		v.VehicleState.ValetMode = boolValue(body["on"])
+	case "auto_conditioning_start":
+		v.ClimateState.IsClimateOn = true
+		v.ClimateState.IsAutoConditioningOn = true
+	case "auto_conditioning_stop":
+		v.ClimateState.IsClimateOn = false
+		v.ClimateState.IsAutoConditioningOn = false
+	case "set_temps":
+		v.ClimateState.DriverTempSetting = floatValue(body["driver_temp"])
+		v.ClimateState.PassengerTempSetting = floatValue(body["passenger_temp"])
+	case "remote_steering_wheel_heater_request":
+		v.ClimateState.SteeringWheelHeater = boolValue(body["on"])
+	case "speed_limit_set_limit":
+		v.VehicleState.SpeedLimitMode.CurrentLimitMph = floatValue(body["limit_mph"])
+	case "speed_limit_activate":
+		v.VehicleState.SpeedLimitMode.Active = true
+	case "speed_limit_deactivate":
+		v.VehicleState.SpeedLimitMode.Active = false
+	case "remote_start_drive":
+		v.VehicleState.RemoteStart = true
+	}
+
+	return ""
+}
+
+func floatValue(val interface{}) float64 {
+	f, _ := val.(float64)
+	return f
+}
+
+func intValue(val interface{}) int {
+	return int(floatValue(val))
+}
+
+func boolValue(val interface{}) bool {
+	b, _ := val.(bool)
+	return b
+}
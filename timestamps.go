@@ -0,0 +1,35 @@
+package tesla
+
+import (
+	"time"
+)
+
+// timeFromMillis converts a Unix timestamp in milliseconds to a time.Time. A zero timestamp
+// returns the zero time.
+func timeFromMillis(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(ms/1000, (ms%1000)*int64(time.Millisecond))
+}
+
+// timeFromSeconds converts a Unix timestamp in seconds to a time.Time. A zero timestamp returns
+// the zero time.
+func timeFromSeconds(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(sec, 0)
+}
+
+// timeFromSecondsPtr converts an optional Unix timestamp in seconds to a time.Time. A nil or zero
+// timestamp returns the zero time.
+func timeFromSecondsPtr(sec *int) time.Time {
+	if sec == nil {
+		return time.Time{}
+	}
+
+	return timeFromSeconds(int64(*sec))
+}
@@ -0,0 +1,241 @@
+package tesla
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestamps(t *testing.T) {
+	seconds := time.Unix(1580000000, 0)
+	millis := time.Unix(1580000000, 250*int64(time.Millisecond))
+
+	decode := func(data string, v interface{}) {
+		require.NoError(t, json.Unmarshal([]byte(data), v))
+	}
+
+	tests := []struct {
+		name     string
+		json     string
+		time     func(json string) time.Time
+		expected time.Time
+	}{
+		{
+			name: "charge state in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "charge state zero",
+			json: `{"timestamp": 0}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.Time()
+			},
+		},
+		{
+			name: "managed charging start in seconds",
+			json: `{"managed_charging_start_time": 1580000000}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.ManagedChargingStart()
+			},
+			expected: seconds,
+		},
+		{
+			name: "scheduled charging start null",
+			json: `{"scheduled_charging_start_time": null}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.ScheduledChargingStart()
+			},
+		},
+		{
+			name: "scheduled departure in seconds",
+			json: `{"scheduled_departure_time": 1580000000}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.ScheduledDeparture()
+			},
+			expected: seconds,
+		},
+		{
+			name: "scheduled departure zero",
+			json: `{"scheduled_departure_time": 0}`,
+			time: func(data string) time.Time {
+				var s ChargeState
+				decode(data, &s)
+				return s.ScheduledDeparture()
+			},
+		},
+		{
+			name: "charging sites in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s ChargingSites
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "congestion sync in seconds",
+			json: `{"congestion_sync_time_utc_secs": 1580000000}`,
+			time: func(data string) time.Time {
+				var s ChargingSites
+				decode(data, &s)
+				return s.CongestionSyncTime()
+			},
+			expected: seconds,
+		},
+		{
+			name: "climate state in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s ClimateState
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "drive state in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s DriveState
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "gps fix in seconds",
+			json: `{"gps_as_of": 1580000000}`,
+			time: func(data string) time.Time {
+				var s DriveState
+				decode(data, &s)
+				return s.GpsTime()
+			},
+			expected: seconds,
+		},
+		{
+			name: "gps fix zero",
+			json: `{"gps_as_of": 0}`,
+			time: func(data string) time.Time {
+				var s DriveState
+				decode(data, &s)
+				return s.GpsTime()
+			},
+		},
+		{
+			name: "gui settings in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s GUISettings
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "backseat token in seconds",
+			json: `{"backseat_token_updated_at": 1580000000}`,
+			time: func(data string) time.Time {
+				var v Vehicle
+				decode(data, &v)
+				return v.BackseatTokenUpdatedTime()
+			},
+			expected: seconds,
+		},
+		{
+			name: "backseat token null",
+			json: `{"backseat_token_updated_at": null}`,
+			time: func(data string) time.Time {
+				var v Vehicle
+				decode(data, &v)
+				return v.BackseatTokenUpdatedTime()
+			},
+		},
+		{
+			name: "vehicle config in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var c VehicleConfig
+				decode(data, &c)
+				return c.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "vehicle state in milliseconds",
+			json: `{"timestamp": 1580000000250}`,
+			time: func(data string) time.Time {
+				var s VehicleState
+				decode(data, &s)
+				return s.Time()
+			},
+			expected: millis,
+		},
+		{
+			name: "software update in milliseconds",
+			json: `{"software_update": {"scheduled_time_ms": 1580000000250}}`,
+			time: func(data string) time.Time {
+				var s VehicleState
+				decode(data, &s)
+				return s.SoftwareUpdateScheduledTime()
+			},
+			expected: millis,
+		},
+		{
+			name: "software update not scheduled",
+			json: `{"software_update": {"scheduled_time_ms": 0}}`,
+			time: func(data string) time.Time {
+				var s VehicleState
+				decode(data, &s)
+				return s.SoftwareUpdateScheduledTime()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.time(tt.json)
+			assert.True(t, tt.expected.Equal(actual), "expected %v, got %v", tt.expected, actual)
+			assert.Equal(t, tt.expected.IsZero(), actual.IsZero())
+		})
+	}
+}
+
+func TestStreamingMessageTimestamp(t *testing.T) {
+	tests := []struct {
+		name     string
+		csv      string
+		expected time.Time
+	}{
+		{name: "milliseconds", csv: "1580000000250,65,12400.5,72,30,180,37.5,-122.1,25,D,200,190,180", expected: time.Unix(1580000000, 250*int64(time.Millisecond))},
+		{name: "zero", csv: "0,,,,,,,,,,,,"},
+		{name: "empty", csv: ",,,,,,,,,,,,"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var msg StreamingMessage
+			msg.fromCSV(tt.csv)
+
+			assert.True(t, tt.expected.Equal(msg.Timestamp), "expected %v, got %v", tt.expected, msg.Timestamp)
+			assert.Equal(t, tt.expected.IsZero(), msg.Timestamp.IsZero())
+		})
+	}
+}
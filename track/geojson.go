@@ -0,0 +1,88 @@
+package track
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+)
+
+// LineString is a GeoJSON LineString geometry.
+type LineString struct {
+	Type string `json:"type"`
+	// Coordinates are longitude, latitude, and, if known, elevation in meters.
+	Coordinates [][]float64 `json:"coordinates"`
+}
+
+// Feature is a GeoJSON feature of a track.
+type Feature struct {
+	Type       string            `json:"type"`
+	Geometry   LineString        `json:"geometry"`
+	Properties FeatureProperties `json:"properties"`
+}
+
+// FeatureProperties are the properties of a track feature. Times and speeds are per point, in the
+// same order as the coordinates; coordTimes is the name other tools use for point times.
+type FeatureProperties struct {
+	Name   string    `json:"name,omitempty"`
+	Times  []string  `json:"coordTimes"`
+	Speeds []float64 `json:"speeds"`
+}
+
+// FeatureCollection is a GeoJSON feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// LineString returns the track as a GeoJSON LineString.
+func (t Track) LineString() LineString {
+	coords := make([][]float64, 0, len(t.Points))
+
+	for _, p := range t.Points {
+		coord := []float64{p.Longitude, p.Latitude}
+
+		if p.HasElevation {
+			coord = append(coord, p.Elevation)
+		}
+
+		coords = append(coords, coord)
+	}
+
+	return LineString{Type: "LineString", Coordinates: coords}
+}
+
+// Feature returns the track as a GeoJSON feature, with its name and the time and speed of each
+// point as properties.
+func (t Track) Feature() Feature {
+	props := FeatureProperties{
+		Name:   t.Name,
+		Times:  make([]string, 0, len(t.Points)),
+		Speeds: make([]float64, 0, len(t.Points)),
+	}
+
+	for _, p := range t.Points {
+		props.Times = append(props.Times, formatTime(p.Time))
+		props.Speeds = append(props.Speeds, math.Round(p.metersPerSecond()*100)/100)
+	}
+
+	return Feature{Type: "Feature", Geometry: t.LineString(), Properties: props}
+}
+
+// WriteGeoJSON writes the tracks as a GeoJSON FeatureCollection, with a feature per track.
+func WriteGeoJSON(w io.Writer, tracks ...Track) error {
+	fc := FeatureCollection{Type: "FeatureCollection", Features: make([]Feature, 0, len(tracks))}
+
+	for _, t := range tracks {
+		fc.Features = append(fc.Features, t.Feature())
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(fc); err != nil {
+		return fmt.Errorf("error writing GeoJSON: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package track
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	gpxNamespace    = "http://www.topografix.com/GPX/1/1"
+	gpxtpxNamespace = "http://www.garmin.com/xmlschemas/TrackPointExtension/v2"
+	creator         = "github.com/rickbassham/tesla"
+)
+
+type gpx struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Version string     `xml:"version,attr"`
+	Creator string     `xml:"creator,attr"`
+	XMLNS   string     `xml:"xmlns,attr"`
+	GPXTPX  string     `xml:"xmlns:gpxtpx,attr"`
+	Tracks  []gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name     string       `xml:"name,omitempty"`
+	Segments []gpxSegment `xml:"trkseg"`
+}
+
+type gpxSegment struct {
+	Points []gpxPoint `xml:"trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude   string         `xml:"lat,attr"`
+	Longitude  string         `xml:"lon,attr"`
+	Elevation  string         `xml:"ele,omitempty"`
+	Time       string         `xml:"time,omitempty"`
+	Extensions *gpxExtensions `xml:"extensions"`
+}
+
+// gpxExtensions holds the speed and course, which GPX 1.1 has no elements for, in Garmin's widely
+// supported track point extension.
+type gpxExtensions struct {
+	Speed  string `xml:"gpxtpx:TrackPointExtension>gpxtpx:speed"`
+	Course int    `xml:"gpxtpx:TrackPointExtension>gpxtpx:course"`
+}
+
+// WriteGPX writes the tracks as a GPX 1.1 document.
+func WriteGPX(w io.Writer, tracks ...Track) error {
+	doc := gpx{
+		Version: "1.1",
+		Creator: creator,
+		XMLNS:   gpxNamespace,
+		GPXTPX:  gpxtpxNamespace,
+	}
+
+	for _, t := range tracks {
+		var seg gpxSegment
+
+		for _, p := range t.Points {
+			pt := gpxPoint{
+				Latitude:  formatFloat(p.Latitude),
+				Longitude: formatFloat(p.Longitude),
+				Time:      formatTime(p.Time),
+				Extensions: &gpxExtensions{
+					Speed:  strconv.FormatFloat(p.metersPerSecond(), 'f', 2, 64),
+					Course: p.Heading,
+				},
+			}
+
+			if p.HasElevation {
+				pt.Elevation = formatFloat(p.Elevation)
+			}
+
+			seg.Points = append(seg.Points, pt)
+		}
+
+		doc.Tracks = append(doc.Tracks, gpxTrack{Name: t.Name, Segments: []gpxSegment{seg}})
+	}
+
+	return writeXML(w, doc, "GPX")
+}
+
+func writeXML(w io.Writer, doc interface{}, format string) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("error writing %s: %w", format, err)
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("error writing %s: %w", format, err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("error writing %s: %w", format, err)
+	}
+
+	return nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// formatTime formats a time in UTC as RFC 3339, which every format uses. The zero time is empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,67 @@
+package track
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kml struct {
+	XMLName  xml.Name `xml:"kml"`
+	XMLNS    string   `xml:"xmlns,attr"`
+	Document kmlDocument
+}
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"Document"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name       string        `xml:"name,omitempty"`
+	TimeSpan   *kmlTimeSpan  `xml:"TimeSpan"`
+	LineString kmlLineString `xml:"LineString"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin,omitempty"`
+	End   string `xml:"end,omitempty"`
+}
+
+type kmlLineString struct {
+	Tessellate  int    `xml:"tessellate"`
+	Coordinates string `xml:"coordinates"`
+}
+
+// WriteKML writes the tracks as a KML document, with a placemark per track. Each track is a line
+// string, which every KML viewer supports, with the time span of the track; use GPX or GeoJSON to
+// keep the time of each point. Lines follow the ground, so elevation is not written.
+func WriteKML(w io.Writer, tracks ...Track) error {
+	doc := kml{XMLNS: kmlNamespace}
+
+	for _, t := range tracks {
+		coords := make([]string, 0, len(t.Points))
+
+		for _, p := range t.Points {
+			coords = append(coords, formatFloat(p.Longitude)+","+formatFloat(p.Latitude))
+		}
+
+		placemark := kmlPlacemark{
+			Name:       t.Name,
+			LineString: kmlLineString{Tessellate: 1, Coordinates: strings.Join(coords, " ")},
+		}
+
+		if n := len(t.Points); n > 0 {
+			placemark.TimeSpan = &kmlTimeSpan{
+				Begin: formatTime(t.Points[0].Time),
+				End:   formatTime(t.Points[n-1].Time),
+			}
+		}
+
+		doc.Document.Placemarks = append(doc.Document.Placemarks, placemark)
+	}
+
+	return writeXML(w, doc, "KML")
+}
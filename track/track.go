@@ -0,0 +1,112 @@
+// Package track converts vehicle positions into tracks and writes them in formats that mapping
+// tools read: GPX, KML, and GeoJSON.
+//
+//	t := track.FromStream("Commute", msgs)
+//	err := track.WriteGPX(f, t)
+//
+// Positions come from streaming messages, which include elevation, or from drive states, which do
+// not. Speeds are written in meters per second, the unit GPX uses, in every format.
+package track
+
+import (
+	"time"
+
+	"github.com/rickbassham/tesla"
+)
+
+// Point is a position of a vehicle.
+type Point struct {
+	Time      time.Time
+	Latitude  float64
+	Longitude float64
+
+	// Elevation is in meters. HasElevation is false if it is not known.
+	Elevation    float64
+	HasElevation bool
+
+	Speed tesla.Speed
+	// Heading is in degrees clockwise from north.
+	Heading int
+}
+
+// PointFromStream returns the position in a streaming message.
+func PointFromStream(msg tesla.StreamingMessage) Point {
+	return Point{
+		Time:         msg.Timestamp,
+		Latitude:     msg.EstLatitude,
+		Longitude:    msg.EstLongitude,
+		Elevation:    float64(msg.Elevation),
+		HasElevation: true,
+		Speed:        msg.CurrentSpeed(),
+		Heading:      msg.EstHeading,
+	}
+}
+
+// PointFromDriveState returns the position in a drive state, as of the GPS fix.
+func PointFromDriveState(s *tesla.DriveState) Point {
+	t := s.GpsTime()
+	if t.IsZero() {
+		t = s.Time()
+	}
+
+	return Point{
+		Time:      t,
+		Latitude:  s.Latitude,
+		Longitude: s.Longitude,
+		Speed:     s.CurrentSpeed(),
+		Heading:   s.Heading,
+	}
+}
+
+// hasFix reports whether the point has a position; the API reports 0, 0 without a GPS fix.
+func (p Point) hasFix() bool {
+	return p.Latitude != 0 || p.Longitude != 0
+}
+
+// metersPerSecond returns the speed in meters per second.
+func (p Point) metersPerSecond() float64 {
+	return p.Speed.KilometersPerHour() / 3.6
+}
+
+// Track is a named sequence of positions, such as a drive.
+type Track struct {
+	Name   string
+	Points []Point
+}
+
+// FromStream returns a track of the positions in streaming messages, which should be in time
+// order. Messages without a GPS fix are skipped.
+func FromStream(name string, msgs []tesla.StreamingMessage) Track {
+	t := Track{Name: name}
+
+	for _, msg := range msgs {
+		t.Add(PointFromStream(msg))
+	}
+
+	return t
+}
+
+// FromDriveStates returns a track of the positions in drive states, which should be in time
+// order. States without a GPS fix, and repeats of the same fix, are skipped.
+func FromDriveStates(name string, states []*tesla.DriveState) Track {
+	t := Track{Name: name}
+
+	for _, s := range states {
+		p := PointFromDriveState(s)
+
+		if n := len(t.Points); n > 0 && t.Points[n-1].Time.Equal(p.Time) {
+			continue
+		}
+
+		t.Add(p)
+	}
+
+	return t
+}
+
+// Add appends a point to the track, unless it has no GPS fix.
+func (t *Track) Add(p Point) {
+	if p.hasFix() {
+		t.Points = append(t.Points, p)
+	}
+}
@@ -0,0 +1,138 @@
+package track_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rickbassham/tesla"
+	"github.com/rickbassham/tesla/track"
+)
+
+var t0 = time.Unix(1580000000, 0)
+
+func testTrack() track.Track {
+	return track.FromStream("Commute", []tesla.StreamingMessage{
+		{Timestamp: t0, EstLatitude: 37.4, EstLongitude: -122.1, Elevation: 12, Speed: 0, EstHeading: 90},
+		{Timestamp: t0.Add(time.Second)},
+		{Timestamp: t0.Add(10 * time.Second), EstLatitude: 37.401, EstLongitude: -122.101, Elevation: 14, Speed: 45, EstHeading: 180},
+	})
+}
+
+func TestFromStream(t *testing.T) {
+	tr := testTrack()
+
+	require.Len(t, tr.Points, 2, "messages without a fix are skipped")
+	assert.Equal(t, track.Point{
+		Time:         t0.Add(10 * time.Second),
+		Latitude:     37.401,
+		Longitude:    -122.101,
+		Elevation:    14,
+		HasElevation: true,
+		Speed:        tesla.MilesPerHour(45),
+		Heading:      180,
+	}, tr.Points[1])
+}
+
+func TestFromDriveStates(t *testing.T) {
+	tr := track.FromDriveStates("", []*tesla.DriveState{
+		{GpsAsOf: 1580000000, Latitude: 37.4, Longitude: -122.1, Speed: 30.0},
+		{GpsAsOf: 1580000000, Latitude: 37.4, Longitude: -122.1, Speed: 30.0},
+		{GpsAsOf: 1580000060, Latitude: 37.5, Longitude: -122.2, Heading: 45},
+	})
+
+	require.Len(t, tr.Points, 2, "repeats of the same fix are skipped")
+	assert.Equal(t, t0, tr.Points[0].Time)
+	assert.Equal(t, tesla.MilesPerHour(30), tr.Points[0].Speed)
+	assert.False(t, tr.Points[0].HasElevation)
+}
+
+func TestWriteGPX(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, track.WriteGPX(&buf, testTrack()))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="github.com/rickbassham/tesla" xmlns="http://www.topografix.com/GPX/1/1" xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v2">
+  <trk>
+    <name>Commute</name>
+    <trkseg>
+      <trkpt lat="37.4" lon="-122.1">
+        <ele>12</ele>
+        <time>2020-01-26T00:53:20Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:speed>0.00</gpxtpx:speed>
+            <gpxtpx:course>90</gpxtpx:course>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+      <trkpt lat="37.401" lon="-122.101">
+        <ele>14</ele>
+        <time>2020-01-26T00:53:30Z</time>
+        <extensions>
+          <gpxtpx:TrackPointExtension>
+            <gpxtpx:speed>20.12</gpxtpx:speed>
+            <gpxtpx:course>180</gpxtpx:course>
+          </gpxtpx:TrackPointExtension>
+        </extensions>
+      </trkpt>
+    </trkseg>
+  </trk>
+</gpx>
+`, buf.String())
+}
+
+func TestWriteKML(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, track.WriteKML(&buf, testTrack()))
+
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<kml xmlns="http://www.opengis.net/kml/2.2">
+  <Document>
+    <Placemark>
+      <name>Commute</name>
+      <TimeSpan>
+        <begin>2020-01-26T00:53:20Z</begin>
+        <end>2020-01-26T00:53:30Z</end>
+      </TimeSpan>
+      <LineString>
+        <tessellate>1</tessellate>
+        <coordinates>-122.1,37.4 -122.101,37.401</coordinates>
+      </LineString>
+    </Placemark>
+  </Document>
+</kml>
+`, buf.String())
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, track.WriteGeoJSON(&buf, testTrack()))
+
+	assert.JSONEq(t, `{
+		"type": "FeatureCollection",
+		"features": [{
+			"type": "Feature",
+			"geometry": {
+				"type": "LineString",
+				"coordinates": [[-122.1, 37.4, 12], [-122.101, 37.401, 14]]
+			},
+			"properties": {
+				"name": "Commute",
+				"coordTimes": ["2020-01-26T00:53:20Z", "2020-01-26T00:53:30Z"],
+				"speeds": [0, 20.12]
+			}
+		}]
+	}`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, track.WriteGeoJSON(&buf))
+
+	var empty map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &empty))
+	assert.Equal(t, []interface{}{}, empty["features"], "an empty collection is valid GeoJSON")
+}
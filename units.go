@@ -0,0 +1,243 @@
+package tesla
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	kilometersPerMile = 1.609344
+)
+
+// DistanceUnit is a unit used to display distances and speeds.
+type DistanceUnit string
+
+var (
+	// DistanceUnitMiles displays distances in miles and speeds in miles per hour.
+	DistanceUnitMiles = DistanceUnit("mi")
+	// DistanceUnitKilometers displays distances in kilometers and speeds in kilometers per hour.
+	DistanceUnitKilometers = DistanceUnit("km")
+)
+
+// TemperatureUnit is a unit used to display temperatures.
+type TemperatureUnit string
+
+var (
+	// TemperatureUnitCelsius displays temperatures in degrees Celsius.
+	TemperatureUnitCelsius = TemperatureUnit("C")
+	// TemperatureUnitFahrenheit displays temperatures in degrees Fahrenheit.
+	TemperatureUnitFahrenheit = TemperatureUnit("F")
+)
+
+// Distance is a length, stored in miles as that is what the API uses.
+type Distance float64
+
+// Miles creates a Distance from a value in miles.
+func Miles(mi float64) Distance {
+	return Distance(mi)
+}
+
+// Kilometers creates a Distance from a value in kilometers.
+func Kilometers(km float64) Distance {
+	return Distance(km / kilometersPerMile)
+}
+
+// Miles returns the distance in miles.
+func (d Distance) Miles() float64 {
+	return float64(d)
+}
+
+// Kilometers returns the distance in kilometers.
+func (d Distance) Kilometers() float64 {
+	return float64(d) * kilometersPerMile
+}
+
+// In returns the distance in the given unit.
+func (d Distance) In(unit DistanceUnit) float64 {
+	if unit == DistanceUnitKilometers {
+		return d.Kilometers()
+	}
+
+	return d.Miles()
+}
+
+// Format returns the distance as a string in the given unit, such as "123.4 km".
+func (d Distance) Format(unit DistanceUnit) string {
+	if unit != DistanceUnitKilometers {
+		unit = DistanceUnitMiles
+	}
+
+	return fmt.Sprintf("%.1f %s", d.In(unit), unit)
+}
+
+func (d Distance) String() string {
+	return d.Format(DistanceUnitMiles)
+}
+
+// Speed is a velocity, stored in miles per hour as that is what the API uses.
+type Speed float64
+
+// MilesPerHour creates a Speed from a value in miles per hour.
+func MilesPerHour(mph float64) Speed {
+	return Speed(mph)
+}
+
+// KilometersPerHour creates a Speed from a value in kilometers per hour.
+func KilometersPerHour(kph float64) Speed {
+	return Speed(kph / kilometersPerMile)
+}
+
+// MilesPerHour returns the speed in miles per hour.
+func (s Speed) MilesPerHour() float64 {
+	return float64(s)
+}
+
+// KilometersPerHour returns the speed in kilometers per hour.
+func (s Speed) KilometersPerHour() float64 {
+	return float64(s) * kilometersPerMile
+}
+
+// In returns the speed in the given unit, per hour.
+func (s Speed) In(unit DistanceUnit) float64 {
+	if unit == DistanceUnitKilometers {
+		return s.KilometersPerHour()
+	}
+
+	return s.MilesPerHour()
+}
+
+// Format returns the speed as a string in the given unit, such as "65 mph".
+func (s Speed) Format(unit DistanceUnit) string {
+	if unit == DistanceUnitKilometers {
+		return fmt.Sprintf("%.0f km/h", s.KilometersPerHour())
+	}
+
+	return fmt.Sprintf("%.0f mph", s.MilesPerHour())
+}
+
+func (s Speed) String() string {
+	return s.Format(DistanceUnitMiles)
+}
+
+// Temperature is stored in degrees Celsius as that is what the API uses.
+type Temperature float64
+
+// Celsius creates a Temperature from a value in degrees Celsius.
+func Celsius(c float64) Temperature {
+	return Temperature(c)
+}
+
+// Fahrenheit creates a Temperature from a value in degrees Fahrenheit.
+func Fahrenheit(f float64) Temperature {
+	return Temperature((f - 32) * 5 / 9)
+}
+
+// Celsius returns the temperature in degrees Celsius.
+func (t Temperature) Celsius() float64 {
+	return float64(t)
+}
+
+// Fahrenheit returns the temperature in degrees Fahrenheit.
+func (t Temperature) Fahrenheit() float64 {
+	return float64(t)*9/5 + 32
+}
+
+// In returns the temperature in the given unit.
+func (t Temperature) In(unit TemperatureUnit) float64 {
+	if unit == TemperatureUnitFahrenheit {
+		return t.Fahrenheit()
+	}
+
+	return t.Celsius()
+}
+
+// Format returns the temperature as a string in the given unit, such as "21.5°C".
+func (t Temperature) Format(unit TemperatureUnit) string {
+	if unit != TemperatureUnitFahrenheit {
+		unit = TemperatureUnitCelsius
+	}
+
+	return fmt.Sprintf("%.1f°%s", t.In(unit), unit)
+}
+
+func (t Temperature) String() string {
+	return t.Format(TemperatureUnitCelsius)
+}
+
+// Energy is stored in kilowatt hours.
+type Energy float64
+
+// KilowattHours creates an Energy from a value in kilowatt hours.
+func KilowattHours(kwh float64) Energy {
+	return Energy(kwh)
+}
+
+// KilowattHours returns the energy in kilowatt hours.
+func (e Energy) KilowattHours() float64 {
+	return float64(e)
+}
+
+// WattHours returns the energy in watt hours.
+func (e Energy) WattHours() float64 {
+	return float64(e) * 1000
+}
+
+func (e Energy) String() string {
+	return fmt.Sprintf("%.2f kWh", e.KilowattHours())
+}
+
+// Power is stored in kilowatts. Negative values indicate power flowing into the battery, such as
+// from regenerative braking.
+type Power float64
+
+// Kilowatts creates a Power from a value in kilowatts.
+func Kilowatts(kw float64) Power {
+	return Power(kw)
+}
+
+// Kilowatts returns the power in kilowatts.
+func (p Power) Kilowatts() float64 {
+	return float64(p)
+}
+
+// Watts returns the power in watts.
+func (p Power) Watts() float64 {
+	return float64(p) * 1000
+}
+
+func (p Power) String() string {
+	return fmt.Sprintf("%.0f kW", p.Kilowatts())
+}
+
+// DistanceUnit returns the unit the vehicle uses to display distances and speeds.
+func (s *GUISettings) DistanceUnit() DistanceUnit {
+	if strings.HasPrefix(s.GUIDistanceUnits, "km") {
+		return DistanceUnitKilometers
+	}
+
+	return DistanceUnitMiles
+}
+
+// TemperatureUnit returns the unit the vehicle uses to display temperatures.
+func (s *GUISettings) TemperatureUnit() TemperatureUnit {
+	if s.GUITemperatureUnits == "F" {
+		return TemperatureUnitFahrenheit
+	}
+
+	return TemperatureUnitCelsius
+}
+
+// FormatDistance formats the distance the same way the vehicle displays it.
+func (s *GUISettings) FormatDistance(d Distance) string {
+	return d.Format(s.DistanceUnit())
+}
+
+// FormatSpeed formats the speed the same way the vehicle displays it.
+func (s *GUISettings) FormatSpeed(speed Speed) string {
+	return speed.Format(s.DistanceUnit())
+}
+
+// FormatTemperature formats the temperature the same way the vehicle displays it.
+func (s *GUISettings) FormatTemperature(t Temperature) string {
+	return t.Format(s.TemperatureUnit())
+}
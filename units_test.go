@@ -0,0 +1,137 @@
+package tesla
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		distance  Distance
+		unit      DistanceUnit
+		value     float64
+		formatted string
+	}{
+		{name: "miles", distance: Miles(100), unit: DistanceUnitMiles, value: 100, formatted: "100.0 mi"},
+		{name: "kilometers", distance: Miles(100), unit: DistanceUnitKilometers, value: 160.9344, formatted: "160.9 km"},
+		{name: "from kilometers", distance: Kilometers(160.9344), unit: DistanceUnitMiles, value: 100, formatted: "100.0 mi"},
+		{name: "unknown unit", distance: Miles(12.34), unit: DistanceUnit("furlongs"), value: 12.34, formatted: "12.3 mi"},
+		{name: "empty unit", distance: Miles(12.34), unit: DistanceUnit(""), value: 12.34, formatted: "12.3 mi"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.value, tt.distance.In(tt.unit), 1e-9)
+			assert.Equal(t, tt.formatted, tt.distance.Format(tt.unit))
+		})
+	}
+
+	assert.InDelta(t, 1.609344, Miles(1).Kilometers(), 1e-9)
+	assert.InDelta(t, 1, Kilometers(1.609344).Miles(), 1e-9)
+	assert.Equal(t, "1.0 mi", Miles(1).String())
+}
+
+func TestSpeed(t *testing.T) {
+	tests := []struct {
+		name      string
+		speed     Speed
+		unit      DistanceUnit
+		value     float64
+		formatted string
+	}{
+		{name: "miles per hour", speed: MilesPerHour(65), unit: DistanceUnitMiles, value: 65, formatted: "65 mph"},
+		{name: "kilometers per hour", speed: MilesPerHour(65), unit: DistanceUnitKilometers, value: 104.60736, formatted: "105 km/h"},
+		{name: "from kilometers per hour", speed: KilometersPerHour(100), unit: DistanceUnitKilometers, value: 100, formatted: "100 km/h"},
+		{name: "unknown unit", speed: MilesPerHour(30), unit: DistanceUnit("knots"), value: 30, formatted: "30 mph"},
+		{name: "empty unit", speed: MilesPerHour(30), unit: DistanceUnit(""), value: 30, formatted: "30 mph"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.value, tt.speed.In(tt.unit), 1e-9)
+			assert.Equal(t, tt.formatted, tt.speed.Format(tt.unit))
+		})
+	}
+
+	assert.InDelta(t, 62.137119, KilometersPerHour(100).MilesPerHour(), 1e-6)
+	assert.Equal(t, "65 mph", MilesPerHour(65).String())
+}
+
+func TestTemperature(t *testing.T) {
+	tests := []struct {
+		name        string
+		temperature Temperature
+		unit        TemperatureUnit
+		value       float64
+		formatted   string
+	}{
+		{name: "celsius", temperature: Celsius(21.5), unit: TemperatureUnitCelsius, value: 21.5, formatted: "21.5°C"},
+		{name: "fahrenheit", temperature: Celsius(21.5), unit: TemperatureUnitFahrenheit, value: 70.7, formatted: "70.7°F"},
+		{name: "from fahrenheit", temperature: Fahrenheit(212), unit: TemperatureUnitCelsius, value: 100, formatted: "100.0°C"},
+		{name: "freezing", temperature: Fahrenheit(32), unit: TemperatureUnitFahrenheit, value: 32, formatted: "32.0°F"},
+		{name: "unknown unit", temperature: Celsius(-5), unit: TemperatureUnit("K"), value: -5, formatted: "-5.0°C"},
+		{name: "empty unit", temperature: Celsius(-5), unit: TemperatureUnit(""), value: -5, formatted: "-5.0°C"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.value, tt.temperature.In(tt.unit), 1e-9)
+			assert.Equal(t, tt.formatted, tt.temperature.Format(tt.unit))
+		})
+	}
+
+	assert.InDelta(t, 0, Fahrenheit(32).Celsius(), 1e-9)
+	assert.InDelta(t, 212, Celsius(100).Fahrenheit(), 1e-9)
+	assert.Equal(t, "21.5°C", Celsius(21.5).String())
+}
+
+func TestGUISettingsUnits(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    GUISettings
+		distance    DistanceUnit
+		temperature TemperatureUnit
+		formatted   [3]string
+	}{
+		{
+			name:        "imperial",
+			settings:    GUISettings{GUIDistanceUnits: "mi/hr", GUITemperatureUnits: "F"},
+			distance:    DistanceUnitMiles,
+			temperature: TemperatureUnitFahrenheit,
+			formatted:   [3]string{"100.0 mi", "62 mph", "68.0°F"},
+		},
+		{
+			name:        "metric",
+			settings:    GUISettings{GUIDistanceUnits: "km/hr", GUITemperatureUnits: "C"},
+			distance:    DistanceUnitKilometers,
+			temperature: TemperatureUnitCelsius,
+			formatted:   [3]string{"160.9 km", "100 km/h", "20.0°C"},
+		},
+		{
+			name:        "unknown",
+			settings:    GUISettings{GUIDistanceUnits: "leagues", GUITemperatureUnits: "K"},
+			distance:    DistanceUnitMiles,
+			temperature: TemperatureUnitCelsius,
+			formatted:   [3]string{"100.0 mi", "62 mph", "20.0°C"},
+		},
+		{
+			name:        "empty",
+			settings:    GUISettings{},
+			distance:    DistanceUnitMiles,
+			temperature: TemperatureUnitCelsius,
+			formatted:   [3]string{"100.0 mi", "62 mph", "20.0°C"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.distance, tt.settings.DistanceUnit())
+			assert.Equal(t, tt.temperature, tt.settings.TemperatureUnit())
+			assert.Equal(t, tt.formatted[0], tt.settings.FormatDistance(Miles(100)))
+			assert.Equal(t, tt.formatted[1], tt.settings.FormatSpeed(KilometersPerHour(100)))
+			assert.Equal(t, tt.formatted[2], tt.settings.FormatTemperature(Celsius(20)))
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package tesla
+
+import (
+	"fmt"
+)
+
+// ValidationError is returned when a command parameter is rejected before the request is sent.
+type ValidationError struct {
+	Param  string
+	Value  interface{}
+	Reason string
+}
+
+func (err *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s %v: %s", err.Param, err.Value, err.Reason)
+}
+
+// Unwrap allows errors.Is(err, ErrInvalidParameter) to match any ValidationError.
+func (err *ValidationError) Unwrap() error {
+	return ErrInvalidParameter
+}
+
+// commandLimits holds the parameter limits reported by the vehicle in its most recent state.
+type commandLimits struct {
+	chargeKnown bool
+	chargeMin   int
+	chargeMax   int
+
+	tempKnown bool
+	tempMin   float64
+	tempMax   float64
+
+	speedKnown bool
+	speedMin   int
+	speedMax   int
+}
+
+func (c *Conn) vehicleLimits(id int) commandLimits {
+	c.limitsMu.RLock()
+	defer c.limitsMu.RUnlock()
+
+	return c.limits[id]
+}
+
+func (c *Conn) updateLimits(id int, update func(*commandLimits)) {
+	c.limitsMu.Lock()
+	defer c.limitsMu.Unlock()
+
+	if c.limits == nil {
+		c.limits = make(map[int]commandLimits)
+	}
+
+	limits := c.limits[id]
+	update(&limits)
+	c.limits[id] = limits
+}
+
+func (c *Conn) learnChargeLimits(id int, state *ChargeState) {
+	if state.ChargeLimitSocMin == 0 && state.ChargeLimitSocMax == 0 {
+		return
+	}
+
+	c.updateLimits(id, func(l *commandLimits) {
+		l.chargeKnown = true
+		l.chargeMin = state.ChargeLimitSocMin
+		l.chargeMax = state.ChargeLimitSocMax
+	})
+}
+
+func (c *Conn) learnTemperatureLimits(id int, state *ClimateState) {
+	if state.MinAvailTemp == 0 && state.MaxAvailTemp == 0 {
+		return
+	}
+
+	c.updateLimits(id, func(l *commandLimits) {
+		l.tempKnown = true
+		l.tempMin = state.MinAvailTemp
+		l.tempMax = state.MaxAvailTemp
+	})
+}
+
+func (c *Conn) learnSpeedLimits(id int, state *VehicleState) {
+	if state.SpeedLimitMode.MinLimitMph == 0 && state.SpeedLimitMode.MaxLimitMph == 0 {
+		return
+	}
+
+	c.updateLimits(id, func(l *commandLimits) {
+		l.speedKnown = true
+		l.speedMin = state.SpeedLimitMode.MinLimitMph
+		l.speedMax = state.SpeedLimitMode.MaxLimitMph
+	})
+}
+
+func (c *Conn) validateChargeLimit(id int, percent int) error {
+	min, max := 0, 100
+
+	if limits := c.vehicleLimits(id); limits.chargeKnown {
+		min, max = limits.chargeMin, limits.chargeMax
+	}
+
+	if percent < min || percent > max {
+		return fmt.Errorf("%w", &ValidationError{
+			Param:  "charge limit",
+			Value:  percent,
+			Reason: fmt.Sprintf("must be between %d and %d", min, max),
+		})
+	}
+
+	return nil
+}
+
+func (c *Conn) validateTemperature(id int, param string, celsius float64) error {
+	limits := c.vehicleLimits(id)
+	if !limits.tempKnown {
+		return nil
+	}
+
+	if celsius < limits.tempMin || celsius > limits.tempMax {
+		return fmt.Errorf("%w", &ValidationError{
+			Param:  param,
+			Value:  celsius,
+			Reason: fmt.Sprintf("must be between %.1f and %.1f", limits.tempMin, limits.tempMax),
+		})
+	}
+
+	return nil
+}
+
+func (c *Conn) validateSpeedLimit(id int, limitMPH int) error {
+	limits := c.vehicleLimits(id)
+	if !limits.speedKnown {
+		return nil
+	}
+
+	if limitMPH < limits.speedMin || limitMPH > limits.speedMax {
+		return fmt.Errorf("%w", &ValidationError{
+			Param:  "speed limit",
+			Value:  limitMPH,
+			Reason: fmt.Sprintf("must be between %d and %d mph", limits.speedMin, limits.speedMax),
+		})
+	}
+
+	return nil
+}
+
+// validatePIN returns an error unless pin is exactly four digits.
+func validatePIN(pin string) error {
+	valid := len(pin) == 4
+
+	for i := 0; valid && i < len(pin); i++ {
+		valid = pin[i] >= '0' && pin[i] <= '9'
+	}
+
+	if !valid {
+		return fmt.Errorf("%w", &ValidationError{
+			Param:  "pin",
+			Value:  "****",
+			Reason: "must be exactly 4 digits",
+		})
+	}
+
+	return nil
+}
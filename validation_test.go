@@ -0,0 +1,118 @@
+package tesla
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidation(t *testing.T) {
+	loadCharge := func(conn *Conn) error {
+		_, err := conn.GetChargeState(1)
+		return err
+	}
+
+	loadClimate := func(conn *Conn) error {
+		_, err := conn.GetClimateState(1)
+		return err
+	}
+
+	loadState := func(conn *Conn) error {
+		_, err := conn.GetVehicleState(1)
+		return err
+	}
+
+	chargeLimit := func(percent int) func(conn *Conn) error {
+		return func(conn *Conn) error { return conn.SetChargeLimit(1, percent) }
+	}
+
+	temperatures := func(driver, passenger float64) func(conn *Conn) error {
+		return func(conn *Conn) error { return conn.SetTemperatures(1, driver, passenger) }
+	}
+
+	speedLimit := func(mph int) func(conn *Conn) error {
+		return func(conn *Conn) error { return conn.SpeedLimitSetLimit(1, mph) }
+	}
+
+	speedLimitPIN := func(pin string) func(conn *Conn) error {
+		return func(conn *Conn) error { return conn.SpeedLimitActivate(1, pin) }
+	}
+
+	tests := []struct {
+		name string
+		load func(conn *Conn) error
+		run  func(conn *Conn) error
+		// param is the rejected parameter, or empty if the command should be sent.
+		param string
+	}{
+		{name: "charge limit default minimum", run: chargeLimit(0)},
+		{name: "charge limit default maximum", run: chargeLimit(100)},
+		{name: "charge limit below default", run: chargeLimit(-1), param: "charge limit"},
+		{name: "charge limit above default", run: chargeLimit(101), param: "charge limit"},
+		{name: "charge limit cached minimum", load: loadCharge, run: chargeLimit(50)},
+		{name: "charge limit cached maximum", load: loadCharge, run: chargeLimit(90)},
+		{name: "charge limit below cached", load: loadCharge, run: chargeLimit(49), param: "charge limit"},
+		{name: "charge limit above cached", load: loadCharge, run: chargeLimit(91), param: "charge limit"},
+
+		{name: "temperature not yet known", run: temperatures(40, 5)},
+		{name: "temperature cached minimum", load: loadClimate, run: temperatures(15, 15)},
+		{name: "temperature cached maximum", load: loadClimate, run: temperatures(28, 28)},
+		{name: "driver temperature too low", load: loadClimate, run: temperatures(14.5, 20), param: "driver temperature"},
+		{name: "passenger temperature too high", load: loadClimate, run: temperatures(20, 28.5), param: "passenger temperature"},
+
+		{name: "speed limit not yet known", run: speedLimit(100)},
+		{name: "speed limit cached minimum", load: loadState, run: speedLimit(50)},
+		{name: "speed limit cached maximum", load: loadState, run: speedLimit(90)},
+		{name: "speed limit below cached", load: loadState, run: speedLimit(49), param: "speed limit"},
+		{name: "speed limit above cached", load: loadState, run: speedLimit(91), param: "speed limit"},
+
+		{name: "pin", run: speedLimitPIN("1234")},
+		{name: "pin too short", run: speedLimitPIN("123"), param: "pin"},
+		{name: "pin too long", run: speedLimitPIN("12345"), param: "pin"},
+		{name: "pin not digits", run: speedLimitPIN("12a4"), param: "pin"},
+		{
+			name:  "valet pin not digits",
+			run:   func(conn *Conn) error { return conn.SetValetMode(1, true, "abcd") },
+			param: "pin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, api := newFakeConn()
+			api.states["charge_state"] = `{"charge_limit_soc_min": 50, "charge_limit_soc_max": 90}`
+			api.states["climate_state"] = `{"min_avail_temp": 15, "max_avail_temp": 28}`
+			api.states["vehicle_state"] = `{"speed_limit_mode": {"min_limit_mph": 50, "max_limit_mph": 90}}`
+
+			if tt.load != nil {
+				require.NoError(t, tt.load(conn))
+			}
+
+			err := tt.run(conn)
+
+			if tt.param == "" {
+				assert.NoError(t, err)
+				assert.Len(t, api.commands(), 1)
+				return
+			}
+
+			assert.True(t, errors.Is(err, ErrInvalidParameter), "%v", err)
+
+			var verr *ValidationError
+			if assert.True(t, errors.As(err, &verr), "%v", err) {
+				assert.Equal(t, tt.param, verr.Param)
+			}
+
+			assert.Empty(t, api.commands(), "no command is sent")
+		})
+	}
+}
+
+func TestValidationErrorMasksPIN(t *testing.T) {
+	conn, _ := newFakeConn()
+
+	err := conn.SpeedLimitClearPin(1, "12a4")
+	assert.EqualError(t, err, "invalid pin ****: must be exactly 4 digits")
+}
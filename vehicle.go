@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Vehicle represents the basic data about the vehicle.
@@ -20,12 +22,48 @@ type Vehicle struct {
 	APIVersion             int      `json:"api_version"`
 	BackseatToken          *string  `json:"backseat_token"`
 	BackseatTokenUpdatedAt *int     `json:"backseat_token_updated_at"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the vehicle, keeping any unrecognized fields in Extra.
+func (v *Vehicle) UnmarshalJSON(data []byte) error {
+	type plain Vehicle
+
+	extra, err := unmarshalExtra(data, (*plain)(v))
+	if err != nil {
+		return err
+	}
+
+	v.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the vehicle, including any fields in Extra.
+func (v Vehicle) MarshalJSON() ([]byte, error) {
+	type plain Vehicle
+
+	return marshalExtra(plain(v), v.Extra)
+}
+
+// BackseatTokenUpdatedTime returns when the backseat token was last updated, or the zero time if
+// it is not set.
+func (v *Vehicle) BackseatTokenUpdatedTime() time.Time {
+	return timeFromSecondsPtr(v.BackseatTokenUpdatedAt)
 }
 
 // GetVehicles retrieves a list of vehicles for the currently authenticated account.
 func (c *Conn) GetVehicles() ([]Vehicle, error) {
+	val, _, err := c.GetVehiclesRaw()
+	return val, err
+}
+
+// GetVehiclesRaw is like GetVehicles, but also returns the raw HTTP response.
+func (c *Conn) GetVehiclesRaw() ([]Vehicle, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -35,18 +73,24 @@ func (c *Conn) GetVehicles() ([]Vehicle, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, "/api/1/vehicles", nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, "/api/1/vehicles", nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return respBody.Response, nil
+	return respBody.Response, raw, nil
 }
 
 // GetVehicle returns a vehicle by id.
 func (c *Conn) GetVehicle(id int) (*Vehicle, error) {
+	val, _, err := c.GetVehicleRaw(id)
+	return val, err
+}
+
+// GetVehicleRaw is like GetVehicle, but also returns the raw HTTP response.
+func (c *Conn) GetVehicleRaw(id int) (*Vehicle, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -55,10 +99,10 @@ func (c *Conn) GetVehicle(id int) (*Vehicle, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	return &respBody.Response, raw, nil
 }
@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // VehicleConfig represents the current capabilities of the vehicle.
@@ -31,12 +33,47 @@ type VehicleConfig struct {
 	Timestamp                   int64  `json:"timestamp"`
 	TrimBadging                 string `json:"trim_badging"`
 	WheelType                   string `json:"wheel_type"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the config, keeping any unrecognized fields in Extra.
+func (c *VehicleConfig) UnmarshalJSON(data []byte) error {
+	type plain VehicleConfig
+
+	extra, err := unmarshalExtra(data, (*plain)(c))
+	if err != nil {
+		return err
+	}
+
+	c.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the config, including any fields in Extra.
+func (c VehicleConfig) MarshalJSON() ([]byte, error) {
+	type plain VehicleConfig
+
+	return marshalExtra(plain(c), c.Extra)
+}
+
+// Time returns when the config was recorded by the vehicle.
+func (c *VehicleConfig) Time() time.Time {
+	return timeFromMillis(c.Timestamp)
 }
 
 // GetVehicleConfig retrieves the vehicles config.
 func (c *Conn) GetVehicleConfig(id int) (*VehicleConfig, error) {
+	val, _, err := c.GetVehicleConfigRaw(id)
+	return val, err
+}
+
+// GetVehicleConfigRaw is like GetVehicleConfig, but also returns the raw HTTP response.
+func (c *Conn) GetVehicleConfigRaw(id int) (*VehicleConfig, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -45,10 +82,12 @@ func (c *Conn) GetVehicleConfig(id int) (*VehicleConfig, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/vehicle_config", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/vehicle_config", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	c.learnCapabilities(id, &respBody.Response, nil)
+
+	return &respBody.Response, raw, nil
 }
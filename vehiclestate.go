@@ -1,8 +1,10 @@
 package tesla
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // VehicleState represents the current vehicle state.
@@ -62,12 +64,58 @@ type VehicleState struct {
 	ValetMode                bool   `json:"valet_mode"`
 	ValetPinNeeded           bool   `json:"valet_pin_needed"`
 	VehicleName              string `json:"vehicle_name"`
+
+	// Extra holds any fields returned by the API that are not decoded above.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the vehicle state, keeping any unrecognized fields in Extra.
+func (s *VehicleState) UnmarshalJSON(data []byte) error {
+	type plain VehicleState
+
+	extra, err := unmarshalExtra(data, (*plain)(s))
+	if err != nil {
+		return err
+	}
+
+	s.Extra = extra
+
+	return nil
+}
+
+// MarshalJSON encodes the vehicle state, including any fields in Extra.
+func (s VehicleState) MarshalJSON() ([]byte, error) {
+	type plain VehicleState
+
+	return marshalExtra(plain(s), s.Extra)
+}
+
+// OdometerDistance returns the total distance the vehicle has driven.
+func (s *VehicleState) OdometerDistance() Distance {
+	return Miles(s.Odometer)
+}
+
+// Time returns when the state was recorded by the vehicle.
+func (s *VehicleState) Time() time.Time {
+	return timeFromMillis(s.Timestamp)
+}
+
+// SoftwareUpdateScheduledTime returns when the pending software update is scheduled to install,
+// or the zero time if none is scheduled.
+func (s *VehicleState) SoftwareUpdateScheduledTime() time.Time {
+	return timeFromMillis(s.SoftwareUpdate.ScheduledTimeMs)
 }
 
 // GetVehicleState retrieves the given vehicles current state.
 func (c *Conn) GetVehicleState(id int) (*VehicleState, error) {
+	val, _, err := c.GetVehicleStateRaw(id)
+	return val, err
+}
+
+// GetVehicleStateRaw is like GetVehicleState, but also returns the raw HTTP response.
+func (c *Conn) GetVehicleStateRaw(id int) (*VehicleState, *RawResponse, error) {
 	if c.accessToken == "" {
-		return nil, fmt.Errorf("%w", ErrMissingAccessToken)
+		return nil, nil, fmt.Errorf("%w", ErrMissingAccessToken)
 	}
 
 	type response struct {
@@ -76,10 +124,13 @@ func (c *Conn) GetVehicleState(id int) (*VehicleState, error) {
 
 	var respBody response
 
-	err := c.doRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/vehicle_state", id), nil, &respBody)
+	raw, err := c.doRawRequest(http.MethodGet, fmt.Sprintf("/api/1/vehicles/%d/data_request/vehicle_state", id), nil, &respBody)
 	if err != nil {
-		return nil, err
+		return nil, raw, err
 	}
 
-	return &respBody.Response, nil
+	c.learnCapabilities(id, nil, &respBody.Response)
+	c.learnSpeedLimits(id, &respBody.Response)
+
+	return &respBody.Response, raw, nil
 }
@@ -0,0 +1,202 @@
+package tesla
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VINInfo is the decoded form of a Tesla vehicle identification number.
+type VINInfo struct {
+	VIN             string
+	Manufacturer    string
+	Model           string
+	BodyType        string
+	RestraintSystem string
+	BatteryType     string
+	MotorType       string
+	ModelYear       int
+	Plant           string
+	Serial          string
+	CheckDigit      byte
+}
+
+var vinManufacturers = map[string]string{
+	"5YJ": "Tesla, Inc. (Fremont)",
+	"7SA": "Tesla, Inc.",
+	"7G2": "Tesla, Inc. (Semi)",
+	"LRW": "Tesla Shanghai",
+	"XP7": "Tesla Germany",
+	"SFZ": "Tesla Motors (Roadster)",
+}
+
+var vinModels = map[byte]string{
+	'S': "Model S",
+	'X': "Model X",
+	'3': "Model 3",
+	'Y': "Model Y",
+	'R': "Roadster",
+	'C': "Cybertruck",
+	'T': "Semi",
+}
+
+var vinBodyTypes = map[byte]string{
+	'A': "5-door hatchback, left-hand drive",
+	'B': "5-door hatchback, right-hand drive",
+	'C': "5-door MPV, left-hand drive",
+	'D': "5-door MPV, right-hand drive",
+	'E': "4-door sedan, left-hand drive",
+	'F': "4-door sedan, right-hand drive",
+	'G': "5-door SUV, left-hand drive",
+	'H': "5-door SUV, right-hand drive",
+}
+
+var vinRestraintSystems = map[byte]string{
+	'1': "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+	'3': "Manual seatbelts, front airbags, PODS, side curtain airbags",
+	'4': "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+	'5': "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+	'6': "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+	'7': "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+	'A': "Manual seatbelts, front airbags, side curtain airbags, knee airbags",
+	'B': "Manual seatbelts, front airbags, side curtain airbags",
+	'C': "Manual seatbelts, front airbags, side curtain airbags, knee airbags",
+	'D': "Manual seatbelts, front airbags, side curtain airbags",
+}
+
+var vinBatteryTypes = map[byte]string{
+	'E': "Electric (NMC)",
+	'F': "Lithium iron phosphate (LFP)",
+	'H': "High capacity",
+	'S': "Standard capacity",
+	'V': "Ultra high capacity",
+}
+
+var vinMotorTypes = map[byte]string{
+	'1': "Single motor, standard",
+	'2': "Dual motor, standard",
+	'3': "Single motor, performance",
+	'4': "Dual motor, performance",
+	'5': "Dual motor, standard",
+	'6': "Dual motor, performance",
+	'A': "Single motor",
+	'B': "Dual motor, standard",
+	'C': "Dual motor, performance",
+	'D': "Single motor, rear-wheel drive",
+	'E': "Dual motor, all-wheel drive",
+	'F': "Dual motor, performance",
+	'J': "Single motor, standard",
+	'K': "Dual motor, standard",
+	'L': "Dual motor, performance",
+}
+
+var vinPlants = map[byte]string{
+	'A': "Austin, Texas",
+	'B': "Berlin, Germany",
+	'C': "Shanghai, China",
+	'F': "Fremont, California",
+	'N': "Reno, Nevada",
+	'P': "Palo Alto, California",
+	'R': "Research",
+}
+
+// vinModelYears maps the tenth character of a VIN to a model year. Codes cycle every 30 years, so
+// digits are mapped to the first years of Tesla production and letters to the current cycle.
+var vinModelYears = map[byte]int{
+	'8': 2008, '9': 2009,
+	'A': 2010, 'B': 2011, 'C': 2012, 'D': 2013, 'E': 2014, 'F': 2015, 'G': 2016, 'H': 2017,
+	'J': 2018, 'K': 2019, 'L': 2020, 'M': 2021, 'N': 2022, 'P': 2023, 'R': 2024, 'S': 2025,
+	'T': 2026, 'V': 2027, 'W': 2028, 'X': 2029, 'Y': 2030,
+}
+
+var vinWeights = [17]int{8, 7, 6, 5, 4, 3, 2, 10, 0, 9, 8, 7, 6, 5, 4, 3, 2}
+
+// vinValue returns the transliterated value of a VIN character used to compute the check digit.
+func vinValue(c byte) (int, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0'), true
+	case c >= 'A' && c <= 'H':
+		return int(c-'A') + 1, true
+	case c >= 'J' && c <= 'N':
+		return int(c-'J') + 1, true
+	case c == 'P':
+		return 7, true
+	case c == 'R':
+		return 9, true
+	case c >= 'S' && c <= 'Z':
+		return int(c-'S') + 2, true
+	}
+
+	return 0, false
+}
+
+// VINCheckDigit computes the expected check digit (position 9) for the given VIN.
+func VINCheckDigit(vin string) (byte, error) {
+	vin = strings.ToUpper(vin)
+
+	if len(vin) != 17 {
+		return 0, fmt.Errorf("vin must be 17 characters, got %d: %w", len(vin), ErrInvalidVIN)
+	}
+
+	sum := 0
+
+	for i := 0; i < len(vin); i++ {
+		val, ok := vinValue(vin[i])
+		if !ok {
+			return 0, fmt.Errorf("invalid character %q at position %d: %w", vin[i], i+1, ErrInvalidVIN)
+		}
+
+		sum += val * vinWeights[i]
+	}
+
+	rem := sum % 11
+	if rem == 10 {
+		return 'X', nil
+	}
+
+	return byte('0' + rem), nil
+}
+
+// ValidVIN returns true if the VIN is well formed and its check digit matches.
+func ValidVIN(vin string) bool {
+	vin = strings.TrimSpace(vin)
+
+	check, err := VINCheckDigit(vin)
+	if err != nil {
+		return false
+	}
+
+	return strings.ToUpper(vin)[8] == check
+}
+
+// DecodeVIN decodes a Tesla VIN. Unrecognized positions are left empty rather than treated as an
+// error, as Tesla regularly introduces new codes. The check digit is not validated; use ValidVIN
+// for that, as vehicles built for some markets do not use one.
+func DecodeVIN(vin string) (*VINInfo, error) {
+	vin = strings.ToUpper(strings.TrimSpace(vin))
+
+	if _, err := VINCheckDigit(vin); err != nil {
+		return nil, err
+	}
+
+	info := &VINInfo{
+		VIN:             vin,
+		Manufacturer:    vinManufacturers[vin[0:3]],
+		Model:           vinModels[vin[3]],
+		BodyType:        vinBodyTypes[vin[4]],
+		RestraintSystem: vinRestraintSystems[vin[5]],
+		BatteryType:     vinBatteryTypes[vin[6]],
+		MotorType:       vinMotorTypes[vin[7]],
+		CheckDigit:      vin[8],
+		ModelYear:       vinModelYears[vin[9]],
+		Plant:           vinPlants[vin[10]],
+		Serial:          vin[11:],
+	}
+
+	return info, nil
+}
+
+// DecodeVIN decodes the vehicle's VIN.
+func (v *Vehicle) DecodeVIN() (*VINInfo, error) {
+	return DecodeVIN(v.VIN)
+}
@@ -0,0 +1,138 @@
+package tesla
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeVIN(t *testing.T) {
+	tests := []struct {
+		name     string
+		vin      string
+		expected VINInfo
+	}{
+		{
+			name: "model s fremont",
+			vin:  "5YJSA1E27HF000001",
+			expected: VINInfo{
+				VIN:             "5YJSA1E27HF000001",
+				Manufacturer:    "Tesla, Inc. (Fremont)",
+				Model:           "Model S",
+				BodyType:        "5-door hatchback, left-hand drive",
+				RestraintSystem: "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+				BatteryType:     "Electric (NMC)",
+				MotorType:       "Dual motor, standard",
+				CheckDigit:      '7',
+				ModelYear:       2017,
+				Plant:           "Fremont, California",
+				Serial:          "000001",
+			},
+		},
+		{
+			name: "model 3 lowercase",
+			vin:  "5yj3e1ea2kf317000",
+			expected: VINInfo{
+				VIN:             "5YJ3E1EA2KF317000",
+				Manufacturer:    "Tesla, Inc. (Fremont)",
+				Model:           "Model 3",
+				BodyType:        "4-door sedan, left-hand drive",
+				RestraintSystem: "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+				BatteryType:     "Electric (NMC)",
+				MotorType:       "Single motor",
+				CheckDigit:      '2',
+				ModelYear:       2019,
+				Plant:           "Fremont, California",
+				Serial:          "317000",
+			},
+		},
+		{
+			name: "model y austin",
+			vin:  "7SAYGDEF2NA000321",
+			expected: VINInfo{
+				VIN:             "7SAYGDEF2NA000321",
+				Manufacturer:    "Tesla, Inc.",
+				Model:           "Model Y",
+				BodyType:        "5-door SUV, left-hand drive",
+				RestraintSystem: "Manual seatbelts, front airbags, side curtain airbags",
+				BatteryType:     "Electric (NMC)",
+				MotorType:       "Dual motor, performance",
+				CheckDigit:      '2',
+				ModelYear:       2022,
+				Plant:           "Austin, Texas",
+				Serial:          "000321",
+			},
+		},
+		{
+			name: "model 3 shanghai",
+			vin:  "LRW3E7FS4MC000777",
+			expected: VINInfo{
+				VIN:             "LRW3E7FS4MC000777",
+				Manufacturer:    "Tesla Shanghai",
+				Model:           "Model 3",
+				BodyType:        "4-door sedan, left-hand drive",
+				RestraintSystem: "Manual seatbelts, front airbags, PODS, side curtain airbags, knee airbags",
+				BatteryType:     "Lithium iron phosphate (LFP)",
+				MotorType:       "",
+				CheckDigit:      '4',
+				ModelYear:       2021,
+				Plant:           "Shanghai, China",
+				Serial:          "000777",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := DecodeVIN(tt.vin)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *info)
+		})
+	}
+}
+
+func TestDecodeVINInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		vin  string
+	}{
+		{name: "empty", vin: ""},
+		{name: "too short", vin: "5YJSA1E27HF00000"},
+		{name: "too long", vin: "5YJSA1E27HF0000011"},
+		{name: "contains I", vin: "5YJSA1E27HF00000I"},
+		{name: "contains O", vin: "5YJSA1E27HF00000O"},
+		{name: "contains Q", vin: "5YJSA1E27HF00000Q"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeVIN(tt.vin)
+			assert.True(t, errors.Is(err, ErrInvalidVIN))
+		})
+	}
+}
+
+func TestValidVIN(t *testing.T) {
+	tests := []struct {
+		vin      string
+		expected bool
+	}{
+		{vin: "5YJSA1E27HF000001", expected: true},
+		{vin: "5YJ3E1EA2KF317000", expected: true},
+		{vin: "5YJXCBE24GF000123", expected: true},
+		{vin: "5YJSA1E22HF000001", expected: false},
+		{vin: "5YJ3E1EA7KF317000", expected: false},
+		{vin: "5YJSA1E27HF00000", expected: false},
+		{vin: "1M8GDM9AXKP042788", expected: true},
+		{vin: " 5yjsa1e27hf000001\n", expected: true},
+		{vin: "", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.vin, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ValidVIN(tt.vin))
+		})
+	}
+}